@@ -0,0 +1,18 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"never-price-match-server/internal/app"
+)
+
+// dedupe is a one-off tool that collapses duplicate product listings left by
+// earlier versions, which inserted a new row on every scrape.
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report what would change without writing")
+	flag.Parse()
+
+	if err := app.RunDedupe(*dryRun); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"never-price-match-server/internal/app"
+)
+
+// prune applies the data retention policy from the `retention` config once:
+// old price history is downsampled to daily points and listings that haven't
+// been scraped for a long time are deleted.
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report what would be deleted without writing")
+	flag.Parse()
+
+	if err := app.RunPrune(*dryRun); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -3,9 +3,18 @@ package main
 import (
 	"log"
 	"never-price-match-server/internal/app"
+	"os"
 )
 
 func main() {
+	// `server migrate up|down|status` manages the schema; anything else starts the server.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := app.RunMigrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if err := app.RunFull(); err != nil {
 		log.Fatal(err)
 	}
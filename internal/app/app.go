@@ -0,0 +1,256 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"never-price-match-server/internal/evidence"
+	"never-price-match-server/internal/infra/db"
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/infra/metrics"
+	"never-price-match-server/internal/infra/repo"
+	"never-price-match-server/internal/notify"
+	"never-price-match-server/internal/pricematch"
+	"never-price-match-server/internal/product"
+	"never-price-match-server/internal/purchase"
+	"never-price-match-server/internal/refresh"
+	"never-price-match-server/internal/retention"
+	"never-price-match-server/internal/scrapejob"
+	"never-price-match-server/internal/searchlog"
+	"never-price-match-server/internal/user"
+	"never-price-match-server/internal/watchlist"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// defaultMaxTabs is how many scrapes share the browser at once when scraper.max_tabs is unset.
+const defaultMaxTabs = 2
+
+// Browser is the shared headless browser as the App manages it; *product.Browser is one.
+type Browser interface {
+	// Close shuts the browser down; scrapes still running fail.
+	Close()
+}
+
+// App owns the configuration, infrastructure and services of one server instance.
+// New builds all of it from the config files; tests can instead fill in only the
+// fields they need, fakes included, and call Handler.
+type App struct {
+	Config  *viper.Viper
+	Log     *logger.Logger
+	DB      *gorm.DB
+	Browser Browser
+
+	Users      user.Service
+	Products   product.Service
+	SearchLog  searchlog.Service
+	Refresher  refresh.Scheduler // nil when refresh.enabled is false
+	Jobs       scrapejob.Service
+	PriceMatch pricematch.Service
+	Evidence   evidence.Service
+	Purchases  purchase.Service
+	Watchlists watchlist.Service
+	Notify     notify.Service
+	Retention  retention.Service // nil when retention.enabled is false
+
+	server *http.Server
+	// debugServer serves metrics on server.debug_addr; nil when that is unset.
+	debugServer *http.Server
+	stopJobs    context.CancelFunc
+}
+
+// open loads the configuration and connects the logger and database,
+// which is all the maintenance commands need.
+func open() (*App, error) {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "local"
+	}
+	a := &App{Log: logger.New(env), Config: loadConfig()}
+
+	gdb, err := db.Connect(a.Config, configEnv(a.Config))
+	if err != nil {
+		_ = a.Shutdown(context.Background())
+		return nil, err
+	}
+	a.DB = gdb
+	return a, nil
+}
+
+// New builds a complete application: it applies pending migrations, loads the
+// search and suggestion indexes and starts the background jobs.
+func New() (*App, error) {
+	a, err := open()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.init(); err != nil {
+		_ = a.Shutdown(context.Background())
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *App) init() error {
+	if err := migrateUp(a.DB, a.Log); err != nil {
+		return err
+	}
+
+	productRepo := repo.NewProductGormRepo(a.DB)
+	productIndex, err := newSearchIndex(a.Config, a.DB, productRepo, a.Log)
+	if err != nil {
+		return err
+	}
+	suggester := product.NewSuggester()
+	n, err := product.BuildIndex(context.Background(), productRepo, suggester)
+	if err != nil {
+		return err
+	}
+	a.Log.Info("suggestion index built", logger.Int("listings", n))
+
+	maxTabs := a.Config.GetInt("scraper.max_tabs")
+	if maxTabs == 0 {
+		maxTabs = defaultMaxTabs
+	}
+	browser := product.NewBrowser(maxTabs, a.Log)
+	a.Browser = browser
+
+	userRepo := repo.NewUserGormRepo(a.DB)
+	a.Users = user.NewService(userRepo, a.Log)
+	a.Notify, err = newNotifier(a, userRepo)
+	if err != nil {
+		return err
+	}
+	resultCache := product.NewLRUCache(a.Config.GetInt("search.cache_size"), a.Config.GetDuration("search.cache_ttl"))
+	metrics.Publish("result_cache_stats", func() any { return resultCache.Stats() })
+	a.Watchlists = watchlist.NewService(repo.NewWatchlistGormRepo(a.DB), productRepo, a.Notify, a.Log)
+	a.Products = product.NewService(productRepo, repo.NewTxRunner(a.DB), productIndex, resultCache, suggester, a.Watchlists, browser, a.Log)
+	a.PriceMatch = pricematch.NewService(a.Products)
+	a.Evidence = evidence.NewService(repo.NewEvidenceGormRepo(a.DB), a.Products, browser, evidence.Config{
+		TTL:     a.Config.GetDuration("evidence.ttl"),
+		BaseURL: a.Config.GetString("evidence.base_url"),
+	}, a.Log)
+	a.Purchases = purchase.NewService(repo.NewPurchaseGormRepo(a.DB), repo.NewTxRunner(a.DB), productRepo, a.Products, a.Notify, purchase.Config{
+		Interval: a.Config.GetDuration("purchases.check_interval"),
+	}, a.Log)
+	a.SearchLog = searchlog.NewService(repo.NewSearchLogGormRepo(a.DB), a.Log)
+
+	a.Jobs = scrapejob.NewService(repo.NewScrapeJobGormRepo(a.DB), repo.NewTxRunner(a.DB), a.Products, scrapejob.Config{
+		Workers:     a.Config.GetInt("jobs.workers"),
+		Lease:       a.Config.GetDuration("jobs.lease"),
+		MaxAttempts: a.Config.GetInt("jobs.max_attempts"),
+	}, a.Log)
+	a.Refresher, err = newRefreshScheduler(a, productRepo)
+	if err != nil {
+		return err
+	}
+
+	if a.Config.GetBool("retention.enabled") {
+		a.Retention = newRetention(a, a.Products)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopJobs = cancel
+	a.SearchLog.Start(ctx)
+	a.Jobs.Start(ctx)
+	a.Evidence.Start(ctx)
+	a.Purchases.Start(ctx)
+	a.Notify.Start(ctx)
+	if a.Refresher != nil {
+		a.Refresher.Start(ctx)
+	} else {
+		a.Log.Info("refresh scheduler disabled")
+	}
+	if a.Retention != nil {
+		a.Retention.Start(ctx)
+	}
+	return nil
+}
+
+// Shutdown stops the application in dependency order: it drains HTTP requests,
+// stops the background jobs and waits for them, closes the browser once nothing
+// scrapes any more, waits for pending writes, then closes the database.
+// Components that were never set are skipped.
+func (a *App) Shutdown(ctx context.Context) error {
+	var errs []error
+	if a.server != nil {
+		errs = append(errs, a.server.Shutdown(ctx))
+	}
+	if a.debugServer != nil {
+		errs = append(errs, a.debugServer.Shutdown(ctx))
+	}
+	if a.stopJobs != nil {
+		a.stopJobs()
+	}
+	if a.Refresher != nil {
+		a.Refresher.Close()
+	}
+	if a.Jobs != nil {
+		a.Jobs.Close()
+	}
+	if a.Retention != nil {
+		a.Retention.Close()
+	}
+	if a.Evidence != nil {
+		a.Evidence.Close()
+	}
+	if a.Purchases != nil {
+		a.Purchases.Close()
+	}
+	if a.Products != nil {
+		a.Products.Close()
+	}
+	if a.Browser != nil {
+		a.Browser.Close()
+	}
+	if a.Watchlists != nil {
+		a.Watchlists.Close()
+	}
+	if a.Notify != nil {
+		a.Notify.Close()
+	}
+	if a.SearchLog != nil {
+		a.SearchLog.Close()
+	}
+	if a.DB != nil {
+		if sqlDB, err := a.DB.DB(); err == nil {
+			errs = append(errs, sqlDB.Close())
+		}
+	}
+	if a.Log != nil {
+		_ = a.Log.Sync()
+	}
+	return errors.Join(errs...)
+}
+
+// loadConfig reads config/config.yaml, overlaid with config.<env>.yaml and
+// environment variables (database.type is read from DATABASE_TYPE).
+func loadConfig() *viper.Viper {
+	v := viper.New()
+	v.SetConfigName("config")
+	v.SetConfigType("yaml")
+	v.AddConfigPath("./config")
+	_ = v.ReadInConfig()
+
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	_ = v.BindEnv("APP_ENV")
+
+	if env := configEnv(v); env != "" {
+		v.SetConfigName("config." + env)
+		_ = v.MergeInConfig()
+	}
+	return v
+}
+
+// configEnv is the deployment environment: APP_ENV, falling back to the env key.
+func configEnv(v *viper.Viper) string {
+	if env := v.GetString("APP_ENV"); env != "" {
+		return env
+	}
+	return v.GetString("env")
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"never-price-match-server/internal/evidence"
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/product"
+	"never-price-match-server/internal/purchase"
+	"never-price-match-server/internal/refresh"
+	"never-price-match-server/internal/scrapejob"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// closeLog records the order components are closed in.
+type closeLog struct{ closed []string }
+
+func (l *closeLog) add(name string) { l.closed = append(l.closed, name) }
+
+// The fakes embed their service interface, so calling anything they don't
+// override panics.
+type fakeProducts struct {
+	product.Service
+	log         *closeLog
+	suggestions []string
+}
+
+func (f *fakeProducts) GetProductSuggestions(string) ([]string, error) { return f.suggestions, nil }
+func (f *fakeProducts) Close()                                         { f.log.add("products") }
+
+type fakeRefresher struct {
+	refresh.Scheduler
+	log *closeLog
+}
+
+func (f *fakeRefresher) Close() { f.log.add("refresher") }
+
+type fakeJobs struct {
+	scrapejob.Service
+	log      *closeLog
+	enqueued int
+}
+
+func (f *fakeJobs) Enqueue(context.Context, string, string, string) (*scrapejob.Job, error) {
+	f.enqueued++
+	return &scrapejob.Job{ID: "job-" + strconv.Itoa(f.enqueued)}, nil
+}
+
+func (f *fakeJobs) Close() { f.log.add("jobs") }
+
+type fakeEvidence struct {
+	evidence.Service
+	log *closeLog
+}
+
+func (f *fakeEvidence) Close() { f.log.add("evidence") }
+
+type fakePurchases struct {
+	purchase.Service
+	log *closeLog
+}
+
+func (f *fakePurchases) Close() { f.log.add("purchases") }
+
+type fakeBrowser struct{ log *closeLog }
+
+func (f *fakeBrowser) Close() { f.log.add("browser") }
+
+func newTestApp(log *closeLog) *App {
+	return &App{
+		Config:    viper.New(),
+		Log:       logger.Nop(),
+		Browser:   &fakeBrowser{log: log},
+		Products:  &fakeProducts{log: log, suggestions: []string{"AirPods Pro", "AirPods Max"}},
+		Refresher: &fakeRefresher{log: log},
+		Jobs:      &fakeJobs{log: log},
+		Evidence:  &fakeEvidence{log: log},
+		Purchases: &fakePurchases{log: log},
+	}
+}
+
+func TestAppWithFakes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := &closeLog{}
+	a := newTestApp(log)
+
+	body := strings.NewReader(`{"query":"{ productSuggestions(name: \"airpods\") }"}`)
+	req := httptest.NewRequest(http.MethodPost, "/graphql", body)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Data struct {
+			ProductSuggestions []string `json:"productSuggestions"`
+		} `json:"data"`
+		Errors []any `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) > 0 || !slices.Equal(resp.Data.ProductSuggestions, []string{"AirPods Pro", "AirPods Max"}) {
+		t.Errorf("unexpected response %s", rec.Body)
+	}
+
+	if err := a.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// The browser goes last, so nothing still scraping loses it mid-scrape.
+	want := []string{"refresher", "jobs", "evidence", "purchases", "products", "browser"}
+	if !slices.Equal(log.closed, want) {
+		t.Errorf("closed in order %v, want %v", log.closed, want)
+	}
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := newTestApp(&closeLog{})
+	h := a.Handler()
+
+	// startSearch allows 10 calls a minute per client.
+	var limited bool
+	for i := range 11 {
+		body := strings.NewReader(`{"query":"mutation { startSearch(name: \"airpods\", category: \"electronics\") }"}`)
+		req := httptest.NewRequest(http.MethodPost, "/graphql", body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", "203.0.113."+strconv.Itoa(i+1))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		limited = strings.Contains(rec.Body.String(), "too many requests")
+		if limited && i < 10 {
+			t.Fatalf("call %d limited: %s", i+1, rec.Body)
+		}
+	}
+	if !limited {
+		t.Error("a new X-Forwarded-For per call got past the limit")
+	}
+	if n := a.Jobs.(*fakeJobs).enqueued; n != 10 {
+		t.Errorf("enqueued %d searches, want 10", n)
+	}
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/infra/repo"
+)
+
+// RunDedupe collapses duplicate rows in the products table so the platform + link
+// unique key can be added. With dryRun set it only reports what it would do.
+func RunDedupe(dryRun bool) error {
+	a, err := open()
+	if err != nil {
+		return err
+	}
+	defer a.Shutdown(context.Background())
+
+	report, err := repo.DedupeProducts(a.DB, dryRun)
+	if err != nil {
+		return err
+	}
+	a.Log.Info("product dedupe finished",
+		logger.Field("dry_run", dryRun),
+		logger.Int("rows_scanned", report.RowsScanned),
+		logger.Int("duplicate_groups", report.DuplicateGroups),
+		logger.Int("rows_deleted", report.RowsDeleted),
+		logger.Int("links_rewritten", report.LinksRewritten),
+		logger.Int("observations_backfilled", report.ObservationsBackfilled),
+	)
+	return nil
+}
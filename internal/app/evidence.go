@@ -0,0 +1,49 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"never-price-match-server/internal/evidence"
+	"never-price-match-server/internal/infra/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// evidenceCSP only lets a pack document use its inline styles and screenshots, so
+// nothing scraped into it can load or run anything.
+const evidenceCSP = "default-src 'none'; img-src data:; style-src 'unsafe-inline'"
+
+// serveEvidence serves GET /evidence/:id: the pack's HTML document, or with
+// ?format=pdf the document printed to PDF.
+func (a *App) serveEvidence(c *gin.Context) {
+	pack, err := a.Evidence.Get(c.Request.Context(), c.Param("id"))
+	switch {
+	case errors.Is(err, evidence.ErrPackNotFound):
+		c.String(http.StatusNotFound, "evidence pack not found")
+		return
+	case errors.Is(err, evidence.ErrPackExpired):
+		c.String(http.StatusGone, "evidence pack expired")
+		return
+	case err != nil:
+		a.Log.Error("Failed to load evidence pack", logger.Err(err))
+		c.String(http.StatusInternalServerError, "failed to load evidence pack")
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Robots-Tag", "noindex")
+	if c.Query("format") == "pdf" {
+		pdf, err := a.Evidence.PDF(c.Request.Context(), pack)
+		if err != nil {
+			a.Log.Error("Failed to print evidence pack", logger.Str("pack", pack.ID), logger.Err(err))
+			c.String(http.StatusInternalServerError, "failed to print evidence pack")
+			return
+		}
+		c.Header("Content-Disposition", `inline; filename="price-match-evidence.pdf"`)
+		c.Data(http.StatusOK, "application/pdf", pdf)
+		return
+	}
+	c.Header("Content-Security-Policy", evidenceCSP)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", pack.Document)
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/infra/migrate"
+
+	"gorm.io/gorm"
+)
+
+// migrateUp applies all pending migrations and logs each one.
+func migrateUp(gdb *gorm.DB, log *logger.Logger) error {
+	applied, err := migrate.New(gdb, migrate.All).Up()
+	for _, m := range applied {
+		log.Info("migration applied", logger.Field("version", m.Version), logger.Str("name", m.Name))
+	}
+	return err
+}
+
+// RunMigrate implements the `migrate up|down [steps]|status` subcommand.
+func RunMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up | down [steps] | status")
+	}
+
+	a, err := open()
+	if err != nil {
+		return err
+	}
+	defer a.Shutdown(context.Background())
+	m := migrate.New(a.DB, migrate.All)
+
+	switch args[0] {
+	case "up":
+		return migrateUp(a.DB, a.Log)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := m.Down(steps)
+		for _, mg := range reverted {
+			a.Log.Info("migration reverted", logger.Field("version", mg.Version), logger.Str("name", mg.Name))
+		}
+		return err
+
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			state, at := "pending", ""
+			if s.Applied {
+				state, at = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, state, at)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"never-price-match-server/internal/infra/repo"
+	"never-price-match-server/internal/notify"
+	"never-price-match-server/internal/user"
+)
+
+// newNotifier builds the notification service from the `notifications` config. Email
+// is only offered when notifications.smtp.host is set.
+func newNotifier(a *App, users user.Repo) (notify.Service, error) {
+	senders := map[notify.Channel]notify.Sender{
+		notify.ChannelWebhook: notify.NewWebhookSender(a.Config.GetDuration("notifications.webhook_timeout")),
+	}
+	if host := a.Config.GetString("notifications.smtp.host"); host != "" {
+		email, err := notify.NewSMTPSender(notify.SMTPConfig{
+			Host:     host,
+			Port:     a.Config.GetInt("notifications.smtp.port"),
+			Username: a.Config.GetString("notifications.smtp.username"),
+			Password: a.Config.GetString("notifications.smtp.password"),
+			From:     a.Config.GetString("notifications.smtp.from"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		senders[notify.ChannelEmail] = email
+	} else {
+		a.Log.Info("email notifications disabled: no notifications.smtp.host")
+	}
+	return notify.NewService(repo.NewNotifyGormRepo(a.DB), users, senders, notify.Config{
+		PollInterval: a.Config.GetDuration("notifications.poll_interval"),
+		MaxAttempts:  a.Config.GetInt("notifications.max_attempts"),
+		RetryBackoff: a.Config.GetDuration("notifications.retry_backoff"),
+	}, a.Log), nil
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+
+	"never-price-match-server/internal/infra/repo"
+	"never-price-match-server/internal/product"
+	"never-price-match-server/internal/refresh"
+	"never-price-match-server/internal/searchlog"
+
+	"github.com/spf13/viper"
+)
+
+// newRefreshScheduler builds the background refresh scheduler from the `refresh` config,
+// or returns nil when refresh.enabled is false.
+func newRefreshScheduler(a *App, productRepo product.Repo) (refresh.Scheduler, error) {
+	if !a.Config.GetBool("refresh.enabled") {
+		return nil, nil
+	}
+	sources := refresh.Sources{Queries: trendingQueries{searches: a.SearchLog}, Watches: a.Watchlists}
+	return refresh.NewScheduler(repo.NewRefreshGormRepo(a.DB), productRepo, a.Products, sources, refreshConfig(a.Config), a.Log)
+}
+
+// refreshConfig reads the scheduler settings; anything unset falls back to refresh.DefaultConfig.
+// Viper lower-cases map keys, which is why budgets are keyed by lower-case platform name.
+func refreshConfig(cfg *viper.Viper) refresh.Config {
+	rc := refresh.Config{
+		Jitter:     cfg.GetDuration("refresh.jitter"),
+		TopQueries: cfg.GetInt("refresh.top_queries"),
+		Interval:   cfg.GetDuration("refresh.interval"),
+	}
+	if schedules := cfg.GetStringMapString("refresh.schedules"); len(schedules) > 0 {
+		rc.Schedules = schedules
+	}
+	if budgets := cfg.GetStringMap("refresh.daily_budget"); len(budgets) > 0 {
+		rc.DailyBudget = make(map[string]int, len(budgets))
+		for platform := range budgets {
+			rc.DailyBudget[platform] = cfg.GetInt("refresh.daily_budget." + platform)
+		}
+	}
+	return rc
+}
+
+// trendingQueries feeds the week's most searched terms to the scheduler, under the
+// category inferred from each term. Terms that have never found anything are skipped.
+type trendingQueries struct {
+	searches searchlog.Service
+}
+
+func (t trendingQueries) TopQueries(ctx context.Context, limit int) ([]refresh.Query, error) {
+	stats, err := t.searches.Trending(ctx, searchlog.WindowWeek, limit)
+	if err != nil {
+		return nil, err
+	}
+	queries := make([]refresh.Query, 0, len(stats))
+	for _, s := range stats {
+		if s.ZeroResults == s.Searches {
+			continue
+		}
+		category := s.InferredCategory
+		if category == "" {
+			category = refresh.DefaultSchedule
+		}
+		queries = append(queries, refresh.Query{Term: s.Term, Category: category})
+	}
+	return queries, nil
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"never-price-match-server/internal/infra/repo"
+	"never-price-match-server/internal/retention"
+
+	"github.com/spf13/viper"
+)
+
+// newRetention builds the retention job from the `retention` config.
+func newRetention(a *App, forget retention.Forgetter) retention.Service {
+	return retention.NewService(repo.NewRetentionGormRepo(a.DB), repo.NewTxRunner(a.DB), forget, retentionConfig(a.Config), a.Log)
+}
+
+// retentionConfig reads the retention policy; unset keys fall back to retention.DefaultConfig
+// and 0 days turns that part of the policy off.
+func retentionConfig(cfg *viper.Viper) retention.Config {
+	rc := retention.DefaultConfig
+	if cfg.IsSet("retention.keep_raw_days") {
+		rc.KeepRaw = time.Duration(cfg.GetInt("retention.keep_raw_days")) * 24 * time.Hour
+	}
+	if cfg.IsSet("retention.purge_unseen_days") {
+		rc.PurgeUnseen = time.Duration(cfg.GetInt("retention.purge_unseen_days")) * 24 * time.Hour
+	}
+	if cfg.IsSet("retention.purge_jobs_days") {
+		rc.PurgeJobs = time.Duration(cfg.GetInt("retention.purge_jobs_days")) * 24 * time.Hour
+	}
+	if interval := cfg.GetDuration("retention.interval"); interval > 0 {
+		rc.Interval = interval
+	}
+	return rc
+}
+
+// RunPrune applies the retention policy once. With dryRun set it only reports what it
+// would delete. A running server's in-memory search index and suggestions keep purged
+// listings until it restarts.
+func RunPrune(dryRun bool) error {
+	a, err := open()
+	if err != nil {
+		return err
+	}
+	defer a.Shutdown(context.Background())
+
+	report, err := newRetention(a, nil).Run(context.Background(), dryRun)
+	if err != nil {
+		return err
+	}
+	retention.LogReport(a.Log, report)
+	return nil
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/infra/search"
+	"never-price-match-server/internal/product"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// newSearchIndex builds the product search index selected by `search.engine`:
+// "mysql" uses the FULLTEXT index, "memory" an in-process inverted index loaded
+// from the database. When unset it is "mysql" on MySQL and "memory" elsewhere.
+func newSearchIndex(cfg *viper.Viper, gdb *gorm.DB, productRepo product.Repo, log *logger.Logger) (product.SearchIndex, error) {
+	engine := cfg.GetString("search.engine")
+	if engine == "" {
+		engine = "memory"
+		if gdb.Dialector.Name() == "mysql" {
+			engine = "mysql"
+		}
+	}
+	switch engine {
+	case "mysql":
+		if gdb.Dialector.Name() != "mysql" {
+			return nil, fmt.Errorf("search engine mysql needs a MySQL database, not %s", gdb.Dialector.Name())
+		}
+		return search.NewMySQLFulltext(gdb), nil
+	case "memory":
+		idx := search.NewInvertedIndex(productRepo)
+		n, err := product.BuildIndex(context.Background(), productRepo, idx)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("search index built", logger.Str("engine", engine), logger.Int("listings", n))
+		return idx, nil
+	default:
+		return nil, fmt.Errorf("unknown search engine %q", engine)
+	}
+}
@@ -1,86 +1,76 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"net/http"
-	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"never-price-match-server/internal/auth"
 	"never-price-match-server/internal/graph"
 	"never-price-match-server/internal/graph/directives"
 	"never-price-match-server/internal/graph/generated"
 	"never-price-match-server/internal/httpctx"
-	"never-price-match-server/internal/infra/db"
 	"never-price-match-server/internal/infra/logger"
-	"never-price-match-server/internal/infra/repo"
-	"never-price-match-server/internal/product"
-	"never-price-match-server/internal/user"
+	"never-price-match-server/internal/infra/metrics"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/viper"
 )
 
-func initViper() {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("./config")
-	_ = viper.ReadInConfig()
-
-	viper.AutomaticEnv()
-	_ = viper.BindEnv("APP_ENV")
-
-	env := viper.GetString("APP_ENV")
-	if env == "" {
-		env = viper.GetString("env")
-	}
-	if env != "" {
-		viper.SetConfigName("config." + env)
-		_ = viper.MergeInConfig()
-	}
-}
+// shutdownTimeout bounds how long in-flight requests get to finish on shutdown.
+const shutdownTimeout = 15 * time.Second
 
 func RunFull() error {
-	// 1) logger
-	env := os.Getenv("APP_ENV")
-	if env == "" {
-		env = "local"
+	a, err := New()
+	if err != nil {
+		return err
 	}
-	logger.Init(env)
-	defer logger.Sync()
-
-	// 2) viper
-	initViper()
-
-	// 3) DB
-	db.Init()
-	gdb := db.DB
+	return a.Run()
+}
 
-	// 4) AutoMigrate
-	if err := gdb.AutoMigrate(&user.User{}); err != nil {
-		logger.L.Fatal("auto migrate failed", logger.Err(err))
+// Handler builds the HTTP routes: the GraphQL endpoint, its playground and shared
+// evidence packs. Metrics are served separately, by debugHandler.
+func (a *App) Handler() http.Handler {
+	resolver := &graph.Resolver{
+		UserService:         a.Users,
+		ProductService:      a.Products,
+		SearchLogService:    a.SearchLog,
+		ScrapeJobService:    a.Jobs,
+		PriceMatchService:   a.PriceMatch,
+		EvidenceService:     a.Evidence,
+		PurchaseService:     a.Purchases,
+		WatchlistService:    a.Watchlists,
+		NotificationService: a.Notify,
+		Log:                 a.Log,
 	}
-
-	// 5) DI
-	userRepo := repo.NewUserGormRepo(gdb)
-	userSvc := user.NewService(userRepo)
-	productRepo := repo.NewProductGormRepo(gdb)
-	productSvc := product.NewService(productRepo)
-	resolver := &graph.Resolver{UserService: userSvc, ProductService: productSvc}
 	cfg := generated.Config{Resolvers: resolver}
 	cfg.Directives.Auth = directives.Auth()
+	cfg.Directives.RateLimit = directives.RateLimit()
 	srv := handler.NewDefaultServer(generated.NewExecutableSchema(cfg))
 
-	// 6) HTTP
 	r := gin.Default()
+	// Only the configured proxies may set the client IP through X-Forwarded-For;
+	// otherwise any client could pick a new one per request and dodge @rateLimit.
+	var proxies []string
+	if p := a.Config.GetStringSlice("server.trusted_proxies"); len(p) > 0 {
+		proxies = p
+	}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		a.Log.Error("Invalid server.trusted_proxies, trusting none", logger.Err(err))
+		_ = r.SetTrustedProxies(nil)
+	}
 	r.Use((cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "http://127.0.0.1:5173"},
 		AllowMethods:     []string{"POST", "GET", "OPTIONS"},
 		AllowHeaders:     []string{"Content-Type", "Authorization"},
 		AllowCredentials: true,
 	})))
-	r.Use(auth.CookieAuth())
+	r.Use(auth.CookieAuth(a.Log))
 	r.Use(func(c *gin.Context) {
 		c.Request = c.Request.WithContext(httpctx.WithGin(c.Request.Context(), c))
 		c.Next()
@@ -88,11 +78,53 @@ func RunFull() error {
 
 	r.GET("/", func(c *gin.Context) { playground.Handler("GraphQL", "/graphql").ServeHTTP(c.Writer, c.Request) })
 	r.POST("/graphql", func(c *gin.Context) { srv.ServeHTTP(c.Writer, c.Request) })
+	r.GET("/evidence/:id", a.serveEvidence)
+	return r
+}
 
-	addr := viper.GetString("app.addr")
+// Run serves HTTP until SIGINT or SIGTERM, then shuts the application down.
+func (a *App) Run() error {
+	addr := a.Config.GetString("app.addr")
 	if addr == "" {
 		addr = ":8080"
 	}
-	logger.L.Info("server started", logger.Str("addr", addr))
-	return http.ListenAndServe(addr, r)
+	a.server = &http.Server{Addr: addr, Handler: a.Handler()}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 2)
+	go func() {
+		a.Log.Info("server started", logger.Str("addr", addr))
+		serveErr <- a.server.ListenAndServe()
+	}()
+	if debugAddr := a.Config.GetString("server.debug_addr"); debugAddr != "" {
+		a.debugServer = &http.Server{Addr: debugAddr, Handler: debugHandler()}
+		go func() {
+			a.Log.Info("debug server started", logger.Str("addr", debugAddr))
+			serveErr <- a.debugServer.ListenAndServe()
+		}()
+	}
+
+	var err error
+	select {
+	case err = <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+	case <-ctx.Done():
+		a.Log.Info("shutting down")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return errors.Join(err, a.Shutdown(shutdownCtx))
+}
+
+// debugHandler serves /debug/vars. It has no auth of its own, so it is only ever
+// served on server.debug_addr, which should be reachable from inside the deployment only.
+func debugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", metrics.Handler())
+	return mux
 }
@@ -6,7 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func CookieAuth() gin.HandlerFunc {
+func CookieAuth(log *logger.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		cookie, err := c.Cookie("sid")
 		if err == nil && cookie != "" {
@@ -15,7 +15,7 @@ func CookieAuth() gin.HandlerFunc {
 				uid := claims.UserID
 				c.Set("uid", uid) // Available for subsequent use
 			} else {
-				logger.L.Warn("parse token failed", logger.Err(err))
+				log.Warn("parse token failed", logger.Err(err))
 			}
 		}
 		c.Next()
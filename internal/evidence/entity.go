@@ -0,0 +1,49 @@
+package evidence
+
+import (
+	"time"
+
+	"never-price-match-server/internal/product"
+)
+
+// Pack is a rendered evidence pack. Its ID is a random token that doubles as the
+// secret in the pack's shareable URL, which stops working at ExpiresAt.
+type Pack struct {
+	ID     string  `gorm:"type:varchar(64);primaryKey"`
+	UserID *string `gorm:"type:varchar(36)"` // nil for packs created before sign-in was required
+	// Document is the self-contained HTML document, screenshots included.
+	Document []byte `gorm:"not null"`
+	// PDF is the document printed to PDF; nil until it is first asked for.
+	PDF       []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time `gorm:"not null;index"`
+
+	// Items are the captured listings; they are only set on a newly created pack.
+	Items []Item `gorm:"-"`
+}
+
+func (Pack) TableName() string { return "evidence_packs" }
+
+// Expired reports whether the pack's URL has stopped working at now.
+func (p *Pack) Expired(now time.Time) bool {
+	return !now.Before(p.ExpiresAt)
+}
+
+// Item is one listing as captured for an evidence pack.
+type Item struct {
+	ListingID uint
+	Platform  string
+	Name      string
+	Link      string
+	// Price and StockStatus are read from the live page. When the page doesn't show
+	// them, or couldn't be captured, they are the last scraped values.
+	Price       float64
+	StockStatus product.StockStatus
+	// LastScrapedPrice is the price stored for the listing before the capture.
+	LastScrapedPrice float64
+	CapturedAt       time.Time
+	// Screenshot is a full-page JPEG; nil when the capture failed.
+	Screenshot []byte
+	// Error says why the page couldn't be captured.
+	Error string
+}
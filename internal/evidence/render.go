@@ -0,0 +1,84 @@
+package evidence
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"never-price-match-server/internal/product"
+)
+
+// documentTemplate lays out a pack as one HTML file with its styles and screenshots
+// inlined, so it can be saved, mailed or printed without the server.
+var documentTemplate = template.Must(template.New("evidence").Funcs(template.FuncMap{
+	"price": formatPrice,
+	"stamp": func(t time.Time) string { return t.Format("2 Jan 2006 15:04:05 MST") },
+	"stock": stockLabel,
+	"screenshot": func(jpeg []byte) template.URL {
+		// The data URL is built here from bytes we produced, so it is safe to trust.
+		return template.URL("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(jpeg))
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Price-match evidence</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem; color: #222; }
+h1 { font-size: 1.5rem; margin-bottom: 0.25rem; }
+.meta { color: #666; font-size: 0.9rem; margin-bottom: 2rem; }
+.item { border: 1px solid #ddd; border-radius: 6px; padding: 1rem; margin-bottom: 2rem; page-break-inside: avoid; }
+.item h2 { font-size: 1.15rem; margin: 0 0 0.5rem; }
+.item table { border-collapse: collapse; margin-bottom: 1rem; }
+.item th { text-align: left; padding: 0.2rem 1rem 0.2rem 0; color: #666; font-weight: normal; }
+.item td { padding: 0.2rem 0; }
+.price { font-size: 1.3rem; font-weight: bold; }
+.error { color: #b00020; }
+.shot { max-width: 100%; border: 1px solid #eee; }
+a { color: #0645ad; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>Price-match evidence</h1>
+<div class="meta">Generated {{stamp .CreatedAt}} &middot; valid until {{stamp .ExpiresAt}} &middot; {{len .Items}} listing(s)</div>
+{{range .Items}}
+<div class="item">
+<h2>{{.Name}}</h2>
+<table>
+<tr><th>Retailer</th><td>{{.Platform}}</td></tr>
+<tr><th>Price</th><td class="price">{{price .Price}}</td></tr>
+{{if ne .Price .LastScrapedPrice}}<tr><th>Last scraped price</th><td>{{price .LastScrapedPrice}}</td></tr>{{end}}
+<tr><th>Stock</th><td>{{stock .StockStatus}}</td></tr>
+<tr><th>Captured</th><td>{{stamp .CapturedAt}}</td></tr>
+<tr><th>Link</th><td><a href="{{.Link}}">{{.Link}}</a></td></tr>
+</table>
+{{if .Error}}<p class="error">The page couldn't be captured ({{.Error}}); the details above are as last scraped.</p>{{end}}
+{{if .Screenshot}}<img class="shot" alt="Screenshot of {{.Platform}} product page" src="{{screenshot .Screenshot}}">{{end}}
+</div>
+{{end}}
+</body>
+</html>
+`))
+
+// render writes a pack's HTML document.
+func render(w io.Writer, pack *Pack) error {
+	return documentTemplate.Execute(w, pack)
+}
+
+func formatPrice(v float64) string {
+	return fmt.Sprintf("$%.2f", v)
+}
+
+func stockLabel(s product.StockStatus) string {
+	switch s {
+	case product.StockInStock:
+		return "In stock"
+	case product.StockOutOfStock:
+		return "Out of stock"
+	default:
+		return "Not shown"
+	}
+}
@@ -0,0 +1,22 @@
+package evidence
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrPackNotFound is returned for a pack ID that doesn't exist.
+var ErrPackNotFound = errors.New("evidence pack not found")
+
+// Repo defines the interface for evidence pack persistence.
+type Repo interface {
+	// Create stores a new pack.
+	Create(ctx context.Context, pack *Pack) error
+	// Get returns a pack with its document, or ErrPackNotFound.
+	Get(ctx context.Context, id string) (*Pack, error)
+	// SetPDF stores the PDF printed from a pack's document.
+	SetPDF(ctx context.Context, id string, pdf []byte) error
+	// DeleteExpired deletes packs that expired before t and returns how many there were.
+	DeleteExpired(ctx context.Context, t time.Time) (int64, error)
+}
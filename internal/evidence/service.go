@@ -0,0 +1,259 @@
+package evidence
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/product"
+)
+
+const (
+	// MaxListings is how many listings one pack can hold.
+	MaxListings = 10
+	// purgeInterval is how often expired packs are deleted.
+	purgeInterval = time.Hour
+)
+
+var (
+	// ErrNoListings is returned when none of the requested listings exist.
+	ErrNoListings = errors.New("no listings to capture")
+	// ErrTooManyListings is returned for more than MaxListings listings.
+	ErrTooManyListings = fmt.Errorf("an evidence pack holds at most %d listings", MaxListings)
+	// ErrPackExpired is returned for a pack whose URL has stopped working.
+	ErrPackExpired = errors.New("evidence pack expired")
+)
+
+// Capturer loads product pages and prints documents; *product.Browser is one.
+type Capturer interface {
+	Capture(ctx context.Context, link string) (*product.PageCapture, error)
+	PrintPDF(ctx context.Context, html []byte) ([]byte, error)
+}
+
+// Config controls how long packs are shared and where their URLs point.
+type Config struct {
+	// TTL is how long a pack's URL works.
+	TTL time.Duration
+	// BaseURL is prefixed to pack paths, e.g. https://api.example.com; empty gives
+	// root-relative URLs.
+	BaseURL string
+}
+
+// DefaultTTL is the pack lifetime used when Config.TTL is zero.
+const DefaultTTL = 24 * time.Hour
+
+// Service defines the business logic interface for evidence packs.
+type Service interface {
+	// Create captures the listings' pages now and stores the rendered pack. Listings
+	// that no longer exist are skipped; a page that can't be captured is included
+	// with its last scraped details and the error.
+	Create(ctx context.Context, listingIDs []uint, userID string) (*Pack, error)
+	// Get returns a pack with its document, ErrPackNotFound or ErrPackExpired.
+	Get(ctx context.Context, id string) (*Pack, error)
+	// PDF returns a pack's document printed to PDF. It is printed once and stored.
+	PDF(ctx context.Context, pack *Pack) ([]byte, error)
+	// URL is the shareable address of a pack's HTML document; PDFURL that of its PDF.
+	URL(pack *Pack) string
+	PDFURL(pack *Pack) string
+	// Start deletes expired packs every hour until ctx is cancelled.
+	Start(ctx context.Context)
+	// Close waits for the purge job to stop.
+	Close()
+}
+
+type service struct {
+	repo     Repo
+	products product.Service
+	capturer Capturer
+	cfg      Config
+	log      *logger.Logger
+	running  sync.WaitGroup
+	// printing lets one pack be printed at a time, so repeated PDF requests can't tie
+	// up the browser.
+	printing sync.Mutex
+}
+
+// NewService creates a new evidence pack service instance. Listings are looked up
+// through products and their pages captured with capturer.
+func NewService(repo Repo, products product.Service, capturer Capturer, cfg Config, log *logger.Logger) Service {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	return &service{repo: repo, products: products, capturer: capturer, cfg: cfg, log: log}
+}
+
+func (s *service) Create(ctx context.Context, listingIDs []uint, userID string) (*Pack, error) {
+	if len(listingIDs) > MaxListings {
+		return nil, ErrTooManyListings
+	}
+	results, err := s.products.Listings(ctx, listingIDs)
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	for _, res := range results {
+		for _, p := range res.Products {
+			items = append(items, Item{
+				ListingID:        p.ListingID,
+				Platform:         res.Platform,
+				Name:             p.Name,
+				Link:             p.Link,
+				Price:            p.Price,
+				StockStatus:      p.StockStatus,
+				LastScrapedPrice: p.Price,
+			})
+		}
+	}
+	if len(items) == 0 {
+		return nil, ErrNoListings
+	}
+
+	// The browser's tab pool bounds how many pages load at once.
+	var wg sync.WaitGroup
+	for i := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.capture(ctx, &items[i])
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	id, err := newPackID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	pack := &Pack{ID: id, CreatedAt: now, ExpiresAt: now.Add(s.cfg.TTL), Items: items}
+	if userID != "" {
+		pack.UserID = &userID
+	}
+	var doc bytes.Buffer
+	if err := render(&doc, pack); err != nil {
+		return nil, err
+	}
+	pack.Document = doc.Bytes()
+	if err := s.repo.Create(ctx, pack); err != nil {
+		return nil, err
+	}
+	s.log.Info("Evidence pack created", logger.Str("pack", pack.ID), logger.Int("listings", len(items)))
+	return pack, nil
+}
+
+// capture fills in an item from its live page, keeping the last scraped details for
+// anything the page doesn't show.
+func (s *service) capture(ctx context.Context, item *Item) {
+	c, err := s.capturer.Capture(ctx, item.Link)
+	if err != nil {
+		item.CapturedAt = time.Now()
+		item.Error = err.Error()
+		s.log.Warn("Evidence capture failed", logger.Str("link", item.Link), logger.Err(err))
+		return
+	}
+	item.CapturedAt = c.CapturedAt
+	item.Screenshot = c.Screenshot
+	if c.Title != "" {
+		item.Name = c.Title
+	}
+	if c.Price > 0 {
+		item.Price = c.Price
+	}
+	if c.StockStatus != product.StockUnknown {
+		item.StockStatus = c.StockStatus
+	}
+}
+
+func (s *service) Get(ctx context.Context, id string) (*Pack, error) {
+	pack, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pack.Expired(time.Now()) {
+		return nil, ErrPackExpired
+	}
+	return pack, nil
+}
+
+func (s *service) PDF(ctx context.Context, pack *Pack) ([]byte, error) {
+	if pack.PDF != nil {
+		return pack.PDF, nil
+	}
+	s.printing.Lock()
+	defer s.printing.Unlock()
+	// A request that waited finds the PDF stored by the one before it.
+	stored, err := s.repo.Get(ctx, pack.ID)
+	if err != nil {
+		return nil, err
+	}
+	if stored.PDF != nil {
+		return stored.PDF, nil
+	}
+	pdf, err := s.capturer.PrintPDF(ctx, pack.Document)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetPDF(ctx, pack.ID, pdf); err != nil {
+		s.log.Warn("Failed to store evidence pack PDF", logger.Str("pack", pack.ID), logger.Err(err))
+	}
+	return pdf, nil
+}
+
+func (s *service) URL(pack *Pack) string {
+	return s.cfg.BaseURL + "/evidence/" + pack.ID
+}
+
+func (s *service) PDFURL(pack *Pack) string {
+	return s.URL(pack) + "?format=pdf"
+}
+
+func (s *service) Start(ctx context.Context) {
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+		for {
+			s.purge(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *service) purge(ctx context.Context) {
+	n, err := s.repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		s.log.Warn("Failed to delete expired evidence packs", logger.Err(err))
+		return
+	}
+	if n > 0 {
+		s.log.Info("Expired evidence packs deleted", logger.Field("deleted", n))
+	}
+}
+
+func (s *service) Close() {
+	s.running.Wait()
+}
+
+// newPackID returns a random URL-safe token that is infeasible to guess.
+func newPackID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
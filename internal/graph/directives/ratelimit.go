@@ -0,0 +1,50 @@
+package directives
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"never-price-match-server/internal/httpctx"
+	"never-price-match-server/internal/infra/ratelimit"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// RateLimit implements @rateLimit(max, seconds): each client may call a field max
+// times per seconds, after which it gets ratelimit.ErrLimited until calls are regained.
+func RateLimit() func(ctx context.Context, obj interface{}, next graphql.Resolver, max int, seconds int) (interface{}, error) {
+	var mu sync.Mutex
+	limiters := make(map[string]*ratelimit.Limiter) // "Object.field" -> limiter
+	return func(ctx context.Context, obj interface{}, next graphql.Resolver, max int, seconds int) (interface{}, error) {
+		fc := graphql.GetFieldContext(ctx)
+		field := fc.Object + "." + fc.Field.Name
+
+		mu.Lock()
+		l, ok := limiters[field]
+		if !ok {
+			l = ratelimit.New(max, time.Duration(seconds)*time.Second)
+			limiters[field] = l
+		}
+		mu.Unlock()
+
+		if !l.Allow(clientKey(ctx)) {
+			return nil, ratelimit.ErrLimited
+		}
+		return next(ctx)
+	}
+}
+
+// clientKey identifies the caller: the signed-in user, or else the client IP address.
+func clientKey(ctx context.Context) string {
+	gc := httpctx.Gin(ctx)
+	if gc == nil {
+		return ""
+	}
+	if uid, _ := gc.Get("uid"); uid != nil {
+		if s, _ := uid.(string); s != "" {
+			return "user:" + s
+		}
+	}
+	return "ip:" + gc.ClientIP()
+}
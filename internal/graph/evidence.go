@@ -0,0 +1,34 @@
+package graph
+
+import (
+	"strconv"
+
+	"never-price-match-server/internal/evidence"
+	"never-price-match-server/internal/graph/model"
+)
+
+func (r *Resolver) evidencePackModel(pack *evidence.Pack) *model.EvidencePack {
+	items := make([]*model.EvidenceItem, 0, len(pack.Items))
+	for _, it := range pack.Items {
+		items = append(items, &model.EvidenceItem{
+			ListingID:        strconv.FormatUint(uint64(it.ListingID), 10),
+			Platform:         it.Platform,
+			ProductName:      it.Name,
+			Price:            it.Price,
+			LastScrapedPrice: it.LastScrapedPrice,
+			Link:             it.Link,
+			StockStatus:      stockStatus(it.StockStatus),
+			CapturedAt:       it.CapturedAt,
+			Screenshot:       len(it.Screenshot) > 0,
+			Error:            optionalString(it.Error),
+		})
+	}
+	return &model.EvidencePack{
+		ID:        pack.ID,
+		URL:       r.EvidenceService.URL(pack),
+		PDFURL:    r.EvidenceService.PDFURL(pack),
+		CreatedAt: pack.CreatedAt,
+		ExpiresAt: pack.ExpiresAt,
+		Items:     items,
+	}
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"never-price-match-server/internal/httpctx"
+)
+
+// optionalString maps an empty string to a null GraphQL value.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// boolValue reads an optional GraphQL Boolean argument, treating null as false.
+func boolValue(b *bool) bool {
+	return b != nil && *b
+}
+
+// optionalID maps a database ID to a GraphQL ID, with 0 meaning "not stored yet".
+func optionalID(id uint) *string {
+	if id == 0 {
+		return nil
+	}
+	s := strconv.FormatUint(uint64(id), 10)
+	return &s
+}
+
+// timeValue reads an optional GraphQL Time argument, treating null as the zero time.
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// intValue reads an optional GraphQL Int argument, falling back to def when null.
+func intValue(i *int, def int) int {
+	if i == nil {
+		return def
+	}
+	return *i
+}
+
+// optionalTime maps an unset time to a null GraphQL value.
+func optionalTime(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// currentUserID returns the signed-in user's ID, or "" for anonymous requests.
+func currentUserID(ctx context.Context) string {
+	gc := httpctx.Gin(ctx)
+	if gc == nil {
+		return ""
+	}
+	v, _ := gc.Get("uid")
+	uid, _ := v.(string)
+	return uid
+}
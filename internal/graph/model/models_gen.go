@@ -3,6 +3,10 @@
 package model
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 )
 
@@ -17,6 +21,47 @@ type CreateUserInput struct {
 	Password string `json:"password"`
 }
 
+type CreateWatchInput struct {
+	// Watch listings whose names contain every word of this term. Give either term or listingId.
+	Term *string `json:"term,omitempty"`
+	// Watch a single stored listing.
+	ListingID   *string  `json:"listingId,omitempty"`
+	TargetPrice float64  `json:"targetPrice"`
+	Platforms   []string `json:"platforms,omitempty"`
+	// A retailer with a price-match policy, such as "JB Hi-Fi".
+	PriceMatchAt *string    `json:"priceMatchAt,omitempty"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+}
+
+type EvidenceItem struct {
+	ListingID   string `json:"listingId"`
+	Platform    string `json:"platform"`
+	ProductName string `json:"productName"`
+	// The price on the live page, or the last scraped price when the page doesn't show one.
+	Price float64 `json:"price"`
+	// The price stored for the listing before the capture.
+	LastScrapedPrice float64     `json:"lastScrapedPrice"`
+	Link             string      `json:"link"`
+	StockStatus      StockStatus `json:"stockStatus"`
+	CapturedAt       time.Time   `json:"capturedAt"`
+	// True when the pack includes a full-page screenshot of the listing.
+	Screenshot bool `json:"screenshot"`
+	// Why the page couldn't be captured, when it couldn't.
+	Error *string `json:"error,omitempty"`
+}
+
+type EvidencePack struct {
+	ID string `json:"id"`
+	// Shareable link to the pack as a self-contained HTML page.
+	URL string `json:"url"`
+	// The same pack printed to PDF.
+	PDFURL    string    `json:"pdfUrl"`
+	CreatedAt time.Time `json:"createdAt"`
+	// When the links stop working.
+	ExpiresAt time.Time       `json:"expiresAt"`
+	Items     []*EvidenceItem `json:"items"`
+}
+
 type LoginInput struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -25,17 +70,272 @@ type LoginInput struct {
 type Mutation struct {
 }
 
+type Notification struct {
+	ID string `json:"id"`
+	// What raised it: watch_alert or price_protection.
+	Kind  string `json:"kind"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Where the notification leads, usually the product page.
+	Link      *string    `json:"link,omitempty"`
+	Read      bool       `json:"read"`
+	ReadAt    *time.Time `json:"readAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type NotificationDelivery struct {
+	ID             string                     `json:"id"`
+	NotificationID string                     `json:"notificationId"`
+	Channel        NotificationChannel        `json:"channel"`
+	Status         NotificationDeliveryStatus `json:"status"`
+	Attempts       int                        `json:"attempts"`
+	// When a pending delivery is next tried.
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	LastError     *string    `json:"lastError,omitempty"`
+	SentAt        *time.Time `json:"sentAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+type NotificationPreferences struct {
+	InApp   bool `json:"inApp"`
+	Email   bool `json:"email"`
+	Webhook bool `json:"webhook"`
+	// Receives a signed JSON POST per notification.
+	WebhookURL *string `json:"webhookUrl,omitempty"`
+	// Whether a webhook signing secret is set; the secret itself is never returned.
+	WebhookSecretSet bool `json:"webhookSecretSet"`
+	// HH:MM in timeZone from which email and webhook deliveries wait; null for no quiet hours.
+	QuietHoursStart *string `json:"quietHoursStart,omitempty"`
+	// HH:MM in timeZone at which quiet hours end; may be earlier than the start to span midnight.
+	QuietHoursEnd *string `json:"quietHoursEnd,omitempty"`
+	// An IANA time zone such as Australia/Sydney.
+	TimeZone string `json:"timeZone"`
+}
+
+// Changes to notification preferences; omitted fields are left as they are. An empty webhookUrl,
+// quietHoursStart or quietHoursEnd clears it.
+type NotificationPreferencesInput struct {
+	InApp      *bool   `json:"inApp,omitempty"`
+	Email      *bool   `json:"email,omitempty"`
+	Webhook    *bool   `json:"webhook,omitempty"`
+	WebhookURL *string `json:"webhookUrl,omitempty"`
+	// Keys the X-Price-Match-Signature header: "sha256=" and the hex HMAC-SHA256 of the
+	// X-Price-Match-Timestamp header, a dot and the request body.
+	WebhookSecret   *string `json:"webhookSecret,omitempty"`
+	QuietHoursStart *string `json:"quietHoursStart,omitempty"`
+	QuietHoursEnd   *string `json:"quietHoursEnd,omitempty"`
+	TimeZone        *string `json:"timeZone,omitempty"`
+}
+
+type PlatformProgress struct {
+	Platform    string               `json:"platform"`
+	Status      PlatformScrapeStatus `json:"status"`
+	ResultCount int                  `json:"resultCount"`
+	Error       *string              `json:"error,omitempty"`
+	FinishedAt  *time.Time           `json:"finishedAt,omitempty"`
+}
+
+type PriceHistory struct {
+	ListingID   string        `json:"listingId"`
+	Platform    string        `json:"platform"`
+	ProductName string        `json:"productName"`
+	Link        string        `json:"link"`
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	Points      []*PricePoint `json:"points"`
+}
+
+type PriceMatchOffer struct {
+	Offer    *Product `json:"offer"`
+	Eligible bool     `json:"eligible"`
+	// The price the retailer would charge, when the offer is eligible.
+	MatchPrice *float64 `json:"matchPrice,omitempty"`
+	// Why the retailer wouldn't match the offer.
+	Reasons []string `json:"reasons"`
+	// What staff will still check before matching an eligible offer.
+	Notes []string `json:"notes"`
+}
+
+type PriceMatchOptions struct {
+	Policy *PriceMatchPolicy `json:"policy"`
+	// The retailer's own lowest price among the results, if it was found.
+	RetailerPrice *float64 `json:"retailerPrice,omitempty"`
+	// The lowest price the retailer would match to, if any offer is eligible.
+	BestPrice *float64 `json:"bestPrice,omitempty"`
+	// How much bestPrice is below retailerPrice, when both are known.
+	Saving *float64 `json:"saving,omitempty"`
+	// Eligible offers by match price, then the others by price.
+	Offers []*PriceMatchOffer `json:"offers"`
+}
+
+type PriceMatchPolicy struct {
+	Retailer string `json:"retailer"`
+	// The platforms whose prices the retailer matches; empty when it matches any retailer.
+	Competitors []string `json:"competitors"`
+	// How far below a matched price the retailer goes, in percent.
+	BeatPercent                float64 `json:"beatPercent"`
+	ExcludesMarketplaceSellers bool    `json:"excludesMarketplaceSellers"`
+	ExcludesClearance          bool    `json:"excludesClearance"`
+	RequiresInStock            bool    `json:"requiresInStock"`
+	RequiresIdenticalModel     bool    `json:"requiresIdenticalModel"`
+	// How many days after a purchase the retailer refunds a drop in its own price; 0 when it doesn't.
+	PriceProtectionDays int `json:"priceProtectionDays"`
+}
+
+type PricePoint struct {
+	PeriodStart time.Time `json:"periodStart"`
+	Min         float64   `json:"min"`
+	Max         float64   `json:"max"`
+	// The last price observed in the period.
+	Close        float64 `json:"close"`
+	Observations int     `json:"observations"`
+}
+
+type PriceProtectionAlert struct {
+	ID          string `json:"id"`
+	PurchaseID  string `json:"purchaseId"`
+	Retailer    string `json:"retailer"`
+	ProductName string `json:"productName"`
+	// The price the refund is counted from: the price paid, less refunds already claimed.
+	PricePaid float64 `json:"pricePaid"`
+	NewPrice  float64 `json:"newPrice"`
+	Refund    float64 `json:"refund"`
+	// When the new price was scraped.
+	ObservedAt time.Time `json:"observedAt"`
+	// When the retailer's price protection ends.
+	ClaimBy time.Time                  `json:"claimBy"`
+	Status  PriceProtectionAlertStatus `json:"status"`
+}
+
 type Product struct {
+	// The stored listing (platform + canonical link) this result belongs to, if it has been saved.
+	ListingID   *string `json:"listingId,omitempty"`
 	Platform    string  `json:"platform"`
 	ProductName string  `json:"productName"`
 	Price       float64 `json:"price"`
 	ImageURL    string  `json:"imageUrl"`
 	Link        string  `json:"link"`
+	// True when the listing is a paid placement.
+	Sponsored bool `json:"sponsored"`
+	// Who sells the offer, if the retailer shows it.
+	Seller *string `json:"seller,omitempty"`
+	// Who ships the offer, if shown separately from the seller.
+	FulfilledBy *string `json:"fulfilledBy,omitempty"`
+	// True when a marketplace seller rather than the retailer itself sells the offer.
+	ThirdParty bool `json:"thirdParty"`
+	// Availability shown by the retailer when the price was scraped.
+	StockStatus StockStatus `json:"stockStatus"`
+	// True when the price is marked as a clearance price.
+	Clearance bool `json:"clearance"`
+	// Whether the result was served from the cache or scraped live for this request.
+	Source ResultSource `json:"source"`
+	// When the price was scraped from the platform.
+	ScrapedAt time.Time `json:"scrapedAt"`
+	// True when a cached price is older than the freshness policy allows; a refresh is running in the background.
+	Stale bool `json:"stale"`
+}
+
+type Purchase struct {
+	ID          string    `json:"id"`
+	ListingID   string    `json:"listingId"`
+	Retailer    string    `json:"retailer"`
+	ProductName string    `json:"productName"`
+	Link        string    `json:"link"`
+	PricePaid   float64   `json:"pricePaid"`
+	PurchasedAt time.Time `json:"purchasedAt"`
+	// When the retailer stops refunding price drops; null when it offers no price protection.
+	ProtectionEndsAt *time.Time `json:"protectionEndsAt,omitempty"`
+	ProtectionActive bool       `json:"protectionActive"`
+	// The listing's latest price; null when the listing is no longer stored.
+	CurrentPrice *float64 `json:"currentPrice,omitempty"`
+	// The lowest price seen since the purchase.
+	LowestPrice      float64                 `json:"lowestPrice"`
+	LastCheckedAt    *time.Time              `json:"lastCheckedAt,omitempty"`
+	ClaimedSavings   float64                 `json:"claimedSavings"`
+	ClaimableSavings float64                 `json:"claimableSavings"`
+	Alerts           []*PriceProtectionAlert `json:"alerts"`
+}
+
+type PurchaseSavings struct {
+	Purchases int     `json:"purchases"`
+	Claimed   float64 `json:"claimed"`
+	Claimable float64 `json:"claimable"`
 }
 
 type Query struct {
 }
 
+type RecordPurchaseInput struct {
+	// The stored listing the purchase was made from.
+	ListingID string  `json:"listingId"`
+	PricePaid float64 `json:"pricePaid"`
+	// Defaults to now.
+	PurchasedAt *time.Time `json:"purchasedAt,omitempty"`
+}
+
+type SearchJob struct {
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Category string          `json:"category"`
+	Status   SearchJobStatus `json:"status"`
+	// Progress per platform, in the order they are scraped.
+	Platforms []*PlatformProgress `json:"platforms"`
+	// Listings found so far; complete once the job has finished.
+	Results []*Product `json:"results"`
+	// Why the job failed, when it did.
+	Error *string `json:"error,omitempty"`
+	// The best deal among the results so far, and how the platforms finished so far fared.
+	SearchSummary *SearchSummary `json:"searchSummary"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	FinishedAt    *time.Time     `json:"finishedAt,omitempty"`
+}
+
+type SearchResponse struct {
+	Products      []*Product     `json:"products"`
+	SearchSummary *SearchSummary `json:"searchSummary"`
+}
+
+// The best deal among a search's offers and how the platforms fared. Unpriced and out-of-stock
+// offers aren't counted; without any offers the price fields are null.
+type SearchSummary struct {
+	// How many offers the prices are taken from.
+	Offers         int      `json:"offers"`
+	LowestPrice    *float64 `json:"lowestPrice,omitempty"`
+	LowestPlatform *string  `json:"lowestPlatform,omitempty"`
+	// The stored listing of the cheapest offer.
+	LowestListingID *string  `json:"lowestListingId,omitempty"`
+	MedianPrice     *float64 `json:"medianPrice,omitempty"`
+	HighestPrice    *float64 `json:"highestPrice,omitempty"`
+	// The gap between the dearest and the cheapest offer.
+	PriceSpread *float64 `json:"priceSpread,omitempty"`
+	// The platform of the dearest offer.
+	HighestPlatform *string `json:"highestPlatform,omitempty"`
+	// What the cheapest offer saves over the dearest one.
+	PotentialSaving *float64 `json:"potentialSaving,omitempty"`
+	// potentialSaving as a percentage of the dearest offer's price.
+	PotentialSavingPercent *float64 `json:"potentialSavingPercent,omitempty"`
+	// Platforms looked up: those of the search's category. A cached answer counts a platform without
+	// listings for the search as failed unless its last scrape for the search succeeded.
+	PlatformsSearched int `json:"platformsSearched"`
+	// Platforms that answered, with or without offers.
+	PlatformsSucceeded int `json:"platformsSucceeded"`
+	// Platforms that couldn't be scraped.
+	PlatformsFailed int      `json:"platformsFailed"`
+	FailedPlatforms []string `json:"failedPlatforms"`
+}
+
+type SearchTrend struct {
+	// The normalised search term (lower-case words).
+	Term               string `json:"term"`
+	Searches           int    `json:"searches"`
+	ZeroResultSearches int    `json:"zeroResultSearches"`
+	// Share of searches answered from the cache, between 0 and 1.
+	CacheHitRate float64 `json:"cacheHitRate"`
+	AvgLatencyMs float64 `json:"avgLatencyMs"`
+	// The category most often inferred from the term, if any.
+	InferredCategory *string `json:"inferredCategory,omitempty"`
+}
+
 type User struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
@@ -43,3 +343,613 @@ type User struct {
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
+
+type Watch struct {
+	ID string `json:"id"`
+	// The search term watched, matched against the names of scraped listings; null for a listing watch.
+	Term *string `json:"term,omitempty"`
+	// The stored listing watched; null for a term watch.
+	ListingID   *string `json:"listingId,omitempty"`
+	TargetPrice float64 `json:"targetPrice"`
+	// The platforms the watch is limited to; empty for any.
+	Platforms []string `json:"platforms"`
+	// When set, only offers this retailer would price-match count, at the price it would match them to.
+	PriceMatchAt *string    `json:"priceMatchAt,omitempty"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	Active       bool       `json:"active"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+type WatchAlert struct {
+	ID          string  `json:"id"`
+	WatchID     string  `json:"watchId"`
+	ListingID   string  `json:"listingId"`
+	Platform    string  `json:"platform"`
+	ProductName string  `json:"productName"`
+	Link        string  `json:"link"`
+	Price       float64 `json:"price"`
+	// What the watch's priceMatchAt retailer would charge, when it has one.
+	MatchPrice  *float64         `json:"matchPrice,omitempty"`
+	TargetPrice float64          `json:"targetPrice"`
+	Status      WatchAlertStatus `json:"status"`
+	// When the alert was raised; an alert is raised again, unread, when the price drops further.
+	RaisedAt time.Time  `json:"raisedAt"`
+	ReadAt   *time.Time `json:"readAt,omitempty"`
+}
+
+type NotificationChannel string
+
+const (
+	NotificationChannelEmail   NotificationChannel = "EMAIL"
+	NotificationChannelWebhook NotificationChannel = "WEBHOOK"
+)
+
+var AllNotificationChannel = []NotificationChannel{
+	NotificationChannelEmail,
+	NotificationChannelWebhook,
+}
+
+func (e NotificationChannel) IsValid() bool {
+	switch e {
+	case NotificationChannelEmail, NotificationChannelWebhook:
+		return true
+	}
+	return false
+}
+
+func (e NotificationChannel) String() string {
+	return string(e)
+}
+
+func (e *NotificationChannel) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NotificationChannel(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NotificationChannel", str)
+	}
+	return nil
+}
+
+func (e NotificationChannel) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *NotificationChannel) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e NotificationChannel) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type NotificationDeliveryStatus string
+
+const (
+	// Waiting for its next attempt, possibly until quiet hours end.
+	NotificationDeliveryStatusPending NotificationDeliveryStatus = "PENDING"
+	NotificationDeliveryStatusSent    NotificationDeliveryStatus = "SENT"
+	// Gave up after its last attempt.
+	NotificationDeliveryStatusFailed NotificationDeliveryStatus = "FAILED"
+)
+
+var AllNotificationDeliveryStatus = []NotificationDeliveryStatus{
+	NotificationDeliveryStatusPending,
+	NotificationDeliveryStatusSent,
+	NotificationDeliveryStatusFailed,
+}
+
+func (e NotificationDeliveryStatus) IsValid() bool {
+	switch e {
+	case NotificationDeliveryStatusPending, NotificationDeliveryStatusSent, NotificationDeliveryStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e NotificationDeliveryStatus) String() string {
+	return string(e)
+}
+
+func (e *NotificationDeliveryStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NotificationDeliveryStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NotificationDeliveryStatus", str)
+	}
+	return nil
+}
+
+func (e NotificationDeliveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *NotificationDeliveryStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e NotificationDeliveryStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PlatformScrapeStatus string
+
+const (
+	PlatformScrapeStatusPending PlatformScrapeStatus = "PENDING"
+	PlatformScrapeStatusRunning PlatformScrapeStatus = "RUNNING"
+	PlatformScrapeStatusDone    PlatformScrapeStatus = "DONE"
+	PlatformScrapeStatusFailed  PlatformScrapeStatus = "FAILED"
+)
+
+var AllPlatformScrapeStatus = []PlatformScrapeStatus{
+	PlatformScrapeStatusPending,
+	PlatformScrapeStatusRunning,
+	PlatformScrapeStatusDone,
+	PlatformScrapeStatusFailed,
+}
+
+func (e PlatformScrapeStatus) IsValid() bool {
+	switch e {
+	case PlatformScrapeStatusPending, PlatformScrapeStatusRunning, PlatformScrapeStatusDone, PlatformScrapeStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e PlatformScrapeStatus) String() string {
+	return string(e)
+}
+
+func (e *PlatformScrapeStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PlatformScrapeStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PlatformScrapeStatus", str)
+	}
+	return nil
+}
+
+func (e PlatformScrapeStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PlatformScrapeStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PlatformScrapeStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PriceHistoryGranularity string
+
+const (
+	PriceHistoryGranularityRaw  PriceHistoryGranularity = "RAW"
+	PriceHistoryGranularityHour PriceHistoryGranularity = "HOUR"
+	PriceHistoryGranularityDay  PriceHistoryGranularity = "DAY"
+)
+
+var AllPriceHistoryGranularity = []PriceHistoryGranularity{
+	PriceHistoryGranularityRaw,
+	PriceHistoryGranularityHour,
+	PriceHistoryGranularityDay,
+}
+
+func (e PriceHistoryGranularity) IsValid() bool {
+	switch e {
+	case PriceHistoryGranularityRaw, PriceHistoryGranularityHour, PriceHistoryGranularityDay:
+		return true
+	}
+	return false
+}
+
+func (e PriceHistoryGranularity) String() string {
+	return string(e)
+}
+
+func (e *PriceHistoryGranularity) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PriceHistoryGranularity(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PriceHistoryGranularity", str)
+	}
+	return nil
+}
+
+func (e PriceHistoryGranularity) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PriceHistoryGranularity) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PriceHistoryGranularity) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PriceProtectionAlertStatus string
+
+const (
+	// The drop can still be claimed from the retailer.
+	PriceProtectionAlertStatusClaimable PriceProtectionAlertStatus = "CLAIMABLE"
+	PriceProtectionAlertStatusClaimed   PriceProtectionAlertStatus = "CLAIMED"
+	PriceProtectionAlertStatusDismissed PriceProtectionAlertStatus = "DISMISSED"
+	// The drop wasn't claimed before the protection window closed.
+	PriceProtectionAlertStatusExpired PriceProtectionAlertStatus = "EXPIRED"
+)
+
+var AllPriceProtectionAlertStatus = []PriceProtectionAlertStatus{
+	PriceProtectionAlertStatusClaimable,
+	PriceProtectionAlertStatusClaimed,
+	PriceProtectionAlertStatusDismissed,
+	PriceProtectionAlertStatusExpired,
+}
+
+func (e PriceProtectionAlertStatus) IsValid() bool {
+	switch e {
+	case PriceProtectionAlertStatusClaimable, PriceProtectionAlertStatusClaimed, PriceProtectionAlertStatusDismissed, PriceProtectionAlertStatusExpired:
+		return true
+	}
+	return false
+}
+
+func (e PriceProtectionAlertStatus) String() string {
+	return string(e)
+}
+
+func (e *PriceProtectionAlertStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PriceProtectionAlertStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PriceProtectionAlertStatus", str)
+	}
+	return nil
+}
+
+func (e PriceProtectionAlertStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PriceProtectionAlertStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PriceProtectionAlertStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ResultSource string
+
+const (
+	ResultSourceCache ResultSource = "CACHE"
+	ResultSourceLive  ResultSource = "LIVE"
+)
+
+var AllResultSource = []ResultSource{
+	ResultSourceCache,
+	ResultSourceLive,
+}
+
+func (e ResultSource) IsValid() bool {
+	switch e {
+	case ResultSourceCache, ResultSourceLive:
+		return true
+	}
+	return false
+}
+
+func (e ResultSource) String() string {
+	return string(e)
+}
+
+func (e *ResultSource) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ResultSource(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ResultSource", str)
+	}
+	return nil
+}
+
+func (e ResultSource) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ResultSource) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ResultSource) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type SearchJobStatus string
+
+const (
+	SearchJobStatusQueued    SearchJobStatus = "QUEUED"
+	SearchJobStatusRunning   SearchJobStatus = "RUNNING"
+	SearchJobStatusSucceeded SearchJobStatus = "SUCCEEDED"
+	SearchJobStatusFailed    SearchJobStatus = "FAILED"
+)
+
+var AllSearchJobStatus = []SearchJobStatus{
+	SearchJobStatusQueued,
+	SearchJobStatusRunning,
+	SearchJobStatusSucceeded,
+	SearchJobStatusFailed,
+}
+
+func (e SearchJobStatus) IsValid() bool {
+	switch e {
+	case SearchJobStatusQueued, SearchJobStatusRunning, SearchJobStatusSucceeded, SearchJobStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e SearchJobStatus) String() string {
+	return string(e)
+}
+
+func (e *SearchJobStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SearchJobStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SearchJobStatus", str)
+	}
+	return nil
+}
+
+func (e SearchJobStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *SearchJobStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e SearchJobStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type StockStatus string
+
+const (
+	StockStatusInStock    StockStatus = "IN_STOCK"
+	StockStatusOutOfStock StockStatus = "OUT_OF_STOCK"
+	// The retailer didn't show availability in its search results.
+	StockStatusUnknown StockStatus = "UNKNOWN"
+)
+
+var AllStockStatus = []StockStatus{
+	StockStatusInStock,
+	StockStatusOutOfStock,
+	StockStatusUnknown,
+}
+
+func (e StockStatus) IsValid() bool {
+	switch e {
+	case StockStatusInStock, StockStatusOutOfStock, StockStatusUnknown:
+		return true
+	}
+	return false
+}
+
+func (e StockStatus) String() string {
+	return string(e)
+}
+
+func (e *StockStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StockStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StockStatus", str)
+	}
+	return nil
+}
+
+func (e StockStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *StockStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e StockStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type TrendWindow string
+
+const (
+	TrendWindowDay   TrendWindow = "DAY"
+	TrendWindowWeek  TrendWindow = "WEEK"
+	TrendWindowMonth TrendWindow = "MONTH"
+)
+
+var AllTrendWindow = []TrendWindow{
+	TrendWindowDay,
+	TrendWindowWeek,
+	TrendWindowMonth,
+}
+
+func (e TrendWindow) IsValid() bool {
+	switch e {
+	case TrendWindowDay, TrendWindowWeek, TrendWindowMonth:
+		return true
+	}
+	return false
+}
+
+func (e TrendWindow) String() string {
+	return string(e)
+}
+
+func (e *TrendWindow) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TrendWindow(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TrendWindow", str)
+	}
+	return nil
+}
+
+func (e TrendWindow) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *TrendWindow) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e TrendWindow) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type WatchAlertStatus string
+
+const (
+	WatchAlertStatusUnread    WatchAlertStatus = "UNREAD"
+	WatchAlertStatusRead      WatchAlertStatus = "READ"
+	WatchAlertStatusDismissed WatchAlertStatus = "DISMISSED"
+)
+
+var AllWatchAlertStatus = []WatchAlertStatus{
+	WatchAlertStatusUnread,
+	WatchAlertStatusRead,
+	WatchAlertStatusDismissed,
+}
+
+func (e WatchAlertStatus) IsValid() bool {
+	switch e {
+	case WatchAlertStatusUnread, WatchAlertStatusRead, WatchAlertStatusDismissed:
+		return true
+	}
+	return false
+}
+
+func (e WatchAlertStatus) String() string {
+	return string(e)
+}
+
+func (e *WatchAlertStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WatchAlertStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WatchAlertStatus", str)
+	}
+	return nil
+}
+
+func (e WatchAlertStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *WatchAlertStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e WatchAlertStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
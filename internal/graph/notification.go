@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"never-price-match-server/internal/graph/model"
+	"never-price-match-server/internal/notify"
+)
+
+func notificationModel(n *notify.Notification) *model.Notification {
+	return &model.Notification{
+		ID:        n.ID,
+		Kind:      n.Kind,
+		Title:     n.Title,
+		Body:      n.Body,
+		Link:      optionalString(n.Link),
+		Read:      n.ReadAt != nil,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
+func notificationPreferencesModel(p *notify.Preferences) *model.NotificationPreferences {
+	return &model.NotificationPreferences{
+		InApp:            p.InApp,
+		Email:            p.Email,
+		Webhook:          p.Webhook,
+		WebhookURL:       optionalString(p.WebhookURL),
+		WebhookSecretSet: p.WebhookSecret != "",
+		QuietHoursStart:  optionalString(p.QuietStart),
+		QuietHoursEnd:    optionalString(p.QuietEnd),
+		TimeZone:         p.TimeZone,
+	}
+}
+
+func notificationDeliveryModel(d *notify.Delivery) *model.NotificationDelivery {
+	m := &model.NotificationDelivery{
+		ID:             d.ID,
+		NotificationID: d.NotificationID,
+		Channel:        model.NotificationChannel(d.Channel),
+		Status:         model.NotificationDeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		LastError:      optionalString(d.LastError),
+		SentAt:         d.SentAt,
+		CreatedAt:      d.CreatedAt,
+	}
+	if d.Status == notify.DeliveryPending {
+		m.NextAttemptAt = &d.NextAttemptAt
+	}
+	return m
+}
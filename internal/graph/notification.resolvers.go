@@ -0,0 +1,74 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.81
+
+import (
+	"context"
+	"never-price-match-server/internal/graph/model"
+	"never-price-match-server/internal/notify"
+)
+
+// UpdateNotificationPreferences is the resolver for the updateNotificationPreferences field.
+func (r *mutationResolver) UpdateNotificationPreferences(ctx context.Context, input model.NotificationPreferencesInput) (*model.NotificationPreferences, error) {
+	p, err := r.NotificationService.UpdatePreferences(ctx, currentUserID(ctx), notify.PreferencesUpdate{
+		InApp:         input.InApp,
+		Email:         input.Email,
+		Webhook:       input.Webhook,
+		WebhookURL:    input.WebhookURL,
+		WebhookSecret: input.WebhookSecret,
+		QuietStart:    input.QuietHoursStart,
+		QuietEnd:      input.QuietHoursEnd,
+		TimeZone:      input.TimeZone,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notificationPreferencesModel(p), nil
+}
+
+// MarkNotificationsRead is the resolver for the markNotificationsRead field.
+func (r *mutationResolver) MarkNotificationsRead(ctx context.Context, ids []string) (int, error) {
+	return r.NotificationService.MarkRead(ctx, currentUserID(ctx), ids)
+}
+
+// Notifications is the resolver for the notifications field.
+func (r *queryResolver) Notifications(ctx context.Context, unreadOnly *bool, limit *int) ([]*model.Notification, error) {
+	ns, err := r.NotificationService.Inbox(ctx, currentUserID(ctx), boolValue(unreadOnly), intValue(limit, notify.DefaultInboxLimit))
+	if err != nil {
+		return nil, err
+	}
+	models := make([]*model.Notification, 0, len(ns))
+	for i := range ns {
+		models = append(models, notificationModel(&ns[i]))
+	}
+	return models, nil
+}
+
+// UnreadNotificationCount is the resolver for the unreadNotificationCount field.
+func (r *queryResolver) UnreadNotificationCount(ctx context.Context) (int, error) {
+	return r.NotificationService.UnreadCount(ctx, currentUserID(ctx))
+}
+
+// NotificationPreferences is the resolver for the notificationPreferences field.
+func (r *queryResolver) NotificationPreferences(ctx context.Context) (*model.NotificationPreferences, error) {
+	p, err := r.NotificationService.Preferences(ctx, currentUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return notificationPreferencesModel(p), nil
+}
+
+// NotificationDeliveries is the resolver for the notificationDeliveries field.
+func (r *queryResolver) NotificationDeliveries(ctx context.Context, limit *int) ([]*model.NotificationDelivery, error) {
+	deliveries, err := r.NotificationService.Deliveries(ctx, currentUserID(ctx), intValue(limit, notify.DefaultInboxLimit))
+	if err != nil {
+		return nil, err
+	}
+	models := make([]*model.NotificationDelivery, 0, len(deliveries))
+	for i := range deliveries {
+		models = append(models, notificationDeliveryModel(&deliveries[i]))
+	}
+	return models, nil
+}
@@ -0,0 +1,51 @@
+package graph
+
+import (
+	"never-price-match-server/internal/graph/model"
+	"never-price-match-server/internal/pricematch"
+)
+
+func priceMatchOptionsModel(opts *pricematch.Options) *model.PriceMatchOptions {
+	competitors := opts.Policy.Competitors
+	if competitors == nil {
+		competitors = []string{}
+	}
+	offers := make([]*model.PriceMatchOffer, 0, len(opts.Offers))
+	for _, o := range opts.Offers {
+		offer := &model.PriceMatchOffer{
+			Offer:    productModel(o.Platform, o.Product),
+			Eligible: o.Eligible,
+			Reasons:  nonNil(o.Reasons),
+			Notes:    nonNil(o.Notes),
+		}
+		if o.Eligible {
+			matchPrice := o.MatchPrice
+			offer.MatchPrice = &matchPrice
+		}
+		offers = append(offers, offer)
+	}
+	return &model.PriceMatchOptions{
+		Policy: &model.PriceMatchPolicy{
+			Retailer:                   opts.Policy.Retailer,
+			Competitors:                competitors,
+			BeatPercent:                opts.Policy.BeatPercent,
+			ExcludesMarketplaceSellers: opts.Policy.ExcludeMarketplace,
+			ExcludesClearance:          opts.Policy.ExcludeClearance,
+			RequiresInStock:            opts.Policy.RequireInStock,
+			RequiresIdenticalModel:     opts.Policy.RequireIdenticalModel,
+			PriceProtectionDays:        opts.Policy.ProtectionDays,
+		},
+		RetailerPrice: opts.RetailerPrice,
+		BestPrice:     opts.BestPrice,
+		Saving:        opts.Saving,
+		Offers:        offers,
+	}
+}
+
+// nonNil turns a nil slice into an empty one for non-null GraphQL lists.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"never-price-match-server/internal/graph/model"
+	"never-price-match-server/internal/product"
+)
+
+// productModels flattens per-platform results into the GraphQL Product list.
+func productModels(results []product.ScrapeResult) []*model.Product {
+	var products []*model.Product
+	for _, platformResult := range results {
+		for _, p := range platformResult.Products {
+			products = append(products, productModel(platformResult.Platform, p))
+		}
+	}
+	return products
+}
+
+func productModel(platform string, p product.ScrapedProduct) *model.Product {
+	return &model.Product{
+		ListingID:   optionalID(p.ListingID),
+		Platform:    platform,
+		ProductName: p.Name,
+		Price:       p.Price,
+		ImageURL:    p.ImageURL,
+		Link:        p.Link,
+		Sponsored:   p.Sponsored,
+		Seller:      optionalString(p.Seller),
+		FulfilledBy: optionalString(p.FulfilledBy),
+		ThirdParty:  p.ThirdParty,
+		StockStatus: stockStatus(p.StockStatus),
+		Clearance:   p.Clearance,
+		Source:      model.ResultSource(p.Source),
+		ScrapedAt:   p.ScrapedAt,
+		Stale:       p.Stale,
+	}
+}
+
+func stockStatus(s product.StockStatus) model.StockStatus {
+	if s == product.StockUnknown {
+		return model.StockStatusUnknown
+	}
+	return model.StockStatus(s)
+}
+
+func searchSummaryModel(s product.SearchSummary) *model.SearchSummary {
+	m := &model.SearchSummary{
+		Offers:             s.Offers,
+		PlatformsSearched:  s.PlatformsSearched,
+		PlatformsSucceeded: s.PlatformsSucceeded,
+		PlatformsFailed:    s.PlatformsFailed,
+		FailedPlatforms:    nonNil(s.FailedPlatforms),
+	}
+	if s.Offers == 0 {
+		return m
+	}
+	m.LowestPrice = &s.LowestPrice
+	m.LowestPlatform = &s.LowestPlatform
+	m.LowestListingID = optionalID(s.LowestListingID)
+	m.MedianPrice = &s.MedianPrice
+	m.HighestPrice = &s.HighestPrice
+	m.PriceSpread = &s.PriceSpread
+	m.HighestPlatform = &s.HighestPlatform
+	m.PotentialSaving = &s.PotentialSaving
+	m.PotentialSavingPercent = &s.PotentialSavingPercent
+	return m
+}
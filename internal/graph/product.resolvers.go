@@ -6,41 +6,165 @@ package graph
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"never-price-match-server/internal/graph/model"
+	"never-price-match-server/internal/product"
+	"never-price-match-server/internal/scrapejob"
+	"strconv"
+	"time"
 )
 
+// RecordSuggestionClick is the resolver for the recordSuggestionClick field.
+func (r *mutationResolver) RecordSuggestionClick(ctx context.Context, text string) (bool, error) {
+	r.ProductService.RecordSuggestionClick(text)
+	return true, nil
+}
+
+// StartSearch is the resolver for the startSearch field.
+func (r *mutationResolver) StartSearch(ctx context.Context, name string, category string) (string, error) {
+	job, err := r.ScrapeJobService.Enqueue(ctx, name, category, currentUserID(ctx))
+	if err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// CreateEvidencePack is the resolver for the createEvidencePack field.
+func (r *mutationResolver) CreateEvidencePack(ctx context.Context, listingIds []string) (*model.EvidencePack, error) {
+	ids := make([]uint, 0, len(listingIds))
+	for _, listingID := range listingIds {
+		id, err := strconv.ParseUint(listingID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid listing id %q", listingID)
+		}
+		ids = append(ids, uint(id))
+	}
+	pack, err := r.EvidenceService.Create(ctx, ids, currentUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return r.evidencePackModel(pack), nil
+}
+
 // SearchProduct is the resolver for the searchProduct field.
 // It calls the service layer and maps the results to the GraphQL model.
-func (r *queryResolver) SearchProduct(ctx context.Context, name string, category string) ([]*model.Product, error) {
+func (r *queryResolver) SearchProduct(ctx context.Context, name string, category string, excludeSponsored *bool, excludeThirdParty *bool) ([]*model.Product, error) {
 	// 1. Call the service, which returns a list of results from all platforms
 	// (either from cache or a live scrape).
-	scrapeResults, err := r.ProductService.SearchAndScrape(name, category)
+	start := time.Now()
+	scrapeResults, err := r.ProductService.SearchAndScrape(ctx, name, category)
 	if err != nil {
 		return nil, err
 	}
+	scrapeResults = product.FilterListings(scrapeResults, product.ListingFilter{
+		ExcludeSponsored:  boolValue(excludeSponsored),
+		ExcludeThirdParty: boolValue(excludeThirdParty),
+	})
 
-	// 2. Prepare a flat list to hold all individual products for the GraphQL response.
-	var finalProductList []*model.Product
+	// 2. Flatten the per-platform results into the GraphQL response.
+	finalProductList := productModels(scrapeResults)
 
-	// 3. Iterate through the results from each platform.
-	for _, platformResult := range scrapeResults {
-		// 4. For each platform, iterate through the products found there.
-		for _, product := range platformResult.Products {
-			// 5. Create a GraphQL model object.
-			finalProductList = append(finalProductList, &model.Product{
-				Platform:    platformResult.Platform,
-				ProductName: product.Name,
-				Price:       product.Price,
-				ImageURL:    product.ImageURL,
-				Link:        product.Link,
-			})
-		}
+	r.recordSearch(ctx, name, category, scrapeResults, len(finalProductList), time.Since(start))
+	return finalProductList, nil
+}
+
+// Search is the resolver for the search field.
+func (r *queryResolver) Search(ctx context.Context, name string, category string, excludeSponsored *bool, excludeThirdParty *bool) (*model.SearchResponse, error) {
+	start := time.Now()
+	res, err := r.ProductService.Search(ctx, name, category, product.ListingFilter{
+		ExcludeSponsored:  boolValue(excludeSponsored),
+		ExcludeThirdParty: boolValue(excludeThirdParty),
+	})
+	if err != nil {
+		return nil, err
+	}
+	products := productModels(res.Results)
+	if products == nil {
+		products = []*model.Product{}
 	}
 
-	return finalProductList, nil
+	r.recordSearch(ctx, name, category, res.Results, len(products), time.Since(start))
+	return &model.SearchResponse{Products: products, SearchSummary: searchSummaryModel(res.Summary)}, nil
 }
 
 // ProductSuggestions is the resolver for the productSuggestions field.
 func (r *queryResolver) ProductSuggestions(ctx context.Context, name string) ([]string, error) {
 	return r.ProductService.GetProductSuggestions(name)
 }
+
+// TrendingSearches is the resolver for the trendingSearches field.
+func (r *queryResolver) TrendingSearches(ctx context.Context, window *model.TrendWindow, limit *int) ([]*model.SearchTrend, error) {
+	stats, err := r.SearchLogService.Trending(ctx, trendWindow(window), intValue(limit, 20))
+	if err != nil {
+		return nil, err
+	}
+	return searchTrends(stats), nil
+}
+
+// ZeroResultSearches is the resolver for the zeroResultSearches field.
+func (r *queryResolver) ZeroResultSearches(ctx context.Context, window *model.TrendWindow, limit *int) ([]*model.SearchTrend, error) {
+	stats, err := r.SearchLogService.ZeroResults(ctx, trendWindow(window), intValue(limit, 20))
+	if err != nil {
+		return nil, err
+	}
+	return searchTrends(stats), nil
+}
+
+// SearchJob is the resolver for the searchJob field.
+func (r *queryResolver) SearchJob(ctx context.Context, id string) (*model.SearchJob, error) {
+	progress, err := r.ScrapeJobService.Get(ctx, id)
+	if errors.Is(err, scrapejob.ErrJobNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return searchJobModel(progress), nil
+}
+
+// PriceHistory is the resolver for the priceHistory field.
+func (r *queryResolver) PriceHistory(ctx context.Context, listingID string, from *time.Time, to *time.Time, granularity *model.PriceHistoryGranularity) (*model.PriceHistory, error) {
+	id, err := strconv.ParseUint(listingID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listing id %q", listingID)
+	}
+	g := product.GranularityDay
+	if granularity != nil {
+		g = product.Granularity(*granularity)
+	}
+
+	history, err := r.ProductService.PriceHistory(ctx, uint(id), timeValue(from), timeValue(to), g)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]*model.PricePoint, 0, len(history.Points))
+	for _, p := range history.Points {
+		points = append(points, &model.PricePoint{
+			PeriodStart:  p.PeriodStart,
+			Min:          p.Min,
+			Max:          p.Max,
+			Close:        p.Close,
+			Observations: p.Observations,
+		})
+	}
+	return &model.PriceHistory{
+		ListingID:   listingID,
+		Platform:    history.Listing.Platform,
+		ProductName: history.Listing.Name,
+		Link:        history.Listing.Link,
+		From:        history.From,
+		To:          history.To,
+		Points:      points,
+	}, nil
+}
+
+// PriceMatchOptions is the resolver for the priceMatchOptions field.
+func (r *queryResolver) PriceMatchOptions(ctx context.Context, productQuery string, atRetailer string, category string) (*model.PriceMatchOptions, error) {
+	opts, err := r.PriceMatchService.Options(ctx, productQuery, category, atRetailer)
+	if err != nil {
+		return nil, err
+	}
+	return priceMatchOptionsModel(opts), nil
+}
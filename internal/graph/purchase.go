@@ -0,0 +1,54 @@
+package graph
+
+import (
+	"strconv"
+	"time"
+
+	"never-price-match-server/internal/graph/model"
+	"never-price-match-server/internal/purchase"
+)
+
+func purchaseModel(s purchase.Summary) *model.Purchase {
+	p := s.Purchase
+	alerts := make([]*model.PriceProtectionAlert, 0, len(s.Alerts))
+	for _, a := range s.Alerts {
+		alerts = append(alerts, priceProtectionAlertModel(a, &p))
+	}
+	return &model.Purchase{
+		ID:               p.ID,
+		ListingID:        strconv.FormatUint(uint64(p.ListingID), 10),
+		Retailer:         p.Retailer,
+		ProductName:      p.ProductName,
+		Link:             p.Link,
+		PricePaid:        p.PricePaid,
+		PurchasedAt:      p.PurchasedAt,
+		ProtectionEndsAt: p.ProtectionEndsAt,
+		ProtectionActive: p.Protected(time.Now()),
+		CurrentPrice:     s.CurrentPrice,
+		LowestPrice:      p.LowestPrice,
+		LastCheckedAt:    p.LastCheckedAt,
+		ClaimedSavings:   s.Claimed,
+		ClaimableSavings: s.Claimable,
+		Alerts:           alerts,
+	}
+}
+
+// priceProtectionAlertModel maps an alert; p is its purchase, which is always loaded
+// with it.
+func priceProtectionAlertModel(a purchase.Alert, p *purchase.Purchase) *model.PriceProtectionAlert {
+	m := &model.PriceProtectionAlert{
+		ID:         a.ID,
+		PurchaseID: a.PurchaseID,
+		PricePaid:  a.PricePaid,
+		NewPrice:   a.NewPrice,
+		Refund:     a.Refund,
+		ObservedAt: a.ObservedAt,
+		ClaimBy:    a.ClaimBy,
+		Status:     model.PriceProtectionAlertStatus(a.State(time.Now())),
+	}
+	if p != nil {
+		m.Retailer = p.Retailer
+		m.ProductName = p.ProductName
+	}
+	return m
+}
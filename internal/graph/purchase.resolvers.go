@@ -0,0 +1,95 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.81
+
+import (
+	"context"
+	"fmt"
+	"never-price-match-server/internal/graph/model"
+	"never-price-match-server/internal/purchase"
+	"strconv"
+)
+
+// RecordPurchase is the resolver for the recordPurchase field.
+func (r *mutationResolver) RecordPurchase(ctx context.Context, input model.RecordPurchaseInput) (*model.Purchase, error) {
+	listingID, err := strconv.ParseUint(input.ListingID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listing id %q", input.ListingID)
+	}
+	summary, err := r.PurchaseService.Record(ctx, currentUserID(ctx), uint(listingID), input.PricePaid, timeValue(input.PurchasedAt))
+	if err != nil {
+		return nil, err
+	}
+	return purchaseModel(*summary), nil
+}
+
+// DeletePurchase is the resolver for the deletePurchase field.
+func (r *mutationResolver) DeletePurchase(ctx context.Context, id string) (bool, error) {
+	if err := r.PurchaseService.Delete(ctx, currentUserID(ctx), id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ClaimPriceProtection is the resolver for the claimPriceProtection field.
+func (r *mutationResolver) ClaimPriceProtection(ctx context.Context, alertID string) (*model.PriceProtectionAlert, error) {
+	a, err := r.PurchaseService.Claim(ctx, currentUserID(ctx), alertID)
+	if err != nil {
+		return nil, err
+	}
+	return priceProtectionAlertModel(*a, a.Purchase), nil
+}
+
+// DismissPriceProtectionAlert is the resolver for the dismissPriceProtectionAlert field.
+func (r *mutationResolver) DismissPriceProtectionAlert(ctx context.Context, alertID string) (*model.PriceProtectionAlert, error) {
+	a, err := r.PurchaseService.Dismiss(ctx, currentUserID(ctx), alertID)
+	if err != nil {
+		return nil, err
+	}
+	return priceProtectionAlertModel(*a, a.Purchase), nil
+}
+
+// Purchases is the resolver for the purchases field.
+func (r *queryResolver) Purchases(ctx context.Context) ([]*model.Purchase, error) {
+	summaries, err := r.PurchaseService.List(ctx, currentUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	purchases := make([]*model.Purchase, 0, len(summaries))
+	for _, s := range summaries {
+		purchases = append(purchases, purchaseModel(s))
+	}
+	return purchases, nil
+}
+
+// PriceProtectionAlerts is the resolver for the priceProtectionAlerts field.
+func (r *queryResolver) PriceProtectionAlerts(ctx context.Context, status *model.PriceProtectionAlertStatus) ([]*model.PriceProtectionAlert, error) {
+	var filter purchase.AlertStatus
+	if status != nil {
+		filter = purchase.AlertStatus(*status)
+	}
+	alerts, err := r.PurchaseService.Alerts(ctx, currentUserID(ctx), filter)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]*model.PriceProtectionAlert, 0, len(alerts))
+	for _, a := range alerts {
+		models = append(models, priceProtectionAlertModel(a, a.Purchase))
+	}
+	return models, nil
+}
+
+// PurchaseSavings is the resolver for the purchaseSavings field.
+func (r *queryResolver) PurchaseSavings(ctx context.Context) (*model.PurchaseSavings, error) {
+	savings, err := r.PurchaseService.Savings(ctx, currentUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &model.PurchaseSavings{
+		Purchases: savings.Purchases,
+		Claimed:   savings.Claimed,
+		Claimable: savings.Claimable,
+	}, nil
+}
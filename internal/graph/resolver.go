@@ -1,8 +1,16 @@
 package graph
 
 import (
+	"never-price-match-server/internal/evidence"
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/notify"
+	"never-price-match-server/internal/pricematch"
 	"never-price-match-server/internal/product"
+	"never-price-match-server/internal/purchase"
+	"never-price-match-server/internal/scrapejob"
+	"never-price-match-server/internal/searchlog"
 	"never-price-match-server/internal/user"
+	"never-price-match-server/internal/watchlist"
 )
 
 // This file will not be regenerated automatically.
@@ -10,6 +18,14 @@ import (
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	UserService    user.Service
-	ProductService product.Service
+	UserService         user.Service
+	ProductService      product.Service
+	SearchLogService    searchlog.Service
+	ScrapeJobService    scrapejob.Service
+	PriceMatchService   pricematch.Service
+	EvidenceService     evidence.Service
+	PurchaseService     purchase.Service
+	WatchlistService    watchlist.Service
+	NotificationService notify.Service
+	Log                 *logger.Logger
 }
@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"never-price-match-server/internal/graph/model"
+	"never-price-match-server/internal/scrapejob"
+)
+
+func searchJobModel(p *scrapejob.Progress) *model.SearchJob {
+	platforms := make([]*model.PlatformProgress, 0, len(p.Platforms))
+	for _, jp := range p.Platforms {
+		platforms = append(platforms, &model.PlatformProgress{
+			Platform:    jp.Platform,
+			Status:      model.PlatformScrapeStatus(jp.Status),
+			ResultCount: jp.ResultCount,
+			Error:       optionalString(jp.Error),
+			FinishedAt:  optionalTime(jp.FinishedAt),
+		})
+	}
+	results := productModels(p.Results)
+	if results == nil {
+		results = []*model.Product{}
+	}
+	return &model.SearchJob{
+		ID:            p.ID,
+		Name:          p.Term,
+		Category:      p.Category,
+		Status:        model.SearchJobStatus(p.Status),
+		Platforms:     platforms,
+		Results:       results,
+		Error:         optionalString(p.Error),
+		SearchSummary: searchSummaryModel(p.Summary),
+		CreatedAt:     p.CreatedAt,
+		FinishedAt:    optionalTime(p.FinishedAt),
+	}
+}
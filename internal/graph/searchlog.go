@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"never-price-match-server/internal/graph/model"
+	"never-price-match-server/internal/product"
+	"never-price-match-server/internal/searchlog"
+)
+
+// recordSearch adds a searchProduct call to the search log. A search counts as a
+// cache hit when any of its results was served from the cache.
+func (r *queryResolver) recordSearch(ctx context.Context, name, category string, results []product.ScrapeResult, resultCount int, latency time.Duration) {
+	if r.SearchLogService == nil {
+		return
+	}
+	cacheHit := false
+	for _, res := range results {
+		for _, p := range res.Products {
+			if p.Source == product.SourceCache {
+				cacheHit = true
+			}
+		}
+	}
+	r.SearchLogService.Record(ctx, searchlog.Entry{
+		Term:             name,
+		Category:         category,
+		InferredCategory: product.InferCategory(name),
+		ResultCount:      resultCount,
+		CacheHit:         cacheHit,
+		Latency:          latency,
+		UserID:           currentUserID(ctx),
+	})
+}
+
+// trendWindow reads an optional TrendWindow argument, defaulting to a week.
+func trendWindow(w *model.TrendWindow) searchlog.Window {
+	if w == nil {
+		return searchlog.WindowWeek
+	}
+	return searchlog.Window(*w)
+}
+
+func searchTrends(stats []searchlog.TermStats) []*model.SearchTrend {
+	trends := make([]*model.SearchTrend, 0, len(stats))
+	for _, t := range stats {
+		trends = append(trends, &model.SearchTrend{
+			Term:               t.Term,
+			Searches:           t.Searches,
+			ZeroResultSearches: t.ZeroResults,
+			CacheHitRate:       t.CacheHitRate(),
+			AvgLatencyMs:       t.AvgLatencyMs(),
+			InferredCategory:   optionalString(t.InferredCategory),
+		})
+	}
+	return trends
+}
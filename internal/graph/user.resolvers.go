@@ -17,7 +17,7 @@ import (
 
 // CreateUser is the resolver for the createUser field.
 func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUserInput) (*model.User, error) {
-	u, err := r.UserService.Create(input.Name, input.Email, input.Password)
+	u, err := r.UserService.Create(ctx, input.Name, input.Email, input.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -26,14 +26,14 @@ func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUse
 
 // Login is the resolver for the login field.
 func (r *mutationResolver) Login(ctx context.Context, input model.LoginInput) (*model.AuthPayload, error) {
-	u, err := r.UserService.Login(input.Email, input.Password)
+	u, err := r.UserService.Login(ctx, input.Email, input.Password)
 	if err != nil {
 		return &model.AuthPayload{Ok: false, User: nil}, err
 	}
 
 	token, err := auth.Sign(u.ID, 7*24*time.Hour)
 	if err != nil {
-		logger.L.Error("sign token failed", logger.Err(err))
+		r.Log.Error("sign token failed", logger.Err(err))
 		return nil, err
 	}
 
@@ -59,7 +59,7 @@ func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
 	gc := httpctx.Gin(ctx)
 	v, _ := gc.Get("uid")
 	uid, _ := v.(string)
-	u, err := r.UserService.Get(uid)
+	u, err := r.UserService.Get(ctx, uid)
 	if err != nil || u == nil {
 		return nil, err
 	}
@@ -68,7 +68,7 @@ func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
 
 // User is the resolver for the user field.
 func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
-	u, err := r.UserService.Get(id)
+	u, err := r.UserService.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -82,7 +82,7 @@ func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
 
 // CheckEmailExist is the resolver for the checkEmailExist field.
 func (r *queryResolver) CheckEmailExist(ctx context.Context, email string) (bool, error) {
-	return r.UserService.CheckEmailExist(email)
+	return r.UserService.CheckEmailExist(ctx, email)
 }
 
 // Mutation returns generated.MutationResolver implementation.
@@ -0,0 +1,43 @@
+package graph
+
+import (
+	"strconv"
+	"time"
+
+	"never-price-match-server/internal/graph/model"
+	"never-price-match-server/internal/watchlist"
+)
+
+func watchModel(w *watchlist.Watch) *model.Watch {
+	m := &model.Watch{
+		ID:           w.ID,
+		Term:         optionalString(w.Term),
+		TargetPrice:  w.TargetPrice,
+		Platforms:    nonNil(w.Platforms),
+		PriceMatchAt: optionalString(w.PriceMatchAt),
+		ExpiresAt:    w.ExpiresAt,
+		Active:       w.Active(time.Now()),
+		CreatedAt:    w.CreatedAt,
+	}
+	if w.ListingID != nil {
+		m.ListingID = optionalID(*w.ListingID)
+	}
+	return m
+}
+
+func watchAlertModel(a *watchlist.Alert) *model.WatchAlert {
+	return &model.WatchAlert{
+		ID:          a.ID,
+		WatchID:     a.WatchID,
+		ListingID:   strconv.FormatUint(uint64(a.ListingID), 10),
+		Platform:    a.Platform,
+		ProductName: a.ProductName,
+		Link:        a.Link,
+		Price:       a.Price,
+		MatchPrice:  a.MatchPrice,
+		TargetPrice: a.TargetPrice,
+		Status:      model.WatchAlertStatus(a.Status),
+		RaisedAt:    a.RaisedAt,
+		ReadAt:      a.ReadAt,
+	}
+}
@@ -0,0 +1,93 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.81
+
+import (
+	"context"
+	"fmt"
+	"never-price-match-server/internal/graph/model"
+	"never-price-match-server/internal/watchlist"
+	"strconv"
+)
+
+// CreateWatch is the resolver for the createWatch field.
+func (r *mutationResolver) CreateWatch(ctx context.Context, input model.CreateWatchInput) (*model.Watch, error) {
+	in := watchlist.NewWatch{
+		TargetPrice: input.TargetPrice,
+		Platforms:   input.Platforms,
+		ExpiresAt:   input.ExpiresAt,
+	}
+	if input.Term != nil {
+		in.Term = *input.Term
+	}
+	if input.PriceMatchAt != nil {
+		in.PriceMatchAt = *input.PriceMatchAt
+	}
+	if input.ListingID != nil {
+		id, err := strconv.ParseUint(*input.ListingID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid listing id %q", *input.ListingID)
+		}
+		listingID := uint(id)
+		in.ListingID = &listingID
+	}
+	w, err := r.WatchlistService.Create(ctx, currentUserID(ctx), in)
+	if err != nil {
+		return nil, err
+	}
+	return watchModel(w), nil
+}
+
+// DeleteWatch is the resolver for the deleteWatch field.
+func (r *mutationResolver) DeleteWatch(ctx context.Context, id string) (bool, error) {
+	if err := r.WatchlistService.Delete(ctx, currentUserID(ctx), id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MarkWatchAlertsRead is the resolver for the markWatchAlertsRead field.
+func (r *mutationResolver) MarkWatchAlertsRead(ctx context.Context, ids []string) (int, error) {
+	return r.WatchlistService.MarkRead(ctx, currentUserID(ctx), ids)
+}
+
+// DismissWatchAlert is the resolver for the dismissWatchAlert field.
+func (r *mutationResolver) DismissWatchAlert(ctx context.Context, id string) (*model.WatchAlert, error) {
+	a, err := r.WatchlistService.Dismiss(ctx, currentUserID(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	return watchAlertModel(a), nil
+}
+
+// Watches is the resolver for the watches field.
+func (r *queryResolver) Watches(ctx context.Context) ([]*model.Watch, error) {
+	watches, err := r.WatchlistService.List(ctx, currentUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	models := make([]*model.Watch, 0, len(watches))
+	for i := range watches {
+		models = append(models, watchModel(&watches[i]))
+	}
+	return models, nil
+}
+
+// WatchAlerts is the resolver for the watchAlerts field.
+func (r *queryResolver) WatchAlerts(ctx context.Context, status *model.WatchAlertStatus) ([]*model.WatchAlert, error) {
+	var filter watchlist.AlertStatus
+	if status != nil {
+		filter = watchlist.AlertStatus(*status)
+	}
+	alerts, err := r.WatchlistService.Alerts(ctx, currentUserID(ctx), filter)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]*model.WatchAlert, 0, len(alerts))
+	for i := range alerts {
+		models = append(models, watchAlertModel(&alerts[i]))
+	}
+	return models, nil
+}
@@ -0,0 +1,50 @@
+// Package date stores calendar dates the same way on every database.
+package date
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// Date is a UTC calendar date. It is written as "YYYY-MM-DD" rather than a timestamp,
+// so no driver shifts it into its connection's time zone.
+type Date struct{ time.Time }
+
+// Value implements driver.Valuer.
+func (d Date) Value() (driver.Value, error) {
+	return d.UTC().Format(time.DateOnly), nil
+}
+
+// Scan implements sql.Scanner.
+func (d *Date) Scan(src any) error {
+	switch v := src.(type) {
+	case time.Time:
+		d.Time = time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, time.UTC)
+		return nil
+	case string:
+		return d.parse(v)
+	case []byte:
+		return d.parse(string(v))
+	default:
+		return fmt.Errorf("date: cannot scan %T into Date", src)
+	}
+}
+
+func (d *Date) parse(s string) error {
+	if len(s) < len(time.DateOnly) {
+		return fmt.Errorf("date: invalid date %q", s)
+	}
+	t, err := time.Parse(time.DateOnly, s[:len(time.DateOnly)])
+	if err != nil {
+		return err
+	}
+	d.Time = t
+	return nil
+}
+
+// Of is the UTC date of t.
+func Of(t time.Time) Date {
+	y, m, d := t.UTC().Date()
+	return Date{time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+}
@@ -1,37 +1,56 @@
 package db
 
 import (
-	"log"
+	"fmt"
 
+	"github.com/glebarez/sqlite"
 	"github.com/spf13/viper"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+// Supported values of `database.type`.
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
 
-func Init() {
-	env := viper.GetString("env") 
-	if e := viper.GetString("APP_ENV"); e != "" {
-		env = e                     
+// Connect opens the database described by cfg. The DSN is read from
+// database.<type>_docker when env is "docker" and database.<type>_local otherwise.
+func Connect(cfg *viper.Viper, env string) (*gorm.DB, error) {
+	driver := cfg.GetString("database.type")
+	if driver == "" {
+		driver = MySQL
 	}
 
 	var dsn string
 	switch env {
 	case "docker":
-		dsn = viper.GetString("database.mysql_docker")
-	case "dev", "local":
-		dsn = viper.GetString("database.mysql_local")
+		dsn = cfg.GetString("database." + driver + "_docker")
 	default:
-		dsn = viper.GetString("database.mysql_local")
+		dsn = cfg.GetString("database." + driver + "_local")
 	}
 
-	if dsn == "" { log.Fatal("empty DSN: check config.database.mysql_local / APP_ENV") }
-
+	if dsn == "" {
+		return nil, fmt.Errorf("empty DSN: check config.database.%s_local / APP_ENV", driver)
+	}
+	return Open(driver, dsn)
+}
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatalf("db connect failed: %v", err)
+// Open connects to the database of the given type ("mysql", "postgres" or "sqlite").
+func Open(driver, dsn string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case MySQL:
+		dialector = mysql.Open(dsn)
+	case Postgres:
+		dialector = postgres.Open(dsn)
+	case SQLite:
+		dialector = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unknown database type %q (want mysql, postgres or sqlite)", driver)
 	}
-	DB = db
+	return gorm.Open(dialector, &gorm.Config{})
 }
@@ -3,21 +3,24 @@ package logger
 import (
 	"os"
 	"time"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var L *zap.Logger
+// Logger is the structured logger handed to every component that logs.
+type Logger = zap.Logger
 
-func Init(env string) {
+// New builds the JSON logger for env ("dev" enables development mode).
+func New(env string) *Logger {
 	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
-		TimeKey:       "ts",
-		LevelKey:      "level",
-		MessageKey:    "msg",
-		CallerKey:     "caller",
-		EncodeTime:    zapcore.TimeEncoderOfLayout(time.RFC3339),
-		EncodeLevel:   zapcore.LowercaseLevelEncoder,
-		EncodeCaller:  zapcore.ShortCallerEncoder,
+		TimeKey:      "ts",
+		LevelKey:     "level",
+		MessageKey:   "msg",
+		CallerKey:    "caller",
+		EncodeTime:   zapcore.TimeEncoderOfLayout(time.RFC3339),
+		EncodeLevel:  zapcore.LowercaseLevelEncoder,
+		EncodeCaller: zapcore.ShortCallerEncoder,
 	})
 	level := zapcore.InfoLevel
 	ws := zapcore.AddSync(os.Stdout)
@@ -27,14 +30,14 @@ func Init(env string) {
 	if env == "dev" {
 		opts = append(opts, zap.Development())
 	}
-	L = zap.New(core, opts...)
+	return zap.New(core, opts...)
 }
 
-func Sync() { _ = L.Sync() }
-
+// Nop returns a logger that discards everything, for tests.
+func Nop() *Logger { return zap.NewNop() }
 
-func Field(k string, v any) zap.Field { return zap.Any(k, v) }
-func Err(err error) zap.Field                 { return zap.Error(err) }
-func Str(k, v string) zap.Field               { return zap.String(k, v) }
-func Int(k string, v int) zap.Field           { return zap.Int(k, v) }
-func Dur(k string, v interface{}) zap.Field   { return zap.Any(k, v) }
+func Field(k string, v any) zap.Field       { return zap.Any(k, v) }
+func Err(err error) zap.Field               { return zap.Error(err) }
+func Str(k, v string) zap.Field             { return zap.String(k, v) }
+func Int(k string, v int) zap.Field         { return zap.Int(k, v) }
+func Dur(k string, v interface{}) zap.Field { return zap.Any(k, v) }
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+	"sync"
+)
+
+// Counters are published through the standard expvar registry so they can be
+// scraped from /debug/vars without pulling in an external metrics stack.
+
+var mu sync.Mutex
+
+// counterMap returns the named expvar map, creating it on first use.
+func counterMap(name string) *expvar.Map {
+	if v, ok := expvar.Get(name).(*expvar.Map); ok {
+		return v
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if v, ok := expvar.Get(name).(*expvar.Map); ok {
+		return v
+	}
+	return expvar.NewMap(name)
+}
+
+// Add increments the counter `key` inside the metric `name` by delta.
+func Add(name, key string, delta int64) {
+	counterMap(name).Add(key, delta)
+}
+
+// Publish serves the value returned by f under name, replacing what was published there.
+func Publish(name string, f func() any) {
+	mu.Lock()
+	defer mu.Unlock()
+	if v, ok := expvar.Get(name).(*funcVar); ok {
+		v.set(f)
+		return
+	}
+	v := &funcVar{}
+	v.set(f)
+	expvar.Publish(name, v)
+}
+
+// funcVar is an expvar.Func whose function can be replaced, since expvar names can't
+// be published twice.
+type funcVar struct {
+	mu sync.Mutex
+	f  func() any
+}
+
+func (v *funcVar) set(f func() any) {
+	v.mu.Lock()
+	v.f = f
+	v.mu.Unlock()
+}
+
+func (v *funcVar) String() string {
+	v.mu.Lock()
+	f := v.f
+	v.mu.Unlock()
+	return expvar.Func(f).String()
+}
+
+// Handler serves all published metrics as JSON.
+func Handler() http.Handler { return expvar.Handler() }
@@ -0,0 +1,211 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one reversible, versioned schema change.
+// Up and Down run inside a transaction where the database supports transactional DDL.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration is a row of the schema_migrations table: one applied migration.
+type SchemaMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time
+}
+
+// Status describes a known migration and whether it has been applied.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// ErrNothingToRollBack is returned by Down when no migration has been applied.
+var ErrNothingToRollBack = errors.New("no applied migrations to roll back")
+
+// Migrator applies and reverts migrations, tracking them in schema_migrations.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New creates a Migrator for the given migrations, which are ordered by version.
+func New(db *gorm.DB, migrations []Migration) *Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &Migrator{db: db, migrations: sorted}
+}
+
+// Up applies every pending migration in version order and returns the ones applied.
+// It stops at the first failure; migrations applied before it stay applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mg := range m.migrations {
+		if _, ok := applied[mg.Version]; ok {
+			continue
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := mg.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: mg.Version, Name: mg.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s failed: %w", mg.Version, mg.Name, err)
+		}
+		done = append(done, mg)
+	}
+	return done, nil
+}
+
+// Down reverts the most recently applied migrations, at most steps of them,
+// and returns the ones reverted, latest first.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) == 0 {
+		return nil, ErrNothingToRollBack
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mg := m.migrations[i]
+		if _, ok := applied[mg.Version]; !ok {
+			continue
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if mg.Down == nil {
+				return errors.New("migration is not reversible")
+			}
+			indexes, err := sqliteIndexes(tx)
+			if err != nil {
+				return err
+			}
+			if err := mg.Down(tx); err != nil {
+				return err
+			}
+			if err := restoreSQLiteIndexes(tx, indexes); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, mg.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("rollback of %d_%s failed: %w", mg.Version, mg.Name, err)
+		}
+		done = append(done, mg)
+	}
+	return done, nil
+}
+
+// Status lists every known migration with its applied state, in version order.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		row, ok := applied[mg.Version]
+		statuses = append(statuses, Status{
+			Version:   mg.Version,
+			Name:      mg.Name,
+			Applied:   ok,
+			AppliedAt: row.AppliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// applied returns the applied migrations keyed by version, creating the tracking table if needed.
+func (m *Migrator) applied() (map[int64]SchemaMigration, error) {
+	if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("prepare schema_migrations: %w", err)
+	}
+	var rows []SchemaMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]SchemaMigration, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// sqliteIndex is a SQLite index as it was before a rollback.
+type sqliteIndex struct {
+	Name      string
+	TblName   string
+	SQL       string
+	TableRoot int      // the root page of its table, which changes when the table is rebuilt
+	Columns   []string `gorm:"-"`
+}
+
+// sqliteIndexes lists the explicitly created indexes of a SQLite database; on other
+// databases it returns nil. The SQLite migrator drops or alters a column by
+// rebuilding the table, which silently drops the table's indexes along with it.
+func sqliteIndexes(tx *gorm.DB) ([]sqliteIndex, error) {
+	if tx.Dialector.Name() != "sqlite" {
+		return nil, nil
+	}
+	var indexes []sqliteIndex
+	err := tx.Raw(`SELECT i.name, i.tbl_name, i.sql, t.rootpage AS table_root
+		FROM sqlite_master i JOIN sqlite_master t ON t.type = 'table' AND t.name = i.tbl_name
+		WHERE i.type = 'index' AND i.sql IS NOT NULL`).Scan(&indexes).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range indexes {
+		if err := tx.Raw("SELECT name FROM pragma_index_info(?)", indexes[i].Name).Scan(&indexes[i].Columns).Error; err != nil {
+			return nil, err
+		}
+	}
+	return indexes, nil
+}
+
+// restoreSQLiteIndexes recreates the indexes a rollback lost by rebuilding their table.
+// An index whose table was not rebuilt, or one of whose columns is gone, was dropped
+// on purpose and stays dropped.
+func restoreSQLiteIndexes(tx *gorm.DB, indexes []sqliteIndex) error {
+	for _, idx := range indexes {
+		var root int
+		if err := tx.Raw("SELECT rootpage FROM sqlite_master WHERE type = 'table' AND name = ?", idx.TblName).Scan(&root).Error; err != nil {
+			return err
+		}
+		if root == 0 || root == idx.TableRoot || tx.Migrator().HasIndex(idx.TblName, idx.Name) {
+			continue
+		}
+		kept := true
+		for _, col := range idx.Columns {
+			kept = kept && tx.Migrator().HasColumn(idx.TblName, col)
+		}
+		if !kept {
+			continue
+		}
+		if err := tx.Exec(idx.SQL).Error; err != nil {
+			return fmt.Errorf("restore index %s: %w", idx.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,527 @@
+package migrate
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// All is the ordered list of schema migrations. Append new migrations with the
+// next version number; never edit one that has been released.
+//
+// Each migration works on its own snapshot structs rather than the live entities,
+// so later changes to the entities don't change what an old migration does.
+var All = []Migration{
+	{Version: 1, Name: "create_users", Up: createUsersUp, Down: createUsersDown},
+	{Version: 2, Name: "create_products_and_price_observations", Up: createProductsUp, Down: createProductsDown},
+	{Version: 3, Name: "unique_products_platform_link", Up: uniqueProductsUp, Down: uniqueProductsDown},
+	{Version: 4, Name: "add_products_category", Up: addProductsCategoryUp, Down: addProductsCategoryDown},
+	{Version: 5, Name: "fulltext_products_name", Up: fulltextProductsNameUp, Down: fulltextProductsNameDown},
+	{Version: 6, Name: "create_search_log", Up: createSearchLogUp, Down: createSearchLogDown},
+	{Version: 7, Name: "create_refresh_jobs", Up: createRefreshJobsUp, Down: createRefreshJobsDown},
+	{Version: 8, Name: "create_scrape_jobs", Up: createScrapeJobsUp, Down: createScrapeJobsDown},
+	{Version: 9, Name: "add_products_stock_clearance", Up: addProductsStockUp, Down: addProductsStockDown},
+	{Version: 10, Name: "create_evidence_packs", Up: createEvidencePacksUp, Down: createEvidencePacksDown},
+	{Version: 11, Name: "create_purchases", Up: createPurchasesUp, Down: createPurchasesDown},
+	{Version: 12, Name: "create_watches", Up: createWatchesUp, Down: createWatchesDown},
+	{Version: 13, Name: "create_notifications", Up: createNotificationsUp, Down: createNotificationsDown},
+	{Version: 14, Name: "create_search_scrapes", Up: createSearchScrapesUp, Down: createSearchScrapesDown},
+	{Version: 15, Name: "restore_products_indexes", Up: restoreProductsIndexesUp, Down: restoreProductsIndexesDown},
+	{Version: 16, Name: "add_evidence_packs_pdf", Up: addEvidencePacksPDFUp, Down: addEvidencePacksPDFDown},
+}
+
+// --- 1: users ---
+
+type userV1 struct {
+	ID           string `gorm:"type:varchar(36);primaryKey"`
+	Name         string `gorm:"type:varchar(128);not null"`
+	Email        string `gorm:"type:varchar(255);uniqueIndex;not null"`
+	PasswordHash string `gorm:"type:varchar(255);not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (userV1) TableName() string { return "users" }
+
+// createUsersUp uses AutoMigrate so databases created by the old start-up AutoMigrate
+// are adopted as-is instead of failing on an existing table.
+func createUsersUp(tx *gorm.DB) error { return tx.AutoMigrate(&userV1{}) }
+
+func createUsersDown(tx *gorm.DB) error { return tx.Migrator().DropTable(&userV1{}) }
+
+// --- 2: products and price_observations ---
+
+type productV2 struct {
+	ID          uint   `gorm:"primarykey"`
+	Name        string `gorm:"type:varchar(255);not null;index"`
+	Platform    string `gorm:"type:varchar(64);not null"`
+	Price       float64
+	Link        string `gorm:"type:varchar(700);not null"`
+	ImageURL    string `gorm:"type:varchar(1024)"`
+	Sponsored   bool
+	Seller      string `gorm:"type:varchar(255)"`
+	FulfilledBy string `gorm:"type:varchar(255)"`
+	ThirdParty  bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (productV2) TableName() string { return "products" }
+
+type priceObservationV2 struct {
+	ID         uint      `gorm:"primarykey"`
+	ProductID  uint      `gorm:"not null;index:idx_price_observations_product_time,priority:1"`
+	Price      float64   `gorm:"not null"`
+	ObservedAt time.Time `gorm:"not null;index:idx_price_observations_product_time,priority:2"`
+}
+
+func (priceObservationV2) TableName() string { return "price_observations" }
+
+// createProductsUp also adopts a products table that was created by hand, adding
+// the columns it lacks.
+func createProductsUp(tx *gorm.DB) error {
+	return tx.AutoMigrate(&productV2{}, &priceObservationV2{})
+}
+
+func createProductsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&priceObservationV2{}, &productV2{})
+}
+
+// --- 3: unique listing identity ---
+
+type productV3 struct {
+	Platform string `gorm:"uniqueIndex:idx_products_platform_link,priority:1"`
+	Link     string `gorm:"uniqueIndex:idx_products_platform_link,priority:2"`
+}
+
+func (productV3) TableName() string { return "products" }
+
+func uniqueProductsUp(tx *gorm.DB) error {
+	var duplicates int64
+	err := tx.Raw(`SELECT COUNT(*) FROM (
+		SELECT platform, link FROM products GROUP BY platform, link HAVING COUNT(*) > 1
+	) duplicates`).Scan(&duplicates).Error
+	if err != nil {
+		return err
+	}
+	if duplicates > 0 {
+		return fmt.Errorf("%d duplicate platform/link groups in products; run `go run ./cmd/dedupe` first", duplicates)
+	}
+	return tx.Migrator().CreateIndex(&productV3{}, "idx_products_platform_link")
+}
+
+func uniqueProductsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropIndex(&productV3{}, "idx_products_platform_link")
+}
+
+// --- 4: products.category ---
+
+type productV4 struct {
+	// Category is the search category the listing was last found under.
+	Category string `gorm:"type:varchar(64);index:idx_products_category"`
+}
+
+func (productV4) TableName() string { return "products" }
+
+func addProductsCategoryUp(tx *gorm.DB) error {
+	if err := tx.Migrator().AddColumn(&productV4{}, "Category"); err != nil {
+		return err
+	}
+	return tx.Migrator().CreateIndex(&productV4{}, "idx_products_category")
+}
+
+func addProductsCategoryDown(tx *gorm.DB) error {
+	if err := tx.Migrator().DropIndex(&productV4{}, "idx_products_category"); err != nil {
+		return err
+	}
+	return tx.Migrator().DropColumn(&productV4{}, "Category")
+}
+
+// --- 5: FULLTEXT index for product search (MySQL only) ---
+
+// fulltextProductsNameUp adds the index used by the MySQL search engine.
+// Other databases use the in-memory search index, so there is nothing to do.
+func fulltextProductsNameUp(tx *gorm.DB) error {
+	if tx.Dialector.Name() != "mysql" {
+		return nil
+	}
+	return tx.Exec("ALTER TABLE products ADD FULLTEXT INDEX idx_products_name_fulltext (name)").Error
+}
+
+func fulltextProductsNameDown(tx *gorm.DB) error {
+	if tx.Dialector.Name() != "mysql" {
+		return nil
+	}
+	return tx.Exec("ALTER TABLE products DROP INDEX idx_products_name_fulltext").Error
+}
+
+// --- 6: search query log and its daily rollup ---
+
+type searchQueryV6 struct {
+	ID               uint      `gorm:"primarykey"`
+	Term             string    `gorm:"type:varchar(255);not null"`
+	NormalizedTerm   string    `gorm:"type:varchar(255);not null;index"`
+	Category         string    `gorm:"type:varchar(64)"`
+	InferredCategory string    `gorm:"type:varchar(64)"`
+	ResultCount      int       `gorm:"not null"`
+	CacheHit         bool      `gorm:"not null"`
+	LatencyMs        int64     `gorm:"not null"`
+	UserID           *string   `gorm:"type:varchar(36);index"`
+	CreatedAt        time.Time `gorm:"index"`
+}
+
+func (searchQueryV6) TableName() string { return "search_queries" }
+
+type dailySearchStatV6 struct {
+	Day              time.Time `gorm:"primaryKey;type:date"`
+	Term             string    `gorm:"primaryKey;type:varchar(255)"`
+	Category         string    `gorm:"primaryKey;type:varchar(64)"`
+	InferredCategory string    `gorm:"primaryKey;type:varchar(64)"`
+	Searches         int       `gorm:"not null"`
+	ZeroResults      int       `gorm:"not null"`
+	CacheHits        int       `gorm:"not null"`
+	TotalLatencyMs   int64     `gorm:"not null"`
+}
+
+func (dailySearchStatV6) TableName() string { return "daily_search_stats" }
+
+func createSearchLogUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&searchQueryV6{}, &dailySearchStatV6{})
+}
+
+func createSearchLogDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&dailySearchStatV6{}, &searchQueryV6{})
+}
+
+// --- 7: refresh scheduler jobs and daily scrape budgets ---
+
+type refreshJobV7 struct {
+	ID        uint      `gorm:"primarykey"`
+	Kind      string    `gorm:"type:varchar(16);not null;uniqueIndex:idx_refresh_jobs_target,priority:1"`
+	Target    string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_refresh_jobs_target,priority:2"`
+	Category  string    `gorm:"type:varchar(64)"`
+	NextRunAt time.Time `gorm:"not null;index"`
+	LastRunAt *time.Time
+	LastError string `gorm:"type:varchar(1024)"`
+	Runs      int    `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (refreshJobV7) TableName() string { return "refresh_jobs" }
+
+type scrapeBudgetV7 struct {
+	Day      time.Time `gorm:"primaryKey;type:date"`
+	Platform string    `gorm:"primaryKey;type:varchar(64)"`
+	Used     int       `gorm:"not null"`
+}
+
+func (scrapeBudgetV7) TableName() string { return "scrape_budgets" }
+
+func createRefreshJobsUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&refreshJobV7{}, &scrapeBudgetV7{})
+}
+
+func createRefreshJobsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&scrapeBudgetV7{}, &refreshJobV7{})
+}
+
+// --- 8: asynchronous scrape job queue ---
+
+type scrapeJobV8 struct {
+	ID             string     `gorm:"type:varchar(36);primaryKey"`
+	Term           string     `gorm:"type:varchar(255);not null"`
+	Category       string     `gorm:"type:varchar(64);not null"`
+	UserID         *string    `gorm:"type:varchar(36)"`
+	Status         string     `gorm:"type:varchar(16);not null;index:idx_scrape_jobs_status_lease,priority:1"`
+	Attempts       int        `gorm:"not null"`
+	LeaseOwner     string     `gorm:"type:varchar(128)"`
+	LeaseExpiresAt *time.Time `gorm:"index:idx_scrape_jobs_status_lease,priority:2"`
+	Error          string     `gorm:"type:varchar(1024)"`
+	CreatedAt      time.Time  `gorm:"index"`
+	UpdatedAt      time.Time
+	FinishedAt     *time.Time
+}
+
+func (scrapeJobV8) TableName() string { return "scrape_jobs" }
+
+type scrapeJobPlatformV8 struct {
+	JobID       string `gorm:"type:varchar(36);primaryKey"`
+	Platform    string `gorm:"type:varchar(64);primaryKey"`
+	Position    int    `gorm:"not null"`
+	Status      string `gorm:"type:varchar(16);not null"`
+	ResultCount int    `gorm:"not null"`
+	Error       string `gorm:"type:varchar(1024)"`
+	FinishedAt  *time.Time
+}
+
+func (scrapeJobPlatformV8) TableName() string { return "scrape_job_platforms" }
+
+type scrapeJobResultV8 struct {
+	JobID     string `gorm:"type:varchar(36);primaryKey"`
+	ProductID uint   `gorm:"primaryKey"`
+	Platform  string `gorm:"type:varchar(64);not null"`
+	Position  int    `gorm:"not null"`
+}
+
+func (scrapeJobResultV8) TableName() string { return "scrape_job_results" }
+
+func createScrapeJobsUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&scrapeJobV8{}, &scrapeJobPlatformV8{}, &scrapeJobResultV8{})
+}
+
+func createScrapeJobsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&scrapeJobResultV8{}, &scrapeJobPlatformV8{}, &scrapeJobV8{})
+}
+
+// --- 9: products.stock_status and products.clearance ---
+
+type productV9 struct {
+	StockStatus string `gorm:"type:varchar(16);not null;default:''"`
+	Clearance   bool   `gorm:"not null;default:false"`
+}
+
+func (productV9) TableName() string { return "products" }
+
+func addProductsStockUp(tx *gorm.DB) error {
+	if err := tx.Migrator().AddColumn(&productV9{}, "StockStatus"); err != nil {
+		return err
+	}
+	return tx.Migrator().AddColumn(&productV9{}, "Clearance")
+}
+
+func addProductsStockDown(tx *gorm.DB) error {
+	// The SQLite migrator drops a column by rebuilding the table, which loses its
+	// indexes; SQLite 3.35+ can drop it in place.
+	if tx.Dialector.Name() == "sqlite" {
+		if err := tx.Exec("ALTER TABLE products DROP COLUMN clearance").Error; err != nil {
+			return err
+		}
+		return tx.Exec("ALTER TABLE products DROP COLUMN stock_status").Error
+	}
+	if err := tx.Migrator().DropColumn(&productV9{}, "Clearance"); err != nil {
+		return err
+	}
+	return tx.Migrator().DropColumn(&productV9{}, "StockStatus")
+}
+
+// --- 10: evidence packs ---
+
+type evidencePackV10 struct {
+	ID        string  `gorm:"type:varchar(64);primaryKey"`
+	UserID    *string `gorm:"type:varchar(36)"`
+	Document  []byte  `gorm:"not null"`
+	CreatedAt time.Time
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+func (evidencePackV10) TableName() string { return "evidence_packs" }
+
+func createEvidencePacksUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&evidencePackV10{})
+}
+
+func createEvidencePacksDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&evidencePackV10{})
+}
+
+// --- 11: purchases and price-protection alerts ---
+
+type purchaseV11 struct {
+	ID               string  `gorm:"type:varchar(36);primaryKey"`
+	UserID           string  `gorm:"type:varchar(36);not null;index"`
+	ListingID        uint    `gorm:"not null;index"`
+	Retailer         string  `gorm:"type:varchar(64);not null"`
+	ProductName      string  `gorm:"type:varchar(255);not null"`
+	Link             string  `gorm:"type:varchar(700);not null"`
+	PricePaid        float64 `gorm:"not null"`
+	PurchasedAt      time.Time
+	ProtectionEndsAt *time.Time `gorm:"index"`
+	LowestPrice      float64    `gorm:"not null"`
+	LastCheckedAt    *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func (purchaseV11) TableName() string { return "purchases" }
+
+type priceProtectionAlertV11 struct {
+	ID         string    `gorm:"type:varchar(36);primaryKey"`
+	PurchaseID string    `gorm:"type:varchar(36);not null;index"`
+	UserID     string    `gorm:"type:varchar(36);not null;index"`
+	Status     string    `gorm:"type:varchar(16);not null"`
+	PricePaid  float64   `gorm:"not null"`
+	NewPrice   float64   `gorm:"not null"`
+	Refund     float64   `gorm:"not null"`
+	ObservedAt time.Time `gorm:"not null"`
+	ClaimBy    time.Time `gorm:"not null"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (priceProtectionAlertV11) TableName() string { return "price_protection_alerts" }
+
+func createPurchasesUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&purchaseV11{}, &priceProtectionAlertV11{})
+}
+
+func createPurchasesDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&priceProtectionAlertV11{}, &purchaseV11{})
+}
+
+// --- 12: watches and watch alerts ---
+
+type watchV12 struct {
+	ID           string     `gorm:"type:varchar(36);primaryKey"`
+	UserID       string     `gorm:"type:varchar(36);not null;index"`
+	Term         string     `gorm:"type:varchar(255)"`
+	ListingID    *uint      `gorm:"index"`
+	TargetPrice  float64    `gorm:"not null"`
+	Platforms    string     `gorm:"type:varchar(1024)"`
+	PriceMatchAt string     `gorm:"type:varchar(64)"`
+	ExpiresAt    *time.Time `gorm:"index"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (watchV12) TableName() string { return "watches" }
+
+type watchAlertV12 struct {
+	ID          string  `gorm:"type:varchar(36);primaryKey"`
+	WatchID     string  `gorm:"type:varchar(36);not null;uniqueIndex:idx_watch_alerts_watch_listing,priority:1"`
+	UserID      string  `gorm:"type:varchar(36);not null;index:idx_watch_alerts_user_status,priority:1"`
+	ListingID   uint    `gorm:"not null;uniqueIndex:idx_watch_alerts_watch_listing,priority:2"`
+	Platform    string  `gorm:"type:varchar(64);not null"`
+	ProductName string  `gorm:"type:varchar(255);not null"`
+	Link        string  `gorm:"type:varchar(700);not null"`
+	Price       float64 `gorm:"not null"`
+	MatchPrice  *float64
+	TargetPrice float64   `gorm:"not null"`
+	Status      string    `gorm:"type:varchar(16);not null;index:idx_watch_alerts_user_status,priority:2"`
+	RaisedAt    time.Time `gorm:"not null"`
+	ReadAt      *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (watchAlertV12) TableName() string { return "watch_alerts" }
+
+func createWatchesUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&watchV12{}, &watchAlertV12{})
+}
+
+func createWatchesDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&watchAlertV12{}, &watchV12{})
+}
+
+// --- 13: notifications, preferences and the delivery log ---
+
+type notificationV13 struct {
+	ID        string `gorm:"type:varchar(36);primaryKey"`
+	UserID    string `gorm:"type:varchar(36);not null;index:idx_notifications_user_created,priority:1"`
+	Kind      string `gorm:"type:varchar(32);not null"`
+	Title     string `gorm:"type:varchar(255);not null"`
+	Body      string `gorm:"type:text;not null"`
+	Link      string `gorm:"type:varchar(1024)"`
+	InApp     bool   `gorm:"not null"`
+	ReadAt    *time.Time
+	CreatedAt time.Time `gorm:"index:idx_notifications_user_created,priority:2"`
+}
+
+func (notificationV13) TableName() string { return "notifications" }
+
+type notificationPreferencesV13 struct {
+	UserID        string `gorm:"type:varchar(36);primaryKey"`
+	InApp         bool   `gorm:"not null"`
+	Email         bool   `gorm:"not null"`
+	Webhook       bool   `gorm:"not null"`
+	WebhookURL    string `gorm:"type:varchar(1024)"`
+	WebhookSecret string `gorm:"type:varchar(255)"`
+	QuietStart    string `gorm:"type:varchar(5)"`
+	QuietEnd      string `gorm:"type:varchar(5)"`
+	TimeZone      string `gorm:"type:varchar(64);not null"`
+	UpdatedAt     time.Time
+}
+
+func (notificationPreferencesV13) TableName() string { return "notification_preferences" }
+
+type notificationDeliveryV13 struct {
+	ID             string    `gorm:"type:varchar(36);primaryKey"`
+	NotificationID string    `gorm:"type:varchar(36);not null;index"`
+	UserID         string    `gorm:"type:varchar(36);not null;index:idx_notification_deliveries_user_created,priority:1"`
+	Channel        string    `gorm:"type:varchar(16);not null"`
+	Status         string    `gorm:"type:varchar(16);not null;index:idx_notification_deliveries_due,priority:1"`
+	Attempts       int       `gorm:"not null"`
+	NextAttemptAt  time.Time `gorm:"not null;index:idx_notification_deliveries_due,priority:2"`
+	LastError      string    `gorm:"type:varchar(1024)"`
+	SentAt         *time.Time
+	CreatedAt      time.Time `gorm:"index:idx_notification_deliveries_user_created,priority:2"`
+	UpdatedAt      time.Time
+}
+
+func (notificationDeliveryV13) TableName() string { return "notification_deliveries" }
+
+func createNotificationsUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&notificationV13{}, &notificationPreferencesV13{}, &notificationDeliveryV13{})
+}
+
+func createNotificationsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&notificationDeliveryV13{}, &notificationPreferencesV13{}, &notificationV13{})
+}
+
+// --- 14: search_scrapes ---
+
+type searchScrapeV14 struct {
+	Term      string    `gorm:"type:varchar(255);primaryKey"`
+	Category  string    `gorm:"type:varchar(64);primaryKey"`
+	Platform  string    `gorm:"type:varchar(64);primaryKey"`
+	ScrapedAt time.Time `gorm:"not null"`
+	Failed    bool      `gorm:"not null"`
+}
+
+func (searchScrapeV14) TableName() string { return "search_scrapes" }
+
+func createSearchScrapesUp(tx *gorm.DB) error { return tx.Migrator().CreateTable(&searchScrapeV14{}) }
+
+func createSearchScrapesDown(tx *gorm.DB) error { return tx.Migrator().DropTable(&searchScrapeV14{}) }
+
+// --- 15: products indexes lost on SQLite ---
+
+// restoreProductsIndexesUp recreates the products indexes of migrations 2 and 3 where
+// they are missing. On SQLite, rolling back migration 4 rebuilt the products table
+// without them before the migrator learned to keep indexes across a rollback.
+func restoreProductsIndexesUp(tx *gorm.DB) error {
+	if !tx.Migrator().HasIndex(&productV2{}, "idx_products_name") {
+		if err := tx.Migrator().CreateIndex(&productV2{}, "idx_products_name"); err != nil {
+			return err
+		}
+	}
+	if !tx.Migrator().HasIndex(&productV3{}, "idx_products_platform_link") {
+		return uniqueProductsUp(tx)
+	}
+	return nil
+}
+
+// restoreProductsIndexesDown has nothing to undo: the indexes belong to migrations 2 and 3.
+func restoreProductsIndexesDown(*gorm.DB) error { return nil }
+
+// --- 16: evidence_packs.pdf ---
+
+type evidencePackV16 struct {
+	ID  string `gorm:"type:varchar(64);primaryKey"`
+	PDF []byte
+}
+
+func (evidencePackV16) TableName() string { return "evidence_packs" }
+
+func addEvidencePacksPDFUp(tx *gorm.DB) error {
+	return tx.Migrator().AddColumn(&evidencePackV16{}, "PDF")
+}
+
+func addEvidencePacksPDFDown(tx *gorm.DB) error {
+	if tx.Dialector.Name() == "sqlite" {
+		return tx.Exec("ALTER TABLE evidence_packs DROP COLUMN pdf").Error
+	}
+	return tx.Migrator().DropColumn(&evidencePackV16{}, "PDF")
+}
@@ -0,0 +1,38 @@
+// Package netguard keeps outbound requests made on users' behalf, such as webhooks
+// and evidence captures, away from the server's own network.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// ErrNotPublic is returned for a host that is, or resolves to, a loopback, private or
+// link-local address.
+var ErrNotPublic = errors.New("address is not public")
+
+// sharedAddrs is the carrier-grade NAT range, which is private in all but name.
+var sharedAddrs = netip.MustParsePrefix("100.64.0.0/10")
+
+// PublicAddr reports whether addr is a public unicast address.
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddrs.Contains(addr)
+}
+
+// CheckHost fails with ErrNotPublic unless every address host resolves to is public.
+func CheckHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("host %q: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !PublicAddr(addr) {
+			return fmt.Errorf("host %q: %w", host, ErrNotPublic)
+		}
+	}
+	return nil
+}
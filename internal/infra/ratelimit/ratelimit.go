@@ -0,0 +1,84 @@
+// Package ratelimit throttles callers by key, such as a user ID or client IP address.
+package ratelimit
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLimited is returned to callers that are over their limit.
+var ErrLimited = errors.New("too many requests, try again later")
+
+// sweepInterval is how often buckets that have refilled are dropped.
+const sweepInterval = time.Minute
+
+// Limiter is an in-memory token bucket per key: each key may make burst calls at
+// once, and regains one every interval/burst.
+type Limiter struct {
+	burst  float64
+	refill time.Duration // time to regain one call
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// New creates a limiter allowing each key burst calls per interval.
+func New(burst int, interval time.Duration) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		burst:   float64(burst),
+		refill:  interval / time.Duration(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a call from key's allowance, reporting false if none is left.
+func (l *Limiter) Allow(key string) bool {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.tokensAt(b, now)
+	b.at = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// tokensAt is how many calls b allows at now.
+func (l *Limiter) tokensAt(b *bucket, now time.Time) float64 {
+	if l.refill <= 0 {
+		return l.burst
+	}
+	return min(l.burst, b.tokens+float64(now.Sub(b.at))/float64(l.refill))
+}
+
+// sweep drops the buckets that have refilled, which are the same as no bucket.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if l.tokensAt(b, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
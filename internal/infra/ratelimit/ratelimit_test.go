@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := New(2, time.Hour)
+	if !l.Allow("a") || !l.Allow("a") {
+		t.Fatal("burst not allowed")
+	}
+	if l.Allow("a") {
+		t.Error("allowed past the burst")
+	}
+	if !l.Allow("b") {
+		t.Error("another key was limited")
+	}
+
+	// Half the interval regains one call of the two.
+	l.buckets["a"].at = l.buckets["a"].at.Add(-31 * time.Minute)
+	if !l.Allow("a") {
+		t.Error("call not regained")
+	}
+	if l.Allow("a") {
+		t.Error("regained more than one call")
+	}
+}
+
+func TestLimiterSweepsRefilledBuckets(t *testing.T) {
+	l := New(1, time.Minute)
+	l.Allow("a")
+	l.Allow("b")
+	l.buckets["a"].at = l.buckets["a"].at.Add(-2 * time.Minute)
+	l.lastSweep = time.Time{}
+
+	l.Allow("c")
+	if _, ok := l.buckets["a"]; ok {
+		t.Error("refilled bucket kept")
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Error("bucket still limiting dropped")
+	}
+}
@@ -0,0 +1,44 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"never-price-match-server/internal/evidence"
+
+	"gorm.io/gorm"
+)
+
+type evidenceGormRepo struct {
+	db *gorm.DB
+}
+
+// NewEvidenceGormRepo creates a new GORM evidence pack repository instance
+func NewEvidenceGormRepo(db *gorm.DB) evidence.Repo {
+	return &evidenceGormRepo{db: db}
+}
+
+func (r *evidenceGormRepo) Create(ctx context.Context, pack *evidence.Pack) error {
+	return conn(ctx, r.db).Create(pack).Error
+}
+
+func (r *evidenceGormRepo) Get(ctx context.Context, id string) (*evidence.Pack, error) {
+	var pack evidence.Pack
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&pack).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, evidence.ErrPackNotFound
+		}
+		return nil, err
+	}
+	return &pack, nil
+}
+
+func (r *evidenceGormRepo) SetPDF(ctx context.Context, id string, pdf []byte) error {
+	return conn(ctx, r.db).Model(&evidence.Pack{}).Where("id = ?", id).UpdateColumn("pdf", pdf).Error
+}
+
+func (r *evidenceGormRepo) DeleteExpired(ctx context.Context, t time.Time) (int64, error) {
+	res := conn(ctx, r.db).Where("expires_at < ?", t).Delete(&evidence.Pack{})
+	return res.RowsAffected, res.Error
+}
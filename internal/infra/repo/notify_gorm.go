@@ -0,0 +1,138 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"never-price-match-server/internal/notify"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type notifyGormRepo struct {
+	db *gorm.DB
+}
+
+// NewNotifyGormRepo creates a new GORM notification repository instance
+func NewNotifyGormRepo(db *gorm.DB) notify.Repo {
+	return &notifyGormRepo{db: db}
+}
+
+func (r *notifyGormRepo) Create(ctx context.Context, n *notify.Notification, deliveries []notify.Delivery) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(n).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		for i := range deliveries {
+			deliveries[i].NotificationID = n.ID
+		}
+		return tx.Create(&deliveries).Error
+	})
+}
+
+func (r *notifyGormRepo) Inbox(ctx context.Context, userID string, unreadOnly bool, limit int) ([]notify.Notification, error) {
+	q := conn(ctx, r.db).Where("user_id = ? AND in_app = ?", userID, true)
+	if unreadOnly {
+		q = q.Where("read_at IS NULL")
+	}
+	var ns []notify.Notification
+	err := q.Order("created_at DESC").Limit(limit).Find(&ns).Error
+	return ns, err
+}
+
+func (r *notifyGormRepo) UnreadCount(ctx context.Context, userID string) (int64, error) {
+	var n int64
+	err := conn(ctx, r.db).Model(&notify.Notification{}).
+		Where("user_id = ? AND in_app = ? AND read_at IS NULL", userID, true).
+		Count(&n).Error
+	return n, err
+}
+
+func (r *notifyGormRepo) MarkRead(ctx context.Context, userID string, ids []string, t time.Time) (int64, error) {
+	q := conn(ctx, r.db).Model(&notify.Notification{}).
+		Where("user_id = ? AND in_app = ? AND read_at IS NULL", userID, true)
+	if ids != nil {
+		q = q.Where("id IN ?", ids)
+	}
+	res := q.Update("read_at", t)
+	return res.RowsAffected, res.Error
+}
+
+func (r *notifyGormRepo) GetNotifications(ctx context.Context, ids []string) ([]notify.Notification, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var ns []notify.Notification
+	err := conn(ctx, r.db).Where("id IN ?", ids).Find(&ns).Error
+	return ns, err
+}
+
+func (r *notifyGormRepo) GetPreferences(ctx context.Context, userID string) (*notify.Preferences, error) {
+	var p notify.Preferences
+	err := conn(ctx, r.db).Where("user_id = ?", userID).First(&p).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *notifyGormRepo) SavePreferences(ctx context.Context, p *notify.Preferences) error {
+	return conn(ctx, r.db).Clauses(clause.OnConflict{UpdateAll: true}).Create(p).Error
+}
+
+// ClaimDue takes each candidate with a conditional update that pushes its next attempt
+// out to the lease, so competing senders never both win one.
+func (r *notifyGormRepo) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]notify.Delivery, error) {
+	db := conn(ctx, r.db)
+	due := func(q *gorm.DB) *gorm.DB {
+		return q.Where("status = ? AND next_attempt_at <= ?", notify.DeliveryPending, now)
+	}
+
+	var candidates []notify.Delivery
+	err := due(db.Model(&notify.Delivery{}).Select("id")).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var claimed []string
+	for _, c := range candidates {
+		res := due(db.Model(&notify.Delivery{}).Where("id = ?", c.ID)).
+			Updates(map[string]any{
+				"next_attempt_at": leaseUntil,
+				"attempts":        gorm.Expr("attempts + 1"),
+			})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			claimed = append(claimed, c.ID)
+		}
+	}
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+	var deliveries []notify.Delivery
+	err = db.Where("id IN ?", claimed).Order("next_attempt_at").Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *notifyGormRepo) SaveDelivery(ctx context.Context, d *notify.Delivery) error {
+	return conn(ctx, r.db).Save(d).Error
+}
+
+func (r *notifyGormRepo) Deliveries(ctx context.Context, userID string, limit int) ([]notify.Delivery, error) {
+	var deliveries []notify.Delivery
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
@@ -0,0 +1,150 @@
+package repo
+
+import (
+	"never-price-match-server/internal/product"
+
+	"gorm.io/gorm"
+)
+
+// DedupeReport summarises what DedupeProducts changed (or would change in a dry run).
+type DedupeReport struct {
+	RowsScanned            int
+	DuplicateGroups        int
+	RowsDeleted            int
+	LinksRewritten         int
+	ObservationsBackfilled int
+}
+
+// dedupeBatchSize is how many product rows are read per query.
+const dedupeBatchSize = 1000
+
+// DedupeProducts collapses rows of the products table that refer to the same listing,
+// i.e. the same platform and canonical link. It is a one-off tool for data saved before
+// listings were upserted.
+//
+// In each group the most recently updated row is kept and its link canonicalised. The
+// price of every row without a recorded observation is backfilled into price_observations
+// so no history is lost, and the duplicates' observations are moved to the kept row.
+// Afterwards `migrate up` can add the unique key on platform + link.
+//
+// With dryRun set nothing is written and the report says what would have happened.
+func DedupeProducts(db *gorm.DB, dryRun bool) (*DedupeReport, error) {
+	report := &DedupeReport{}
+	groups := make(map[[2]string][]product.Product)
+	var order [][2]string
+
+	var batch []product.Product
+	err := db.Order("id").FindInBatches(&batch, dedupeBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, p := range batch {
+			key := [2]string{p.Platform, product.CanonicalLink(p.Platform, p.Link)}
+			if _, ok := groups[key]; !ok {
+				order = append(order, key)
+			}
+			groups[key] = append(groups[key], p)
+		}
+		report.RowsScanned += len(batch)
+		return nil
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range order {
+		rows := groups[key]
+		keeper := newestRow(rows)
+		if len(rows) > 1 {
+			report.DuplicateGroups++
+			report.RowsDeleted += len(rows) - 1
+		}
+		if keeper.Link != key[1] {
+			report.LinksRewritten++
+		}
+		if len(rows) == 1 && keeper.Link == key[1] {
+			continue
+		}
+
+		backfilled, err := collapseGroup(db, rows, keeper, key[1], dryRun)
+		if err != nil {
+			return nil, err
+		}
+		report.ObservationsBackfilled += backfilled
+	}
+
+	return report, nil
+}
+
+// newestRow returns the most recently updated row, preferring the higher ID on ties.
+func newestRow(rows []product.Product) product.Product {
+	keeper := rows[0]
+	for _, r := range rows[1:] {
+		if r.UpdatedAt.After(keeper.UpdatedAt) || (r.UpdatedAt.Equal(keeper.UpdatedAt) && r.ID > keeper.ID) {
+			keeper = r
+		}
+	}
+	return keeper
+}
+
+// collapseGroup merges one group of duplicate rows into keeper inside a transaction.
+// It returns the number of observations backfilled from the rows' own prices.
+func collapseGroup(db *gorm.DB, rows []product.Product, keeper product.Product, canonical string, dryRun bool) (int, error) {
+	ids := make([]uint, 0, len(rows))
+	var duplicateIDs []uint
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+		if r.ID != keeper.ID {
+			duplicateIDs = append(duplicateIDs, r.ID)
+		}
+	}
+
+	backfilled := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Rows saved before price observations existed carry their only price in the row itself.
+		var observed []uint
+		if err := tx.Model(&product.PriceObservation{}).
+			Where("product_id IN ?", ids).
+			Distinct().
+			Pluck("product_id", &observed).Error; err != nil {
+			return err
+		}
+		hasObservations := make(map[uint]bool, len(observed))
+		for _, id := range observed {
+			hasObservations[id] = true
+		}
+
+		var backfill []product.PriceObservation
+		for _, r := range rows {
+			if !hasObservations[r.ID] {
+				backfill = append(backfill, product.PriceObservation{ProductID: keeper.ID, Price: r.Price, ObservedAt: r.UpdatedAt})
+			}
+		}
+		backfilled = len(backfill)
+		if dryRun {
+			return nil
+		}
+
+		if len(backfill) > 0 {
+			if err := tx.Create(&backfill).Error; err != nil {
+				return err
+			}
+		}
+		if len(duplicateIDs) > 0 {
+			if err := tx.Model(&product.PriceObservation{}).
+				Where("product_id IN ?", duplicateIDs).
+				Update("product_id", keeper.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&product.Product{}, duplicateIDs).Error; err != nil {
+				return err
+			}
+		}
+		if keeper.Link != canonical {
+			if err := tx.Model(&product.Product{}).
+				Where("id = ?", keeper.ID).
+				UpdateColumn("link", canonical).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return backfilled, err
+}
@@ -1,9 +1,15 @@
 package repo
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"never-price-match-server/internal/product"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type productGormRepo struct {
@@ -15,48 +21,132 @@ func NewProductGormRepo(db *gorm.DB) product.Repo {
 	return &productGormRepo{db: db}
 }
 
-// SearchProductsByName performs a case-insensitive search for products by name.
-func (r *productGormRepo) SearchProductsByName(name string) ([]product.Product, error) {
+// ListProducts returns up to limit listings with an ID greater than afterID, in ID order.
+func (r *productGormRepo) ListProducts(ctx context.Context, afterID uint, limit int) ([]product.Product, error) {
 	var products []product.Product
-	err := r.db.Where("LOWER(name) LIKE LOWER(?)", "%"+name+"%").Find(&products).Error
+	err := conn(ctx, r.db).Where("id > ?", afterID).Order("id").Limit(limit).Find(&products).Error
 	if err != nil {
 		return nil, err
 	}
 	return products, nil
 }
 
-// SaveProducts saves a slice of new Product entities to the database.
-func (r *productGormRepo) SaveProducts(products []product.Product) error {
+// UpsertListings upserts a slice of Product entities.
+// Platform + canonical link is the identity of a listing (unique key idx_products_platform_link):
+// an existing listing gets its name, price, image, seller details and UpdatedAt refreshed instead
+// of a new row.
+func (r *productGormRepo) UpsertListings(ctx context.Context, products []product.Product) error {
 	if len(products) == 0 {
 		return nil
 	}
-	// This is now a simple batch-create operation.
-	return r.db.Create(&products).Error
+	db := conn(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "platform"}, {Name: "link"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"name", "price", "image_url", "category", "sponsored", "seller", "fulfilled_by", "third_party",
+			"stock_status", "clearance", "updated_at",
+		}),
+	}).Create(&products).Error
+	if err != nil {
+		return err
+	}
+
+	// IDs reported back for rows that hit the conflict branch aren't reliable on every
+	// driver, so read the listing IDs back by their identity.
+	return fillListingIDs(db, products)
 }
 
-// GetProductsByCategory retrieves products from the database by category
-func (r *productGormRepo) GetProductsByCategory(category string) ([]product.Product, error) {
-	var products []product.Product
-	// Use Preload("Prices") to load associated price information simultaneously
-	err := r.db.Where("category = ?", category).Preload("Prices").Find(&products).Error
+// AddPriceObservations appends price observations in one batch insert.
+func (r *productGormRepo) AddPriceObservations(ctx context.Context, observations []product.PriceObservation) error {
+	if len(observations) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Create(&observations).Error
+}
+
+// fillListingIDs sets each product's ID from the stored listing with the same platform and link.
+func fillListingIDs(tx *gorm.DB, products []product.Product) error {
+	platforms := make([]string, 0, len(products))
+	links := make([]string, 0, len(products))
+	for _, p := range products {
+		platforms = append(platforms, p.Platform)
+		links = append(links, p.Link)
+	}
+
+	var stored []product.Product
+	err := tx.Select("id", "platform", "link").
+		Where("platform IN ? AND link IN ?", platforms, links).
+		Find(&stored).Error
 	if err != nil {
+		return err
+	}
+
+	ids := make(map[[2]string]uint, len(stored))
+	for _, s := range stored {
+		ids[[2]string{s.Platform, s.Link}] = s.ID
+	}
+	for i := range products {
+		id, ok := ids[[2]string{products[i].Platform, products[i].Link}]
+		if !ok {
+			return fmt.Errorf("saved listing not found: %s %s", products[i].Platform, products[i].Link)
+		}
+		products[i].ID = id
+	}
+	return nil
+}
+
+// GetProductByID retrieves a single listing by its primary key.
+func (r *productGormRepo) GetProductByID(ctx context.Context, id uint) (*product.Product, error) {
+	var p product.Product
+	if err := conn(ctx, r.db).First(&p, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, product.ErrListingNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetProductsByIDs retrieves the listings with the given primary keys.
+func (r *productGormRepo) GetProductsByIDs(ctx context.Context, ids []uint) ([]product.Product, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var products []product.Product
+	if err := conn(ctx, r.db).Where("id IN ?", ids).Find(&products).Error; err != nil {
 		return nil, err
 	}
 	return products, nil
 }
 
-// GetProductNamesByName performs a distinct, case-insensitive search for product names.
-// It's optimized for search suggestions, limiting the result to 10 records.
-func (r *productGormRepo) GetProductNamesByName(name string) ([]string, error) {
-	var names []string
-	err := r.db.Model(&product.Product{}).
-		Distinct("name").
-		Where("LOWER(name) LIKE LOWER(?)", "%"+name+"%").
-		Limit(10).
-		Pluck("name", &names).Error
+// GetPriceObservations retrieves a listing's price observations within [from, to], oldest first.
+func (r *productGormRepo) GetPriceObservations(ctx context.Context, productID uint, from, to time.Time) ([]product.PriceObservation, error) {
+	var observations []product.PriceObservation
+	err := conn(ctx, r.db).Where("product_id = ? AND observed_at BETWEEN ? AND ?", productID, from, to).
+		Order("observed_at").
+		Find(&observations).Error
+	if err != nil {
+		return nil, err
+	}
+	return observations, nil
+}
 
+// GetProductsByCategory retrieves the listings last found under the given search category.
+func (r *productGormRepo) GetProductsByCategory(ctx context.Context, category string) ([]product.Product, error) {
+	var products []product.Product
+	err := conn(ctx, r.db).Where("category = ?", category).Find(&products).Error
 	if err != nil {
 		return nil, err
 	}
-	return names, nil
+	return products, nil
+}
+
+func (r *productGormRepo) SearchScrapes(ctx context.Context, term, category string) ([]product.SearchScrape, error) {
+	var scrapes []product.SearchScrape
+	err := conn(ctx, r.db).Where("term = ? AND category = ?", term, category).Find(&scrapes).Error
+	return scrapes, err
+}
+
+func (r *productGormRepo) RecordSearchScrape(ctx context.Context, s *product.SearchScrape) error {
+	return conn(ctx, r.db).Clauses(clause.OnConflict{UpdateAll: true}).Create(s).Error
 }
@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"never-price-match-server/internal/purchase"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type purchaseGormRepo struct {
+	db *gorm.DB
+}
+
+// NewPurchaseGormRepo creates a new GORM purchase repository instance
+func NewPurchaseGormRepo(db *gorm.DB) purchase.Repo {
+	return &purchaseGormRepo{db: db}
+}
+
+func (r *purchaseGormRepo) Create(ctx context.Context, p *purchase.Purchase) error {
+	return conn(ctx, r.db).Create(p).Error
+}
+
+func (r *purchaseGormRepo) ListByUser(ctx context.Context, userID string) ([]purchase.Purchase, error) {
+	var purchases []purchase.Purchase
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("purchased_at DESC").Find(&purchases).Error
+	return purchases, err
+}
+
+func (r *purchaseGormRepo) Delete(ctx context.Context, userID, id string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&purchase.Purchase{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return purchase.ErrPurchaseNotFound
+		}
+		return tx.Where("purchase_id = ?", id).Delete(&purchase.Alert{}).Error
+	})
+}
+
+func (r *purchaseGormRepo) Protected(ctx context.Context, now time.Time) ([]purchase.Purchase, error) {
+	var purchases []purchase.Purchase
+	err := conn(ctx, r.db).Where("protection_ends_at > ?", now).Order("listing_id").Find(&purchases).Error
+	return purchases, err
+}
+
+func (r *purchaseGormRepo) Save(ctx context.Context, p *purchase.Purchase) error {
+	return conn(ctx, r.db).Save(p).Error
+}
+
+func (r *purchaseGormRepo) Alerts(ctx context.Context, userID string, status purchase.AlertStatus) ([]purchase.Alert, error) {
+	q := conn(ctx, r.db).Where("user_id = ?", userID)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var alerts []purchase.Alert
+	err := q.Preload("Purchase").Order("created_at DESC").Find(&alerts).Error
+	return alerts, err
+}
+
+func (r *purchaseGormRepo) PurchaseAlerts(ctx context.Context, purchaseIDs []string) ([]purchase.Alert, error) {
+	if len(purchaseIDs) == 0 {
+		return nil, nil
+	}
+	var alerts []purchase.Alert
+	err := conn(ctx, r.db).Where("purchase_id IN ?", purchaseIDs).Order("created_at DESC").Find(&alerts).Error
+	return alerts, err
+}
+
+func (r *purchaseGormRepo) GetAlert(ctx context.Context, userID, id string) (*purchase.Alert, error) {
+	var a purchase.Alert
+	if err := conn(ctx, r.db).Preload("Purchase").Where("id = ? AND user_id = ?", id, userID).First(&a).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, purchase.ErrAlertNotFound
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *purchaseGormRepo) SaveAlert(ctx context.Context, a *purchase.Alert) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Save(a).Error
+}
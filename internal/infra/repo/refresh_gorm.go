@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"never-price-match-server/internal/infra/date"
+	"never-price-match-server/internal/refresh"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type refreshGormRepo struct {
+	db *gorm.DB
+}
+
+// NewRefreshGormRepo creates a new GORM refresh job repository instance
+func NewRefreshGormRepo(db *gorm.DB) refresh.Repo {
+	return &refreshGormRepo{db: db}
+}
+
+func (r *refreshGormRepo) EnsureJobs(ctx context.Context, jobs []refresh.Job) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kind"}, {Name: "target"}},
+		DoUpdates: clause.AssignmentColumns([]string{"category", "updated_at"}),
+	}).Create(&jobs).Error
+}
+
+func (r *refreshGormRepo) DeleteJobsExcept(ctx context.Context, kind string, targets []string) (int64, error) {
+	q := conn(ctx, r.db).Where("kind = ?", kind)
+	if len(targets) > 0 {
+		q = q.Where("target NOT IN ?", targets)
+	}
+	res := q.Delete(&refresh.Job{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *refreshGormRepo) DueJobs(ctx context.Context, now time.Time, limit int) ([]refresh.Job, error) {
+	var jobs []refresh.Job
+	err := conn(ctx, r.db).Where("next_run_at <= ?", now).Order("next_run_at").Limit(limit).Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ClaimJob is a conditional update, like ConsumeBudget: of several instances that
+// found the job due, only the first still sees it due and moves it.
+func (r *refreshGormRepo) ClaimJob(ctx context.Context, id uint, now, leaseUntil time.Time) (bool, error) {
+	res := conn(ctx, r.db).Model(&refresh.Job{}).
+		Where("id = ? AND next_run_at <= ?", id, now).
+		UpdateColumn("next_run_at", leaseUntil)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *refreshGormRepo) SaveJob(ctx context.Context, job *refresh.Job) error {
+	return conn(ctx, r.db).Save(job).Error
+}
+
+// ConsumeBudget makes sure the day's row exists and then increments it only while it
+// is under the limit, so concurrent callers can't overspend.
+func (r *refreshGormRepo) ConsumeBudget(ctx context.Context, day date.Date, platform string, limit int) (bool, error) {
+	db := conn(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&refresh.ScrapeBudget{Day: day, Platform: platform}).Error
+	if err != nil {
+		return false, err
+	}
+	res := db.Model(&refresh.ScrapeBudget{}).
+		Where("day = ? AND platform = ? AND used < ?", day, platform, limit).
+		UpdateColumn("used", gorm.Expr("used + 1"))
+	return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,558 @@
+package repo_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"never-price-match-server/internal/infra/db"
+	"never-price-match-server/internal/infra/migrate"
+	"never-price-match-server/internal/infra/repo"
+	"never-price-match-server/internal/infra/search"
+	"never-price-match-server/internal/product"
+	"never-price-match-server/internal/purchase"
+	"never-price-match-server/internal/refresh"
+	"never-price-match-server/internal/scrapejob"
+	"never-price-match-server/internal/searchlog"
+	"never-price-match-server/internal/watchlist"
+
+	"gorm.io/gorm"
+)
+
+// The suite runs once per database type in -db, which defaults to DATABASE_TYPE and
+// then to sqlite. MySQL and Postgres need a DSN; point it at a throwaway database,
+// since every test drops all tables. For example:
+//
+//	go test ./internal/infra/repo -db=sqlite,mysql,postgres \
+//		-mysql-dsn='root:123456@tcp(127.0.0.1:3306)/price_match_test?parseTime=True' \
+//		-postgres-dsn='host=127.0.0.1 user=postgres password=123456 dbname=price_match_test sslmode=disable'
+var (
+	dbTypes     = flag.String("db", envOr("DATABASE_TYPE", db.SQLite), "comma-separated database types to test: sqlite, mysql, postgres")
+	mysqlDSN    = flag.String("mysql-dsn", os.Getenv("TEST_MYSQL_DSN"), "DSN of a throwaway MySQL database")
+	postgresDSN = flag.String("postgres-dsn", os.Getenv("TEST_POSTGRES_DSN"), "DSN of a throwaway Postgres database")
+)
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// forEachDB runs fn as a subtest against a freshly migrated database of each selected type.
+func forEachDB(t *testing.T, fn func(t *testing.T, gdb *gorm.DB)) {
+	for _, driver := range strings.Split(*dbTypes, ",") {
+		driver = strings.TrimSpace(driver)
+		t.Run(driver, func(t *testing.T) {
+			fn(t, openDB(t, driver))
+		})
+	}
+}
+
+func openDB(t *testing.T, driver string) *gorm.DB {
+	t.Helper()
+	var dsn string
+	switch driver {
+	case db.SQLite:
+		dsn = "file:" + filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)"
+	case db.MySQL:
+		dsn = *mysqlDSN
+	case db.Postgres:
+		dsn = *postgresDSN
+	}
+	if dsn == "" {
+		t.Skipf("no DSN for %s; set -%s-dsn", driver, driver)
+	}
+
+	gdb, err := db.Open(driver, dsn)
+	if err != nil {
+		t.Fatalf("open %s: %v", driver, err)
+	}
+	m := migrate.New(gdb, migrate.All)
+	migrateDownAll(t, m)
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	t.Cleanup(func() {
+		migrateDownAll(t, m)
+		if sqlDB, err := gdb.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return gdb
+}
+
+func migrateDownAll(t *testing.T, m *migrate.Migrator) {
+	t.Helper()
+	if _, err := m.Down(len(migrate.All)); err != nil && !errors.Is(err, migrate.ErrNothingToRollBack) {
+		t.Fatalf("migrate down: %v", err)
+	}
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	forEachDB(t, func(t *testing.T, gdb *gorm.DB) {
+		m := migrate.New(gdb, migrate.All)
+		statuses, err := m.Status()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range statuses {
+			if !s.Applied {
+				t.Errorf("migration %d_%s not applied", s.Version, s.Name)
+			}
+		}
+
+		// Roll back to migration 3, whose unique index must survive the later rollbacks.
+		if _, err := m.Down(len(migrate.All) - 3); err != nil {
+			t.Fatalf("down to 3: %v", err)
+		}
+		if gdb.Migrator().HasColumn(&product.Product{}, "category") {
+			t.Error("products.category still exists at version 3")
+		}
+		if !gdb.Migrator().HasIndex(&product.Product{}, "idx_products_platform_link") {
+			t.Error("idx_products_platform_link lost rolling back to version 3")
+		}
+		if !gdb.Migrator().HasIndex(&product.Product{}, "idx_products_name") {
+			t.Error("idx_products_name lost rolling back to version 3")
+		}
+
+		migrateDownAll(t, m)
+		if gdb.Migrator().HasTable(&product.Product{}) {
+			t.Error("products still exists after rolling everything back")
+		}
+		if _, err := m.Up(); err != nil {
+			t.Fatalf("migrate up again: %v", err)
+		}
+		if !gdb.Migrator().HasTable(&product.SearchScrape{}) {
+			t.Error("search_scrapes missing after migrating up again")
+		}
+	})
+}
+
+func TestUpsertListings(t *testing.T) {
+	forEachDB(t, func(t *testing.T, gdb *gorm.DB) {
+		ctx := context.Background()
+		products := repo.NewProductGormRepo(gdb)
+
+		first := []product.Product{
+			{Name: "Apple AirPods Pro", Platform: "amazon", Link: "https://amazon.example/airpods-pro", Price: 399},
+			{Name: "Sony WH-1000XM5", Platform: "amazon", Link: "https://amazon.example/xm5", Price: 549},
+		}
+		if err := products.UpsertListings(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range first {
+			if p.ID == 0 {
+				t.Fatalf("%s: ID not filled in", p.Name)
+			}
+		}
+
+		second := []product.Product{
+			{Name: "Apple AirPods Pro (2nd gen)", Platform: "amazon", Link: "https://amazon.example/airpods-pro", Price: 349, Category: "electronics"},
+			{Name: "Apple AirPods Pro", Platform: "jbhifi", Link: "https://jbhifi.example/airpods-pro", Price: 379},
+		}
+		if err := products.UpsertListings(ctx, second); err != nil {
+			t.Fatal(err)
+		}
+		if second[0].ID != first[0].ID {
+			t.Errorf("same platform and link got ID %d, want %d", second[0].ID, first[0].ID)
+		}
+		if second[1].ID == first[0].ID || second[1].ID == first[1].ID {
+			t.Errorf("new listing reused ID %d", second[1].ID)
+		}
+
+		got, err := products.GetProductByID(ctx, first[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Price != 349 || got.Name != "Apple AirPods Pro (2nd gen)" || got.Category != "electronics" {
+			t.Errorf("listing not refreshed: %+v", got)
+		}
+
+		all, err := products.ListProducts(ctx, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != 3 {
+			t.Errorf("got %d listings, want 3", len(all))
+		}
+	})
+}
+
+func TestSearch(t *testing.T) {
+	forEachDB(t, func(t *testing.T, gdb *gorm.DB) {
+		ctx := context.Background()
+		products := repo.NewProductGormRepo(gdb)
+		listings := []product.Product{
+			{Name: "Apple AirPods Pro", Platform: "amazon", Link: "https://amazon.example/airpods-pro"},
+			{Name: "Apple AirPods Max", Platform: "amazon", Link: "https://amazon.example/airpods-max"},
+			{Name: "LG OLED TV 55 inch", Platform: "amazon", Link: "https://amazon.example/lg-oled"},
+		}
+		if err := products.UpsertListings(ctx, listings); err != nil {
+			t.Fatal(err)
+		}
+
+		// The engine the app picks: FULLTEXT on MySQL, the in-memory index elsewhere.
+		var index product.SearchIndex
+		if gdb.Dialector.Name() == db.MySQL {
+			index = search.NewMySQLFulltext(gdb)
+		} else {
+			idx := search.NewInvertedIndex(products)
+			if _, err := product.BuildIndex(ctx, products, idx); err != nil {
+				t.Fatal(err)
+			}
+			index = idx
+		}
+
+		cases := []struct {
+			query string
+			want  []string
+		}{
+			{"airpods pro", []string{"Apple AirPods Pro"}},
+			{"apple airp", []string{"Apple AirPods Pro", "Apple AirPods Max"}}, // last word as a prefix
+			{"lg tv", []string{"LG OLED TV 55 inch"}},                          // words too short for FULLTEXT
+			{"walkman", nil},
+		}
+		for _, c := range cases {
+			hits, err := index.Search(ctx, c.query, 10)
+			if err != nil {
+				t.Fatalf("%q: %v", c.query, err)
+			}
+			var names []string
+			for _, h := range hits {
+				names = append(names, h.Product.Name)
+			}
+			if !sameSet(names, c.want) {
+				t.Errorf("%q found %v, want %v", c.query, names, c.want)
+			}
+		}
+	})
+}
+
+func TestSearchLogRollups(t *testing.T) {
+	forEachDB(t, func(t *testing.T, gdb *gorm.DB) {
+		ctx := context.Background()
+		logs := repo.NewSearchLogGormRepo(gdb)
+
+		now := time.Now().UTC()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		queries := []searchlog.SearchQuery{
+			{Term: "AirPods", NormalizedTerm: "airpods", ResultCount: 3, CacheHit: true, LatencyMs: 10, CreatedAt: now},
+			{Term: "airpods", NormalizedTerm: "airpods", ResultCount: 0, LatencyMs: 30, CreatedAt: now},
+			{Term: "walkman", NormalizedTerm: "walkman", ResultCount: 0, LatencyMs: 20, CreatedAt: today.Add(-time.Hour)},
+		}
+		for i := range queries {
+			if err := logs.Create(ctx, &queries[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Rebuilding a day twice must not double count it.
+		for range 2 {
+			if err := logs.ReplaceDailyStats(ctx, today); err != nil {
+				t.Fatal(err)
+			}
+		}
+		stats, err := logs.TermStatsSince(ctx, today)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats) != 1 {
+			t.Fatalf("got %d terms today, want 1: %+v", len(stats), stats)
+		}
+		s := stats[0]
+		if s.Term != "airpods" || s.Searches != 2 || s.ZeroResults != 1 || s.CacheHits != 1 || s.TotalLatencyMs != 40 {
+			t.Errorf("unexpected rollup %+v", s)
+		}
+
+		if err := logs.ReplaceDailyStats(ctx, today.AddDate(0, 0, -1)); err != nil {
+			t.Fatal(err)
+		}
+		stats, err = logs.TermStatsSince(ctx, today.AddDate(0, 0, -1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats) != 2 {
+			t.Errorf("got %d terms since yesterday, want 2: %+v", len(stats), stats)
+		}
+
+		n, err := logs.DeleteDailyStatsBefore(ctx, today)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("deleted %d rollups before today, want 1", n)
+		}
+		n, err = logs.DeleteBefore(ctx, today)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("deleted %d searches before today, want 1", n)
+		}
+	})
+}
+
+func TestScrapeJobClaimAndLease(t *testing.T) {
+	forEachDB(t, func(t *testing.T, gdb *gorm.DB) {
+		ctx := context.Background()
+		jobs := repo.NewScrapeJobGormRepo(gdb)
+
+		job := &scrapejob.Job{
+			Term:      "airpods",
+			Category:  "electronics",
+			Status:    scrapejob.StatusQueued,
+			Platforms: []scrapejob.JobPlatform{{Platform: "amazon", Status: scrapejob.PlatformPending}},
+		}
+		if err := jobs.Create(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+
+		now := time.Now()
+		claimed, err := jobs.Claim(ctx, "worker-a", now, now.Add(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claimed == nil || claimed.ID != job.ID || claimed.LeaseOwner != "worker-a" || claimed.Attempts != 1 {
+			t.Fatalf("worker-a claimed %+v", claimed)
+		}
+		if len(claimed.Platforms) != 1 {
+			t.Errorf("claimed job has %d platforms, want 1", len(claimed.Platforms))
+		}
+
+		// A held lease can't be claimed or renewed by anyone else.
+		if other, err := jobs.Claim(ctx, "worker-b", now, now.Add(time.Minute)); err != nil || other != nil {
+			t.Fatalf("worker-b claimed %+v, %v while the lease was held", other, err)
+		}
+		if ok, err := jobs.RenewLease(ctx, job.ID, "worker-b", now.Add(2*time.Minute)); err != nil || ok {
+			t.Errorf("worker-b renewed a lease it doesn't hold: %v, %v", ok, err)
+		}
+		if ok, err := jobs.RenewLease(ctx, job.ID, "worker-a", now.Add(2*time.Minute)); err != nil || !ok {
+			t.Errorf("worker-a failed to renew its lease: %v, %v", ok, err)
+		}
+
+		// Once the lease runs out another worker takes the job over.
+		later := now.Add(3 * time.Minute)
+		taken, err := jobs.Claim(ctx, "worker-b", later, later.Add(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if taken == nil || taken.LeaseOwner != "worker-b" || taken.Attempts != 2 {
+			t.Fatalf("worker-b took over %+v", taken)
+		}
+
+		finished := time.Now()
+		job.Status = scrapejob.StatusSucceeded
+		job.FinishedAt = &finished
+		if ok, err := jobs.Finish(ctx, job, "worker-a"); err != nil || ok {
+			t.Errorf("worker-a finished a job it lost: %v, %v", ok, err)
+		}
+		if ok, err := jobs.Finish(ctx, job, "worker-b"); err != nil || !ok {
+			t.Errorf("worker-b failed to finish its job: %v, %v", ok, err)
+		}
+		got, err := jobs.Get(ctx, job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != scrapejob.StatusSucceeded || got.LeaseExpiresAt != nil {
+			t.Errorf("finished job is %+v", got)
+		}
+		if next, err := jobs.Claim(ctx, "worker-a", later, later.Add(time.Minute)); err != nil || next != nil {
+			t.Errorf("claimed a finished job: %+v, %v", next, err)
+		}
+	})
+}
+
+func TestRetentionPurgesScrapeJobs(t *testing.T) {
+	forEachDB(t, func(t *testing.T, gdb *gorm.DB) {
+		ctx := context.Background()
+		jobs := repo.NewScrapeJobGormRepo(gdb)
+		retention := repo.NewRetentionGormRepo(gdb)
+		listings := []product.Product{{Name: "Apple AirPods Pro", Platform: "amazon", Link: "https://amazon.example/airpods-pro"}}
+		if err := repo.NewProductGormRepo(gdb).UpsertListings(ctx, listings); err != nil {
+			t.Fatal(err)
+		}
+
+		now := time.Now()
+		old := &scrapejob.Job{Term: "airpods", Status: scrapejob.StatusSucceeded, CreatedAt: now.Add(-8 * 24 * time.Hour),
+			Platforms: []scrapejob.JobPlatform{{Platform: "amazon", Status: scrapejob.PlatformDone}}}
+		recent := &scrapejob.Job{Term: "airpods", Status: scrapejob.StatusQueued,
+			Platforms: []scrapejob.JobPlatform{{Platform: "amazon", Status: scrapejob.PlatformPending}}}
+		for _, j := range []*scrapejob.Job{old, recent} {
+			if err := jobs.Create(ctx, j); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := jobs.ReplaceResults(ctx, old.ID, "amazon", []scrapejob.JobResult{{JobID: old.ID, ProductID: listings[0].ID, Platform: "amazon"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cutoff := now.Add(-7 * 24 * time.Hour)
+		if n, err := retention.CountScrapeJobs(ctx, cutoff); err != nil || n != 1 {
+			t.Fatalf("counted %d expired jobs, %v; want 1", n, err)
+		}
+		if n, err := retention.DeleteScrapeJobs(ctx, cutoff); err != nil || n != 1 {
+			t.Fatalf("deleted %d jobs, %v; want 1", n, err)
+		}
+		if _, err := jobs.Get(ctx, old.ID); !errors.Is(err, scrapejob.ErrJobNotFound) {
+			t.Errorf("expired job still there: %v", err)
+		}
+		if results, err := jobs.Results(ctx, old.ID); err != nil || len(results) != 0 {
+			t.Errorf("expired job's results still there: %v, %v", results, err)
+		}
+		var platforms int64
+		gdb.Model(&scrapejob.JobPlatform{}).Where("job_id = ?", old.ID).Count(&platforms)
+		if platforms != 0 {
+			t.Errorf("expired job's platforms still there: %d", platforms)
+		}
+		if _, err := jobs.Get(ctx, recent.ID); err != nil {
+			t.Errorf("recent job purged: %v", err)
+		}
+	})
+}
+
+func TestRetentionKeepsReferencedListings(t *testing.T) {
+	forEachDB(t, func(t *testing.T, gdb *gorm.DB) {
+		ctx := context.Background()
+		products := repo.NewProductGormRepo(gdb)
+		retention := repo.NewRetentionGormRepo(gdb)
+		listings := []product.Product{
+			{Name: "Apple AirPods Pro", Platform: "amazon", Link: "https://amazon.example/airpods-pro"},
+			{Name: "Apple AirPods Max", Platform: "amazon", Link: "https://amazon.example/airpods-max"},
+			{Name: "Sony WH-1000XM5", Platform: "amazon", Link: "https://amazon.example/xm5"},
+			{Name: "Bose QuietComfort", Platform: "amazon", Link: "https://amazon.example/qc"},
+		}
+		if err := products.UpsertListings(ctx, listings); err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now()
+		old := now.Add(-200 * 24 * time.Hour)
+		if err := gdb.Model(&product.Product{}).Where("1 = 1").UpdateColumn("updated_at", old).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		purchased, watched, alerted, unreferenced := listings[0].ID, listings[1].ID, listings[2].ID, listings[3].ID
+		err := gdb.Create(&purchase.Purchase{UserID: "u1", ListingID: purchased, Retailer: "amazon", ProductName: "Apple AirPods Pro",
+			Link: listings[0].Link, PricePaid: 249, LowestPrice: 249}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		watch := &watchlist.Watch{UserID: "u1", ListingID: &watched, TargetPrice: 400}
+		if err := gdb.Create(watch).Error; err != nil {
+			t.Fatal(err)
+		}
+		termWatch := &watchlist.Watch{UserID: "u1", Term: "sony", TargetPrice: 300}
+		if err := gdb.Create(termWatch).Error; err != nil {
+			t.Fatal(err)
+		}
+		err = gdb.Create(&watchlist.Alert{WatchID: termWatch.ID, UserID: "u1", ListingID: alerted, Platform: "amazon",
+			ProductName: "Sony WH-1000XM5", Link: listings[2].Link, Price: 280, TargetPrice: 300,
+			Status: watchlist.AlertUnread, RaisedAt: now}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids, err := retention.UnseenListings(ctx, now.Add(-180*24*time.Hour), 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) != 1 || ids[0] != unreferenced {
+			t.Errorf("unseen listings %v, want only %d", ids, unreferenced)
+		}
+	})
+}
+
+func TestRetentionPurgesSearchScrapes(t *testing.T) {
+	forEachDB(t, func(t *testing.T, gdb *gorm.DB) {
+		ctx := context.Background()
+		products := repo.NewProductGormRepo(gdb)
+		retention := repo.NewRetentionGormRepo(gdb)
+
+		now := time.Now()
+		for _, s := range []product.SearchScrape{
+			{Term: "airpods", Category: "electronics", Platform: "amazon", ScrapedAt: now.Add(-200 * 24 * time.Hour)},
+			{Term: "airpods", Category: "electronics", Platform: "bestbuy", ScrapedAt: now},
+		} {
+			if err := products.RecordSearchScrape(ctx, &s); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cutoff := now.Add(-180 * 24 * time.Hour)
+		if n, err := retention.CountSearchScrapes(ctx, cutoff); err != nil || n != 1 {
+			t.Fatalf("counted %d old search scrapes, %v; want 1", n, err)
+		}
+		if n, err := retention.DeleteSearchScrapes(ctx, cutoff); err != nil || n != 1 {
+			t.Fatalf("deleted %d search scrapes, %v; want 1", n, err)
+		}
+		scrapes, err := products.SearchScrapes(ctx, "airpods", "electronics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(scrapes) != 1 || scrapes[0].Platform != "bestbuy" {
+			t.Errorf("search scrapes left %+v, want only bestbuy", scrapes)
+		}
+	})
+}
+
+func TestRefreshJobClaim(t *testing.T) {
+	forEachDB(t, func(t *testing.T, gdb *gorm.DB) {
+		ctx := context.Background()
+		jobs := repo.NewRefreshGormRepo(gdb)
+
+		now := time.Now()
+		err := jobs.EnsureJobs(ctx, []refresh.Job{
+			{Kind: refresh.KindQuery, Target: "airpods", NextRunAt: now.Add(-time.Minute)},
+			{Kind: refresh.KindQuery, Target: "walkman", NextRunAt: now.Add(time.Hour)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		due, err := jobs.DueJobs(ctx, now, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(due) != 1 || due[0].Target != "airpods" {
+			t.Fatalf("due jobs %+v, want only airpods", due)
+		}
+
+		// Two instances found the job due; only the first claim wins.
+		if ok, err := jobs.ClaimJob(ctx, due[0].ID, now, now.Add(30*time.Minute)); err != nil || !ok {
+			t.Fatalf("first claim: %v, %v", ok, err)
+		}
+		if ok, err := jobs.ClaimJob(ctx, due[0].ID, now, now.Add(30*time.Minute)); err != nil || ok {
+			t.Fatalf("second claim: %v, %v", ok, err)
+		}
+		due, err = jobs.DueJobs(ctx, now, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(due) != 0 {
+			t.Errorf("claimed job still due: %+v", due)
+		}
+	})
+}
+
+// sameSet reports whether a and b hold the same strings, in any order.
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]int, len(a))
+	for _, s := range a {
+		seen[s]++
+	}
+	for _, s := range b {
+		seen[s]--
+		if seen[s] < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"never-price-match-server/internal/product"
+	"never-price-match-server/internal/purchase"
+	"never-price-match-server/internal/retention"
+	"never-price-match-server/internal/scrapejob"
+	"never-price-match-server/internal/watchlist"
+
+	"gorm.io/gorm"
+)
+
+type retentionGormRepo struct {
+	db *gorm.DB
+}
+
+// NewRetentionGormRepo creates a new GORM retention repository instance
+func NewRetentionGormRepo(db *gorm.DB) retention.Repo {
+	return &retentionGormRepo{db: db}
+}
+
+func (r *retentionGormRepo) UnseenListings(ctx context.Context, cutoff time.Time, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	referencedBy := func(model any, table string) *gorm.DB {
+		return r.db.Model(model).Select("1").Where(table + ".listing_id = products.id")
+	}
+	err := conn(ctx, r.db).Model(&product.Product{}).
+		Where("updated_at < ? AND id > ?", cutoff, afterID).
+		Where("NOT EXISTS (?)", referencedBy(&purchase.Purchase{}, "purchases")).
+		Where("NOT EXISTS (?)", referencedBy(&watchlist.Watch{}, "watches")).
+		Where("NOT EXISTS (?)", referencedBy(&watchlist.Alert{}, "watch_alerts")).
+		Order("id").Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *retentionGormRepo) CountObservations(ctx context.Context, listingIDs []uint) (int64, error) {
+	var n int64
+	err := conn(ctx, r.db).Model(&product.PriceObservation{}).Where("product_id IN ?", listingIDs).Count(&n).Error
+	return n, err
+}
+
+func (r *retentionGormRepo) DeleteListings(ctx context.Context, ids []uint) (int64, error) {
+	db := conn(ctx, r.db)
+	observations := db.Where("product_id IN ?", ids).Delete(&product.PriceObservation{})
+	if observations.Error != nil {
+		return 0, observations.Error
+	}
+	if err := db.Where("product_id IN ?", ids).Delete(&scrapejob.JobResult{}).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Delete(&product.Product{}, ids).Error; err != nil {
+		return 0, err
+	}
+	return observations.RowsAffected, nil
+}
+
+func (r *retentionGormRepo) ListingsObservedBefore(ctx context.Context, before, seenSince time.Time, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := conn(ctx, r.db).Model(&product.Product{}).
+		Where("id > ? AND updated_at >= ?", afterID, seenSince).
+		Where("EXISTS (?)", r.db.Model(&product.PriceObservation{}).
+			Select("1").
+			Where("price_observations.product_id = products.id AND price_observations.observed_at < ?", before)).
+		Order("id").Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *retentionGormRepo) ObservationsBefore(ctx context.Context, listingIDs []uint, before time.Time) ([]product.PriceObservation, error) {
+	var observations []product.PriceObservation
+	err := conn(ctx, r.db).Where("product_id IN ? AND observed_at < ?", listingIDs, before).
+		Order("product_id, observed_at, id").
+		Find(&observations).Error
+	if err != nil {
+		return nil, err
+	}
+	return observations, nil
+}
+
+func (r *retentionGormRepo) DeleteObservations(ctx context.Context, ids []uint) (int64, error) {
+	res := conn(ctx, r.db).Delete(&product.PriceObservation{}, ids)
+	return res.RowsAffected, res.Error
+}
+
+func (r *retentionGormRepo) CountSearchScrapes(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	err := conn(ctx, r.db).Model(&product.SearchScrape{}).Where("scraped_at < ?", before).Count(&n).Error
+	return n, err
+}
+
+func (r *retentionGormRepo) DeleteSearchScrapes(ctx context.Context, before time.Time) (int64, error) {
+	res := conn(ctx, r.db).Where("scraped_at < ?", before).Delete(&product.SearchScrape{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *retentionGormRepo) CountScrapeJobs(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	err := conn(ctx, r.db).Model(&scrapejob.Job{}).Where("created_at < ?", before).Count(&n).Error
+	return n, err
+}
+
+func (r *retentionGormRepo) DeleteScrapeJobs(ctx context.Context, before time.Time) (int64, error) {
+	db := conn(ctx, r.db)
+	expired := func() *gorm.DB {
+		return r.db.Model(&scrapejob.Job{}).Select("id").Where("created_at < ?", before)
+	}
+	if err := db.Where("job_id IN (?)", expired()).Delete(&scrapejob.JobResult{}).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Where("job_id IN (?)", expired()).Delete(&scrapejob.JobPlatform{}).Error; err != nil {
+		return 0, err
+	}
+	res := db.Where("created_at < ?", before).Delete(&scrapejob.Job{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"never-price-match-server/internal/scrapejob"
+
+	"gorm.io/gorm"
+)
+
+// claimCandidates is how many claimable jobs Claim tries before giving up, in case
+// other workers take the first ones.
+const claimCandidates = 5
+
+type scrapeJobGormRepo struct {
+	db *gorm.DB
+}
+
+// NewScrapeJobGormRepo creates a new GORM scrape job repository instance
+func NewScrapeJobGormRepo(db *gorm.DB) scrapejob.Repo {
+	return &scrapeJobGormRepo{db: db}
+}
+
+func (r *scrapeJobGormRepo) Create(ctx context.Context, job *scrapejob.Job) error {
+	return conn(ctx, r.db).Create(job).Error
+}
+
+func (r *scrapeJobGormRepo) Get(ctx context.Context, id string) (*scrapejob.Job, error) {
+	var job scrapejob.Job
+	err := conn(ctx, r.db).
+		Preload("Platforms", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Where("id = ?", id).
+		First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, scrapejob.ErrJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Claim picks candidates and then takes one with a conditional update, which only
+// one of several competing workers can win. It needs no row locks, so it behaves
+// the same on every database.
+func (r *scrapeJobGormRepo) Claim(ctx context.Context, owner string, now, leaseUntil time.Time) (*scrapejob.Job, error) {
+	db := conn(ctx, r.db)
+	claimable := func(q *gorm.DB) *gorm.DB {
+		return q.Where("status = ? OR (status = ? AND lease_expires_at < ?)",
+			scrapejob.StatusQueued, scrapejob.StatusRunning, now)
+	}
+
+	var candidates []scrapejob.Job
+	err := claimable(db.Model(&scrapejob.Job{}).Select("id")).
+		Order("created_at").
+		Limit(claimCandidates).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range candidates {
+		res := claimable(db.Model(&scrapejob.Job{}).Where("id = ?", c.ID)).
+			Updates(map[string]any{
+				"status":           scrapejob.StatusRunning,
+				"lease_owner":      owner,
+				"lease_expires_at": leaseUntil,
+				"attempts":         gorm.Expr("attempts + 1"),
+			})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return r.Get(ctx, c.ID)
+		}
+	}
+	return nil, nil
+}
+
+func (r *scrapeJobGormRepo) RenewLease(ctx context.Context, id, owner string, leaseUntil time.Time) (bool, error) {
+	res := conn(ctx, r.db).Model(&scrapejob.Job{}).
+		Where("id = ? AND lease_owner = ? AND status = ?", id, owner, scrapejob.StatusRunning).
+		Update("lease_expires_at", leaseUntil)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *scrapeJobGormRepo) UpdatePlatform(ctx context.Context, p *scrapejob.JobPlatform) error {
+	return conn(ctx, r.db).Save(p).Error
+}
+
+func (r *scrapeJobGormRepo) ReplaceResults(ctx context.Context, jobID, platform string, results []scrapejob.JobResult) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("job_id = ? AND platform = ?", jobID, platform).Delete(&scrapejob.JobResult{}).Error
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return nil
+		}
+		return tx.Create(&results).Error
+	})
+}
+
+func (r *scrapeJobGormRepo) Results(ctx context.Context, jobID string) ([]scrapejob.JobResult, error) {
+	var results []scrapejob.JobResult
+	err := conn(ctx, r.db).Where("job_id = ?", jobID).Order("platform, position").Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *scrapeJobGormRepo) Finish(ctx context.Context, job *scrapejob.Job, owner string) (bool, error) {
+	res := conn(ctx, r.db).Model(&scrapejob.Job{}).
+		Where("id = ? AND lease_owner = ?", job.ID, owner).
+		Updates(map[string]any{
+			"status":           job.Status,
+			"error":            job.Error,
+			"finished_at":      job.FinishedAt,
+			"lease_expires_at": nil,
+		})
+	return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"never-price-match-server/internal/infra/date"
+	"never-price-match-server/internal/searchlog"
+
+	"gorm.io/gorm"
+)
+
+type searchLogGormRepo struct {
+	db *gorm.DB
+}
+
+// NewSearchLogGormRepo creates a new GORM search log repository instance
+func NewSearchLogGormRepo(db *gorm.DB) searchlog.Repo {
+	return &searchLogGormRepo{db: db}
+}
+
+func (r *searchLogGormRepo) Create(ctx context.Context, q *searchlog.SearchQuery) error {
+	return conn(ctx, r.db).Create(q).Error
+}
+
+func (r *searchLogGormRepo) DeleteBefore(ctx context.Context, t time.Time) (int64, error) {
+	res := conn(ctx, r.db).Where("created_at < ?", t).Delete(&searchlog.SearchQuery{})
+	return res.RowsAffected, res.Error
+}
+
+// ReplaceDailyStats rebuilds one day's rollup. The day is bounded by timestamps rather
+// than a DATE() call so the query stays portable across databases.
+func (r *searchLogGormRepo) ReplaceDailyStats(ctx context.Context, day time.Time) error {
+	next := day.AddDate(0, 0, 1)
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("day = ?", date.Of(day)).Delete(&searchlog.DailySearchStat{}).Error; err != nil {
+			return err
+		}
+
+		var stats []searchlog.DailySearchStat
+		err := tx.Model(&searchlog.SearchQuery{}).
+			Select(`normalized_term AS term, category, inferred_category,
+				COUNT(*) AS searches,
+				SUM(CASE WHEN result_count = 0 THEN 1 ELSE 0 END) AS zero_results,
+				SUM(CASE WHEN cache_hit THEN 1 ELSE 0 END) AS cache_hits,
+				SUM(latency_ms) AS total_latency_ms`).
+			Where("created_at >= ? AND created_at < ?", day, next).
+			Group("normalized_term, category, inferred_category").
+			Scan(&stats).Error
+		if err != nil {
+			return err
+		}
+		if len(stats) == 0 {
+			return nil
+		}
+		for i := range stats {
+			stats[i].Day = date.Of(day)
+		}
+		return tx.Create(&stats).Error
+	})
+}
+
+func (r *searchLogGormRepo) DeleteDailyStatsBefore(ctx context.Context, day time.Time) (int64, error) {
+	res := conn(ctx, r.db).Where("day < ?", date.Of(day)).Delete(&searchlog.DailySearchStat{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *searchLogGormRepo) TermStatsSince(ctx context.Context, day time.Time) ([]searchlog.TermStats, error) {
+	var stats []searchlog.TermStats
+	err := conn(ctx, r.db).Model(&searchlog.DailySearchStat{}).
+		Select(`term, inferred_category,
+			SUM(searches) AS searches,
+			SUM(zero_results) AS zero_results,
+			SUM(cache_hits) AS cache_hits,
+			SUM(total_latency_ms) AS total_latency_ms`).
+		Where("day >= ?", date.Of(day)).
+		Group("term, inferred_category").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
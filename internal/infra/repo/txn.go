@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"context"
+
+	"never-price-match-server/internal/infra/txn"
+
+	"gorm.io/gorm"
+)
+
+// txKey is the context key of the transaction opened by gormTxRunner.
+type txKey struct{}
+
+type gormTxRunner struct {
+	db *gorm.DB
+}
+
+// NewTxRunner creates a unit-of-work runner over db for the GORM repositories.
+func NewTxRunner(db *gorm.DB) txn.Runner {
+	return &gormTxRunner{db: db}
+}
+
+func (r *gormTxRunner) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction ctx carries, or db bound to ctx when there is none.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
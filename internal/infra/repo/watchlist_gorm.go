@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"never-price-match-server/internal/watchlist"
+
+	"gorm.io/gorm"
+)
+
+type watchlistGormRepo struct {
+	db *gorm.DB
+}
+
+// NewWatchlistGormRepo creates a new GORM watchlist repository instance
+func NewWatchlistGormRepo(db *gorm.DB) watchlist.Repo {
+	return &watchlistGormRepo{db: db}
+}
+
+func (r *watchlistGormRepo) Create(ctx context.Context, w *watchlist.Watch) error {
+	return conn(ctx, r.db).Create(w).Error
+}
+
+func (r *watchlistGormRepo) ListByUser(ctx context.Context, userID string) ([]watchlist.Watch, error) {
+	var watches []watchlist.Watch
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at DESC").Find(&watches).Error
+	return watches, err
+}
+
+func (r *watchlistGormRepo) Delete(ctx context.Context, userID, id string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&watchlist.Watch{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return watchlist.ErrWatchNotFound
+		}
+		return tx.Where("watch_id = ?", id).Delete(&watchlist.Alert{}).Error
+	})
+}
+
+func (r *watchlistGormRepo) Active(ctx context.Context, now time.Time, listingIDs []uint) ([]watchlist.Watch, error) {
+	q := conn(ctx, r.db).Where("expires_at IS NULL OR expires_at > ?", now)
+	if len(listingIDs) > 0 {
+		q = q.Where("listing_id IS NULL OR listing_id IN ?", listingIDs)
+	} else {
+		q = q.Where("listing_id IS NULL")
+	}
+	var watches []watchlist.Watch
+	err := q.Find(&watches).Error
+	return watches, err
+}
+
+func (r *watchlistGormRepo) WatchedListings(ctx context.Context, now time.Time) ([]uint, error) {
+	var ids []uint
+	err := conn(ctx, r.db).Model(&watchlist.Watch{}).
+		Where("listing_id IS NOT NULL").
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Distinct().
+		Pluck("listing_id", &ids).Error
+	return ids, err
+}
+
+func (r *watchlistGormRepo) Alerts(ctx context.Context, userID string, status watchlist.AlertStatus) ([]watchlist.Alert, error) {
+	q := conn(ctx, r.db).Where("user_id = ?", userID)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var alerts []watchlist.Alert
+	err := q.Order("raised_at DESC").Find(&alerts).Error
+	return alerts, err
+}
+
+func (r *watchlistGormRepo) AlertsFor(ctx context.Context, watchIDs []string, listingIDs []uint) ([]watchlist.Alert, error) {
+	if len(watchIDs) == 0 || len(listingIDs) == 0 {
+		return nil, nil
+	}
+	var alerts []watchlist.Alert
+	err := conn(ctx, r.db).Where("watch_id IN ? AND listing_id IN ?", watchIDs, listingIDs).Find(&alerts).Error
+	return alerts, err
+}
+
+func (r *watchlistGormRepo) GetAlert(ctx context.Context, userID, id string) (*watchlist.Alert, error) {
+	var a watchlist.Alert
+	if err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&a).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, watchlist.ErrAlertNotFound
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *watchlistGormRepo) SaveAlert(ctx context.Context, a *watchlist.Alert) error {
+	return conn(ctx, r.db).Save(a).Error
+}
+
+func (r *watchlistGormRepo) MarkRead(ctx context.Context, userID string, ids []string, t time.Time) (int64, error) {
+	res := conn(ctx, r.db).Model(&watchlist.Alert{}).
+		Where("user_id = ? AND id IN ? AND status = ?", userID, ids, watchlist.AlertUnread).
+		Updates(map[string]any{"status": watchlist.AlertRead, "read_at": t})
+	return res.RowsAffected, res.Error
+}
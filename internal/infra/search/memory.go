@@ -0,0 +1,210 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"never-price-match-server/internal/product"
+)
+
+// Loader reads the listings a search returns; product.Repo is one.
+type Loader interface {
+	GetProductsByIDs(ctx context.Context, ids []uint) ([]product.Product, error)
+}
+
+// InvertedIndex is an in-process full-text index over listing names.
+// It needs no database support, which makes it the choice for local and dev runs;
+// it is rebuilt from the database on start and kept current through Index. Only what
+// ranking needs is held in memory; the listings found are loaded through a Loader.
+type InvertedIndex struct {
+	listings Loader
+
+	mu       sync.RWMutex
+	docs     map[uint]indexedDoc
+	postings map[string]map[uint]int // token -> listing ID -> term frequency
+	// totalLength is the sum of the docs' lengths, for BM25's average document length.
+	totalLength int
+}
+
+type indexedDoc struct {
+	// tokens are the distinct tokens of the name, to find its postings on removal.
+	tokens []string
+	length int
+}
+
+// NewInvertedIndex creates an empty in-memory index whose hits are loaded from listings.
+func NewInvertedIndex(listings Loader) *InvertedIndex {
+	return &InvertedIndex{
+		listings: listings,
+		docs:     make(map[uint]indexedDoc),
+		postings: make(map[string]map[uint]int),
+	}
+}
+
+// Index adds or replaces listings by ID.
+func (idx *InvertedIndex) Index(products []product.Product) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, p := range products {
+		if p.ID == 0 {
+			continue
+		}
+		idx.remove(p.ID)
+		tokens := product.SearchTokens(p.Name)
+		doc := indexedDoc{length: len(tokens)}
+		for _, t := range tokens {
+			if idx.postings[t] == nil {
+				idx.postings[t] = make(map[uint]int)
+			}
+			if idx.postings[t][p.ID] == 0 {
+				doc.tokens = append(doc.tokens, t)
+			}
+			idx.postings[t][p.ID]++
+		}
+		idx.docs[p.ID] = doc
+		idx.totalLength += doc.length
+	}
+	return nil
+}
+
+// Remove drops listings from the index.
+func (idx *InvertedIndex) Remove(ids ...uint) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, id := range ids {
+		idx.remove(id)
+	}
+}
+
+func (idx *InvertedIndex) remove(id uint) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, t := range doc.tokens {
+		delete(idx.postings[t], id)
+		if len(idx.postings[t]) == 0 {
+			delete(idx.postings, t)
+		}
+	}
+	delete(idx.docs, id)
+	idx.totalLength -= doc.length
+}
+
+// BM25 parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Search returns the listings containing every query word, scored with BM25.
+// The last word also matches as a prefix, so partially typed queries find results.
+// Listings deleted from the database since they were indexed are left out.
+func (idx *InvertedIndex) Search(ctx context.Context, query string, limit int) ([]product.SearchHit, error) {
+	scored := idx.score(query, limit)
+	if len(scored) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, len(scored))
+	for i, s := range scored {
+		ids[i] = s.id
+	}
+	products, err := idx.listings.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]product.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	hits := make([]product.SearchHit, 0, len(scored))
+	for _, s := range scored {
+		if p, ok := byID[s.id]; ok {
+			hits = append(hits, product.SearchHit{Product: p, Relevance: s.relevance})
+		}
+	}
+	return hits, nil
+}
+
+// scoredDoc is a matching listing with its BM25 score.
+type scoredDoc struct {
+	id        uint
+	relevance float64
+}
+
+// score returns up to limit of the listings matching query, best first.
+func (idx *InvertedIndex) score(query string, limit int) []scoredDoc {
+	terms := product.SearchTokens(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	if len(idx.docs) == 0 {
+		return nil
+	}
+	avgLength := float64(idx.totalLength) / float64(len(idx.docs))
+
+	var scores map[uint]float64
+	for i, term := range terms {
+		termScores := make(map[uint]float64)
+		for _, token := range idx.matchingTokens(term, i == len(terms)-1) {
+			posting := idx.postings[token]
+			idf := math.Log(1 + (float64(len(idx.docs))-float64(len(posting))+0.5)/(float64(len(posting))+0.5))
+			for id, tf := range posting {
+				norm := float64(tf) + bm25K1*(1-bm25B+bm25B*float64(idx.docs[id].length)/avgLength)
+				termScores[id] = math.Max(termScores[id], idf*float64(tf)*(bm25K1+1)/norm)
+			}
+		}
+
+		// Keep only listings that matched every term so far.
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if s, ok := termScores[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	docs := make([]scoredDoc, 0, len(scores))
+	for id, score := range scores {
+		docs = append(docs, scoredDoc{id: id, relevance: score})
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if docs[i].relevance != docs[j].relevance {
+			return docs[i].relevance > docs[j].relevance
+		}
+		return docs[i].id > docs[j].id
+	})
+	if limit > 0 && len(docs) > limit {
+		docs = docs[:limit]
+	}
+	return docs
+}
+
+// matchingTokens returns the indexed tokens a query term matches: the term itself,
+// plus every token it prefixes when prefix matching is allowed.
+func (idx *InvertedIndex) matchingTokens(term string, prefix bool) []string {
+	if !prefix {
+		if _, ok := idx.postings[term]; ok {
+			return []string{term}
+		}
+		return nil
+	}
+	var tokens []string
+	for token := range idx.postings {
+		if strings.HasPrefix(token, term) {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"never-price-match-server/internal/product"
+
+	"gorm.io/gorm"
+)
+
+// mysqlMinTokenLength mirrors InnoDB's default innodb_ft_min_token_size;
+// shorter words are not in the FULLTEXT index.
+const mysqlMinTokenLength = 3
+
+// MySQLFulltext searches listings through the FULLTEXT index on products.name.
+type MySQLFulltext struct {
+	db *gorm.DB
+}
+
+// NewMySQLFulltext creates a search index backed by MySQL's FULLTEXT support.
+func NewMySQLFulltext(db *gorm.DB) *MySQLFulltext {
+	return &MySQLFulltext{db: db}
+}
+
+// Index is a no-op: MySQL maintains the FULLTEXT index on write.
+func (m *MySQLFulltext) Index([]product.Product) error { return nil }
+
+// hitRow is a products row plus its FULLTEXT relevance.
+type hitRow struct {
+	product.Product
+	Relevance float64
+}
+
+// Search matches every indexable query word in boolean mode, the last one as a prefix,
+// and ranks by natural-language relevance. Words too short for the FULLTEXT index are
+// matched with LIKE instead.
+func (m *MySQLFulltext) Search(ctx context.Context, query string, limit int) ([]product.SearchHit, error) {
+	terms := product.SearchTokens(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var required []string
+	var short []string
+	for i, t := range terms {
+		if len(t) < mysqlMinTokenLength {
+			short = append(short, t)
+			continue
+		}
+		if i == len(terms)-1 {
+			t += "*"
+		}
+		required = append(required, "+"+t)
+	}
+
+	q := m.db.WithContext(ctx).Model(&product.Product{})
+	if len(required) > 0 {
+		q = q.Select("products.*, MATCH(name) AGAINST (? IN NATURAL LANGUAGE MODE) AS relevance", strings.Join(terms, " ")).
+			Where("MATCH(name) AGAINST (? IN BOOLEAN MODE)", strings.Join(required, " ")).
+			Order("relevance DESC")
+	} else {
+		q = q.Select("products.*, 0 AS relevance")
+	}
+	for _, t := range short {
+		q = q.Where("LOWER(name) LIKE ?", "%"+t+"%")
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	var rows []hitRow
+	if err := q.Order("updated_at DESC").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	hits := make([]product.SearchHit, len(rows))
+	for i, r := range rows {
+		hits[i] = product.SearchHit{Product: r.Product, Relevance: r.Relevance}
+	}
+	return hits, nil
+}
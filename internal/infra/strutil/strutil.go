@@ -0,0 +1,13 @@
+// Package strutil holds string helpers shared by the services.
+package strutil
+
+import "unicode/utf8"
+
+// Truncate cuts s to at most n runes, so it never splits a multi-byte character and
+// fits a varchar(n) column on every database.
+func Truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
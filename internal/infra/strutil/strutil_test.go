@@ -0,0 +1,30 @@
+package strutil
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	cases := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"", 3, ""},
+		{"abc", 3, "abc"},
+		{"abcd", 3, "abc"},
+		{"café au lait", 4, "café"},
+		{"日本語のエラー", 3, "日本語"},
+		{"abc", 0, ""},
+	}
+	for _, c := range cases {
+		got := Truncate(c.in, c.n)
+		if got != c.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", c.in, c.n, got, c.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("Truncate(%q, %d) is not valid UTF-8", c.in, c.n)
+		}
+	}
+}
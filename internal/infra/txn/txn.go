@@ -0,0 +1,12 @@
+// Package txn lets services group repository calls into one database transaction.
+package txn
+
+import "context"
+
+// Runner runs units of work.
+type Runner interface {
+	// Run calls fn in a transaction that is committed if fn returns nil and rolled
+	// back otherwise. Repository calls made with the context passed to fn take part
+	// in the transaction; a Run inside fn joins the outer transaction.
+	Run(ctx context.Context, fn func(ctx context.Context) error) error
+}
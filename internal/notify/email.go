@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoEmail is returned when emailing a user without an email address.
+var ErrNoEmail = errors.New("user has no email address")
+
+// SMTPConfig is where email is relayed. Any SMTP server works, including a local
+// stand-in such as Mailpit.
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password are optional; they are only sent over TLS.
+	Username string
+	Password string
+	// From is the sender address, e.g. "Never Price Match <alerts@example.com>".
+	From string
+}
+
+// smtpSender delivers notifications as plain-text email.
+type smtpSender struct {
+	cfg  SMTPConfig
+	from *mail.Address
+}
+
+// NewSMTPSender creates an email sender relaying through cfg's server.
+func NewSMTPSender(cfg SMTPConfig) (Sender, error) {
+	if cfg.Port == 0 {
+		cfg.Port = 25
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("smtp from address: %w", err)
+	}
+	return &smtpSender{cfg: cfg, from: from}, nil
+}
+
+func (s *smtpSender) Send(ctx context.Context, to Recipient, n *Notification) error {
+	if to.Email == "" {
+		return ErrNoEmail
+	}
+	rcpt := &mail.Address{Name: to.Name, Address: to.Email}
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		// PlainAuth refuses to send credentials unencrypted, except to localhost.
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(rcpt.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.message(rcpt, n)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message builds the email for a notification.
+func (s *smtpSender) message(to *mail.Address, n *Notification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", n.CreatedAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", n.ID, domain(s.from.Address))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(n.Body)
+	b.WriteString("\r\n")
+	if n.Link != "" {
+		b.WriteString("\r\n")
+		b.WriteString(n.Link)
+		b.WriteString("\r\n")
+	}
+	b.WriteString("\r\n-- \r\nYou can change how you're notified in your notification preferences.\r\n")
+	return b.Bytes()
+}
+
+// domain returns the domain of an email address.
+func domain(addr string) string {
+	if i := strings.LastIndexByte(addr, '@'); i >= 0 {
+		return addr[i+1:]
+	}
+	return "localhost"
+}
@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpServer is a minimal in-process SMTP server that accepts one message.
+type smtpServer struct {
+	ln      net.Listener
+	from    string
+	rcpt    []string
+	message string
+	done    chan struct{}
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{ln: ln, done: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *smtpServer) serve() {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250 localhost")
+		case "MAIL":
+			s.from = arg
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			s.rcpt = append(s.rcpt, arg)
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			b, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.message = string(b)
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	srv := newSMTPServer(t)
+	host, port, _ := net.SplitHostPort(srv.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	sender, err := NewSMTPSender(SMTPConfig{Host: host, Port: p, From: "Never Price Match <alerts@example.com>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := &Notification{ID: "n1", Title: "Prix baissé", Body: "Now $199", Link: "https://example.com/p/1", CreatedAt: time.Now()}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sender.Send(ctx, Recipient{Name: "Sam", Email: "sam@example.com"}, n); err != nil {
+		t.Fatal(err)
+	}
+	<-srv.done
+
+	if srv.from != "FROM:<alerts@example.com>" {
+		t.Errorf("MAIL %s", srv.from)
+	}
+	if len(srv.rcpt) != 1 || srv.rcpt[0] != "TO:<sam@example.com>" {
+		t.Errorf("RCPT %v", srv.rcpt)
+	}
+	msg, err := textproto.NewReader(bufio.NewReader(strings.NewReader(srv.message))).ReadMIMEHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msg.Get("Subject"); got != "=?utf-8?q?Prix_baiss=C3=A9?=" {
+		t.Errorf("Subject %q", got)
+	}
+	if got := msg.Get("Message-Id"); got != "<n1@example.com>" {
+		t.Errorf("Message-ID %q", got)
+	}
+	if !strings.Contains(srv.message, "Now $199\n") || !strings.Contains(srv.message, "https://example.com/p/1") {
+		t.Errorf("body missing from message:\n%s", srv.message)
+	}
+}
+
+func TestSMTPSendWithoutEmail(t *testing.T) {
+	sender, err := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", From: "alerts@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.Send(context.Background(), Recipient{Name: "Sam"}, &Notification{}); !errors.Is(err, ErrNoEmail) {
+		t.Errorf("Send: %v, want ErrNoEmail", err)
+	}
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Channel is a way of delivering notifications outside the app.
+type Channel string
+
+const (
+	ChannelEmail   Channel = "EMAIL"
+	ChannelWebhook Channel = "WEBHOOK"
+)
+
+// DeliveryStatus is where a delivery is in its life.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is waiting for its next attempt, possibly after quiet hours.
+	DeliveryPending DeliveryStatus = "PENDING"
+	DeliverySent    DeliveryStatus = "SENT"
+	// DeliveryFailed has used up its attempts.
+	DeliveryFailed DeliveryStatus = "FAILED"
+)
+
+// Notification is one message to a user. It is what the in-app inbox shows, and
+// what its deliveries to other channels are rendered from.
+type Notification struct {
+	ID     string `gorm:"type:varchar(36);primaryKey"`
+	UserID string `gorm:"type:varchar(36);not null;index:idx_notifications_user_created,priority:1"`
+	Kind   string `gorm:"type:varchar(32);not null"`
+	// Title, Body and Link are rendered from the kind's template when the notification is created.
+	Title string `gorm:"type:varchar(255);not null"`
+	Body  string `gorm:"type:text;not null"`
+	Link  string `gorm:"type:varchar(1024)"`
+	// InApp is false when the user had the in-app inbox turned off; such notifications
+	// only exist for their deliveries.
+	InApp     bool `gorm:"not null"`
+	ReadAt    *time.Time
+	CreatedAt time.Time `gorm:"index:idx_notifications_user_created,priority:2"`
+}
+
+func (Notification) TableName() string { return "notifications" }
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) (err error) {
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Preferences are how a user wants to be notified. Users who never saved any get
+// DefaultPreferences.
+type Preferences struct {
+	UserID        string `gorm:"type:varchar(36);primaryKey"`
+	InApp         bool   `gorm:"not null"`
+	Email         bool   `gorm:"not null"`
+	Webhook       bool   `gorm:"not null"`
+	WebhookURL    string `gorm:"type:varchar(1024)"`
+	WebhookSecret string `gorm:"type:varchar(255)"`
+	// QuietStart and QuietEnd are "HH:MM" times in TimeZone between which email and
+	// webhook deliveries wait; both empty means no quiet hours. The span may cross midnight.
+	QuietStart string `gorm:"type:varchar(5)"`
+	QuietEnd   string `gorm:"type:varchar(5)"`
+	// TimeZone is an IANA zone name such as Australia/Sydney.
+	TimeZone  string `gorm:"type:varchar(64);not null"`
+	UpdatedAt time.Time
+}
+
+func (Preferences) TableName() string { return "notification_preferences" }
+
+// DefaultPreferences are the preferences of a user who hasn't saved any: the inbox and email.
+func DefaultPreferences(userID string) Preferences {
+	return Preferences{UserID: userID, InApp: true, Email: true, TimeZone: "UTC"}
+}
+
+// Delivery is one attempt, with retries, to deliver a notification over a channel.
+// The deliveries of a user are their delivery log.
+type Delivery struct {
+	ID             string         `gorm:"type:varchar(36);primaryKey"`
+	NotificationID string         `gorm:"type:varchar(36);not null;index"`
+	UserID         string         `gorm:"type:varchar(36);not null;index:idx_notification_deliveries_user_created,priority:1"`
+	Channel        Channel        `gorm:"type:varchar(16);not null"`
+	Status         DeliveryStatus `gorm:"type:varchar(16);not null;index:idx_notification_deliveries_due,priority:1"`
+	Attempts       int            `gorm:"not null"`
+	// NextAttemptAt is when the delivery is due; while an attempt runs it is pushed
+	// out, so a crashed sender's delivery is retried once it passes.
+	NextAttemptAt time.Time `gorm:"not null;index:idx_notification_deliveries_due,priority:2"`
+	LastError     string    `gorm:"type:varchar(1024)"`
+	SentAt        *time.Time
+	CreatedAt     time.Time `gorm:"index:idx_notification_deliveries_user_created,priority:2"`
+	UpdatedAt     time.Time
+}
+
+func (Delivery) TableName() string { return "notification_deliveries" }
+
+func (d *Delivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
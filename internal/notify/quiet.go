@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+)
+
+// parseClock parses an "HH:MM" time of day into minutes after midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not HH:MM", ErrInvalidQuietHours, s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// quietUntil returns when the quiet hours around t end in p's time zone, or t itself
+// when t isn't in quiet hours.
+func quietUntil(p Preferences, t time.Time) time.Time {
+	if p.QuietStart == "" || p.QuietEnd == "" {
+		return t
+	}
+	start, err := parseClock(p.QuietStart)
+	if err != nil {
+		return t
+	}
+	end, err := parseClock(p.QuietEnd)
+	if err != nil || start == end {
+		return t
+	}
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := t.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	now := local.Hour()*60 + local.Minute()
+	at := func(day, minutes int) time.Time {
+		d := midnight.AddDate(0, 0, day)
+		return time.Date(d.Year(), d.Month(), d.Day(), minutes/60, minutes%60, 0, 0, loc)
+	}
+	switch {
+	case start < end && now >= start && now < end:
+		return at(0, end)
+	case start > end && now >= start:
+		// Quiet hours across midnight, in the evening part.
+		return at(1, end)
+	case start > end && now < end:
+		// Quiet hours across midnight, in the morning part.
+		return at(0, end)
+	}
+	return t
+}
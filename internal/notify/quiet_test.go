@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietUntil(t *testing.T) {
+	day := func(hour, minute int) time.Time { return time.Date(2026, 3, 10, hour, minute, 0, 0, time.UTC) }
+	nextDay := func(hour, minute int) time.Time { return time.Date(2026, 3, 11, hour, minute, 0, 0, time.UTC) }
+	cases := []struct {
+		name       string
+		start, end string
+		at, want   time.Time
+	}{
+		{"no quiet hours", "", "", day(23, 0), day(23, 0)},
+		{"same day, before", "09:00", "17:00", day(8, 59), day(8, 59)},
+		{"same day, inside", "09:00", "17:00", day(9, 0), day(17, 0)},
+		{"same day, at the end", "09:00", "17:00", day(17, 0), day(17, 0)},
+		{"across midnight, evening", "22:00", "07:30", day(23, 15), nextDay(7, 30)},
+		{"across midnight, at the start", "22:00", "07:30", day(22, 0), nextDay(7, 30)},
+		{"across midnight, morning", "22:00", "07:30", day(6, 0), day(7, 30)},
+		{"across midnight, after", "22:00", "07:30", day(7, 30), day(7, 30)},
+		{"across midnight, daytime", "22:00", "07:30", day(12, 0), day(12, 0)},
+		{"empty span", "08:00", "08:00", day(8, 0), day(8, 0)},
+	}
+	for _, c := range cases {
+		p := Preferences{QuietStart: c.start, QuietEnd: c.end, TimeZone: "UTC"}
+		if got := quietUntil(p, c.at); !got.Equal(c.want) {
+			t.Errorf("%s: quietUntil(%s) = %s, want %s", c.name, c.at.Format("15:04"), got, c.want)
+		}
+	}
+}
+
+func TestQuietUntilTimeZone(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+	p := Preferences{QuietStart: "22:00", QuietEnd: "07:00", TimeZone: "Australia/Sydney"}
+	// 12:30 UTC is 23:30 in Sydney (UTC+11), so the quiet hours end at 07:00 there.
+	at := time.Date(2026, 1, 15, 12, 30, 0, 0, time.UTC)
+	want := time.Date(2026, 1, 16, 7, 0, 0, 0, sydney)
+	if got := quietUntil(p, at); !got.Equal(want) {
+		t.Errorf("quietUntil = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+// Repo defines the interface for notification persistence.
+type Repo interface {
+	// Create stores a notification together with its deliveries.
+	Create(ctx context.Context, n *Notification, deliveries []Delivery) error
+	// Inbox returns up to limit of a user's in-app notifications, newest first.
+	Inbox(ctx context.Context, userID string, unreadOnly bool, limit int) ([]Notification, error)
+	// UnreadCount counts a user's unread in-app notifications.
+	UnreadCount(ctx context.Context, userID string) (int64, error)
+	// MarkRead marks a user's unread notifications among ids, or all of them when ids
+	// is nil, as read at t and returns how many there were.
+	MarkRead(ctx context.Context, userID string, ids []string, t time.Time) (int64, error)
+	// GetNotifications returns notifications by ID, in no particular order.
+	GetNotifications(ctx context.Context, ids []string) ([]Notification, error)
+
+	// GetPreferences returns a user's saved preferences, or nil if they never saved any.
+	GetPreferences(ctx context.Context, userID string) (*Preferences, error)
+	// SavePreferences creates or replaces a user's preferences.
+	SavePreferences(ctx context.Context, p *Preferences) error
+
+	// ClaimDue leases up to limit pending deliveries due at now until leaseUntil,
+	// counting an attempt on each. A delivery is only ever claimed by one caller.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]Delivery, error)
+	// SaveDelivery stores a delivery's outcome.
+	SaveDelivery(ctx context.Context, d *Delivery) error
+	// Deliveries returns up to limit of a user's deliveries, newest first.
+	Deliveries(ctx context.Context, userID string, limit int) ([]Delivery, error)
+}
@@ -0,0 +1,451 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/infra/strutil"
+	"never-price-match-server/internal/user"
+)
+
+const (
+	// DefaultPollInterval is how often due deliveries are looked for when Config.PollInterval is zero.
+	DefaultPollInterval = 15 * time.Second
+	// DefaultMaxAttempts is how often a delivery is tried when Config.MaxAttempts is zero.
+	DefaultMaxAttempts = 5
+	// DefaultRetryBackoff is the wait before the first retry when Config.RetryBackoff
+	// is zero; it doubles with every further attempt.
+	DefaultRetryBackoff = time.Minute
+
+	// DefaultInboxLimit and MaxInboxLimit bound how many notifications or deliveries one call returns.
+	DefaultInboxLimit = 50
+	MaxInboxLimit     = 200
+
+	// sendBatch is how many due deliveries are claimed at once.
+	sendBatch = 20
+	// sendTimeout bounds a single attempt; sendLease, a little longer, is how long a
+	// claimed delivery is kept from other senders.
+	sendTimeout = 30 * time.Second
+	sendLease   = 2 * time.Minute
+)
+
+var (
+	// ErrUnknownKind is returned for a notification kind without a template.
+	ErrUnknownKind = errors.New("unknown notification kind")
+	// ErrInvalidWebhookURL is returned for a webhook URL that isn't absolute http(s).
+	ErrInvalidWebhookURL = errors.New("webhook URL must be an absolute http or https URL")
+	// ErrMissingWebhookSecret is returned when turning webhooks on without a secret to sign them with.
+	ErrMissingWebhookSecret = errors.New("webhooks need a URL and a signing secret")
+	// ErrInvalidQuietHours is returned for quiet hours that aren't a pair of HH:MM times.
+	ErrInvalidQuietHours = errors.New("quiet hours need a start and an end time")
+	// ErrInvalidTimeZone is returned for a time zone that isn't an IANA name.
+	ErrInvalidTimeZone = errors.New("unknown time zone")
+)
+
+// Config tunes delivery of notifications outside the app.
+type Config struct {
+	PollInterval time.Duration
+	// MaxAttempts is how often a delivery is tried before it is marked failed.
+	MaxAttempts  int
+	RetryBackoff time.Duration
+}
+
+// PreferencesUpdate changes a user's preferences; nil fields are left as they are.
+// An empty WebhookURL, QuietStart or QuietEnd clears it.
+type PreferencesUpdate struct {
+	InApp         *bool
+	Email         *bool
+	Webhook       *bool
+	WebhookURL    *string
+	WebhookSecret *string
+	QuietStart    *string
+	QuietEnd      *string
+	TimeZone      *string
+}
+
+// Recipient is where one user's deliveries go.
+type Recipient struct {
+	Name          string
+	Email         string
+	WebhookURL    string
+	WebhookSecret string
+}
+
+// Sender delivers notifications over one channel.
+type Sender interface {
+	Send(ctx context.Context, to Recipient, n *Notification) error
+}
+
+// Service defines the business logic interface for notifications.
+type Service interface {
+	// Notify renders a notification of kind from data, puts it in the user's inbox and
+	// queues it on the user's other channels. Outside deliveries wait out quiet hours.
+	Notify(ctx context.Context, userID, kind, link string, data any) error
+	// Inbox returns up to limit of a user's in-app notifications, newest first.
+	Inbox(ctx context.Context, userID string, unreadOnly bool, limit int) ([]Notification, error)
+	// UnreadCount counts a user's unread in-app notifications.
+	UnreadCount(ctx context.Context, userID string) (int, error)
+	// MarkRead marks a user's unread notifications among ids, or all of them when ids
+	// is nil, as read and returns how many there were.
+	MarkRead(ctx context.Context, userID string, ids []string) (int, error)
+	// Preferences returns a user's preferences, the defaults if they never saved any.
+	Preferences(ctx context.Context, userID string) (*Preferences, error)
+	// UpdatePreferences validates and saves changes to a user's preferences.
+	UpdatePreferences(ctx context.Context, userID string, in PreferencesUpdate) (*Preferences, error)
+	// Deliveries returns up to limit of a user's deliveries, newest first.
+	Deliveries(ctx context.Context, userID string, limit int) ([]Delivery, error)
+	// Start sends due deliveries until ctx is cancelled.
+	Start(ctx context.Context)
+	// Close waits for the sender to stop.
+	Close()
+}
+
+type service struct {
+	repo    Repo
+	users   user.Repo
+	senders map[Channel]Sender
+	cfg     Config
+	log     *logger.Logger
+
+	// wake nudges the sender when a notification is queued, so it needn't wait for the next poll.
+	wake    chan struct{}
+	running sync.WaitGroup
+}
+
+// NewService creates a new notification service instance. Email addresses are looked
+// up in users; a channel without a sender in senders is never delivered to.
+func NewService(repo Repo, users user.Repo, senders map[Channel]Sender, cfg Config, log *logger.Logger) Service {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DefaultRetryBackoff
+	}
+	return &service{repo: repo, users: users, senders: senders, cfg: cfg, log: log, wake: make(chan struct{}, 1)}
+}
+
+func (s *service) Notify(ctx context.Context, userID, kind, link string, data any) error {
+	prefs, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	n := &Notification{UserID: userID, Kind: kind, Link: link, InApp: prefs.InApp, CreatedAt: now}
+	if err := render(n, data); err != nil {
+		return err
+	}
+
+	due := quietUntil(*prefs, now)
+	var deliveries []Delivery
+	for _, ch := range s.channels(prefs) {
+		deliveries = append(deliveries, Delivery{
+			UserID:        userID,
+			Channel:       ch,
+			Status:        DeliveryPending,
+			NextAttemptAt: due,
+		})
+	}
+	if !n.InApp && len(deliveries) == 0 {
+		return nil
+	}
+	if err := s.repo.Create(ctx, n, deliveries); err != nil {
+		return err
+	}
+	if len(deliveries) > 0 && !due.After(now) {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// channels returns the outside channels a user wants and the server can deliver to.
+func (s *service) channels(p *Preferences) []Channel {
+	var chs []Channel
+	if p.Email && s.senders[ChannelEmail] != nil {
+		chs = append(chs, ChannelEmail)
+	}
+	if p.Webhook && p.WebhookURL != "" && s.senders[ChannelWebhook] != nil {
+		chs = append(chs, ChannelWebhook)
+	}
+	return chs
+}
+
+func (s *service) Inbox(ctx context.Context, userID string, unreadOnly bool, limit int) ([]Notification, error) {
+	return s.repo.Inbox(ctx, userID, unreadOnly, clampLimit(limit))
+}
+
+func (s *service) UnreadCount(ctx context.Context, userID string) (int, error) {
+	n, err := s.repo.UnreadCount(ctx, userID)
+	return int(n), err
+}
+
+func (s *service) MarkRead(ctx context.Context, userID string, ids []string) (int, error) {
+	if ids != nil && len(ids) == 0 {
+		return 0, nil
+	}
+	n, err := s.repo.MarkRead(ctx, userID, ids, time.Now())
+	return int(n), err
+}
+
+func (s *service) Preferences(ctx context.Context, userID string) (*Preferences, error) {
+	p, err := s.repo.GetPreferences(ctx, userID)
+	if err != nil || p != nil {
+		return p, err
+	}
+	defaults := DefaultPreferences(userID)
+	return &defaults, nil
+}
+
+func (s *service) UpdatePreferences(ctx context.Context, userID string, in PreferencesUpdate) (*Preferences, error) {
+	p, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	set := func(dst *string, src *string) {
+		if src != nil {
+			*dst = strings.TrimSpace(*src)
+		}
+	}
+	if in.InApp != nil {
+		p.InApp = *in.InApp
+	}
+	if in.Email != nil {
+		p.Email = *in.Email
+	}
+	if in.Webhook != nil {
+		p.Webhook = *in.Webhook
+	}
+	set(&p.WebhookURL, in.WebhookURL)
+	set(&p.WebhookSecret, in.WebhookSecret)
+	set(&p.QuietStart, in.QuietStart)
+	set(&p.QuietEnd, in.QuietEnd)
+	set(&p.TimeZone, in.TimeZone)
+	if p.TimeZone == "" {
+		p.TimeZone = "UTC"
+	}
+
+	if p.WebhookURL != "" {
+		u, err := url.Parse(p.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+			return nil, ErrInvalidWebhookURL
+		}
+		// Resolved only when it changes, so DNS trouble can't block other changes.
+		// Deliveries check the address again as they connect.
+		if in.WebhookURL != nil {
+			if err := checkWebhookHost(ctx, u.Hostname()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p.Webhook && (p.WebhookURL == "" || p.WebhookSecret == "") {
+		return nil, ErrMissingWebhookSecret
+	}
+	if (p.QuietStart == "") != (p.QuietEnd == "") {
+		return nil, ErrInvalidQuietHours
+	}
+	if p.QuietStart != "" {
+		if _, err := parseClock(p.QuietStart); err != nil {
+			return nil, err
+		}
+		if _, err := parseClock(p.QuietEnd); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := time.LoadLocation(p.TimeZone); err != nil {
+		return nil, fmt.Errorf("%w %q", ErrInvalidTimeZone, p.TimeZone)
+	}
+
+	if err := s.repo.SavePreferences(ctx, p); err != nil {
+		return nil, err
+	}
+	s.log.Info("notification preferences updated", logger.Str("user", userID))
+	return p, nil
+}
+
+func (s *service) Deliveries(ctx context.Context, userID string, limit int) ([]Delivery, error) {
+	return s.repo.Deliveries(ctx, userID, clampLimit(limit))
+}
+
+func (s *service) Start(ctx context.Context) {
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		ticker := time.NewTicker(s.cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			s.sendDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+func (s *service) Close() {
+	s.running.Wait()
+}
+
+// sendDue sends claimed batches of due deliveries until none are left.
+func (s *service) sendDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now()
+		deliveries, err := s.repo.ClaimDue(ctx, now, now.Add(sendLease), sendBatch)
+		if err != nil {
+			s.log.Warn("Failed to claim notification deliveries", logger.Err(err))
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+		if err := s.sendBatch(ctx, deliveries); err != nil {
+			s.log.Warn("Failed to send notification deliveries", logger.Err(err))
+			return
+		}
+		if len(deliveries) < sendBatch {
+			return
+		}
+	}
+}
+
+// sendBatch attempts claimed deliveries and records each outcome. A delivery whose
+// attempt is cut short by shutdown is left for its lease to run out.
+func (s *service) sendBatch(ctx context.Context, deliveries []Delivery) error {
+	ids := make([]string, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.NotificationID)
+	}
+	ns, err := s.repo.GetNotifications(ctx, ids)
+	if err != nil {
+		return err
+	}
+	notifications := make(map[string]*Notification, len(ns))
+	for i := range ns {
+		notifications[ns[i].ID] = &ns[i]
+	}
+	recipients := make(map[string]*recipient)
+
+	for i := range deliveries {
+		d := &deliveries[i]
+		r, ok := recipients[d.UserID]
+		if !ok {
+			if r, err = s.recipient(ctx, d.UserID); err != nil {
+				return err
+			}
+			recipients[d.UserID] = r
+		}
+		n := notifications[d.NotificationID]
+		if n == nil {
+			s.finish(ctx, d, errors.New("notification no longer exists"), true)
+			continue
+		}
+		s.attempt(ctx, d, n, r)
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// recipient is a user's preferences with their address.
+type recipient struct {
+	prefs *Preferences
+	to    Recipient
+}
+
+func (s *service) recipient(ctx context.Context, userID string) (*recipient, error) {
+	prefs, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	r := &recipient{prefs: prefs, to: Recipient{WebhookURL: prefs.WebhookURL, WebhookSecret: prefs.WebhookSecret}}
+	// Without the user, email attempts fail with ErrNoEmail and run out like any other failure.
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		s.log.Warn("Failed to look up notification recipient", logger.Str("user", userID), logger.Err(err))
+		return r, nil
+	}
+	r.to.Name, r.to.Email = u.Name, u.Email
+	return r, nil
+}
+
+// attempt sends one delivery as the user's preferences now stand: a channel turned off
+// since the delivery was queued fails it, and quiet hours begun since put it off again.
+func (s *service) attempt(ctx context.Context, d *Delivery, n *Notification, r *recipient) {
+	now := time.Now()
+	wanted := false
+	for _, ch := range s.channels(r.prefs) {
+		wanted = wanted || ch == d.Channel
+	}
+	if !wanted {
+		s.finish(ctx, d, errors.New("channel turned off"), true)
+		return
+	}
+	if until := quietUntil(*r.prefs, now); until.After(now) {
+		// Waiting out quiet hours doesn't count as an attempt.
+		d.Attempts--
+		d.NextAttemptAt = until
+		if err := s.repo.SaveDelivery(ctx, d); err != nil {
+			s.log.Warn("Failed to save notification delivery", logger.Str("delivery", d.ID), logger.Err(err))
+		}
+		return
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	err := s.senders[d.Channel].Send(sendCtx, r.to, n)
+	cancel()
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	s.finish(ctx, d, err, false)
+}
+
+// finish records the outcome of an attempt: sent, failed for good once attempts run
+// out (or when final), or pending again after a backoff that doubles every attempt.
+func (s *service) finish(ctx context.Context, d *Delivery, sendErr error, final bool) {
+	now := time.Now()
+	switch {
+	case sendErr == nil:
+		d.Status = DeliverySent
+		d.SentAt = &now
+		d.LastError = ""
+	case final || d.Attempts >= s.cfg.MaxAttempts:
+		d.Status = DeliveryFailed
+		d.LastError = strutil.Truncate(sendErr.Error(), 1024)
+		s.log.Warn("Notification delivery failed",
+			logger.Str("delivery", d.ID),
+			logger.Str("channel", string(d.Channel)),
+			logger.Int("attempts", d.Attempts),
+			logger.Err(sendErr),
+		)
+	default:
+		d.LastError = strutil.Truncate(sendErr.Error(), 1024)
+		d.NextAttemptAt = now.Add(s.cfg.RetryBackoff << (d.Attempts - 1))
+	}
+	if err := s.repo.SaveDelivery(ctx, d); err != nil {
+		s.log.Warn("Failed to save notification delivery", logger.Str("delivery", d.ID), logger.Err(err))
+	}
+}
+
+func clampLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return DefaultInboxLimit
+	case limit > MaxInboxLimit:
+		return MaxInboxLimit
+	}
+	return limit
+}
@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"never-price-match-server/internal/infra/strutil"
+)
+
+// Kinds of notification, each with its own template.
+const (
+	KindWatchAlert      = "watch_alert"
+	KindPriceProtection = "price_protection"
+)
+
+// WatchAlert is the data of a KindWatchAlert notification.
+type WatchAlert struct {
+	ProductName string
+	Platform    string
+	Price       float64
+	// MatchPrice is what the watch's price-match retailer would charge, when it has one.
+	MatchPrice   *float64
+	PriceMatchAt string
+	TargetPrice  float64
+}
+
+// PriceProtection is the data of a KindPriceProtection notification.
+type PriceProtection struct {
+	ProductName string
+	Retailer    string
+	PricePaid   float64
+	NewPrice    float64
+	Refund      float64
+	ClaimBy     time.Time
+}
+
+// messageTemplate renders the title and body of one kind of notification.
+type messageTemplate struct {
+	title, body *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"price": func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+	"date":  func(t time.Time) string { return t.Format("Mon 2 Jan 2006") },
+	"deref": func(v *float64) float64 { return *v },
+}
+
+func newTemplate(kind, title, body string) messageTemplate {
+	return messageTemplate{
+		title: template.Must(template.New(kind + ".title").Funcs(templateFuncs).Parse(title)),
+		body:  template.Must(template.New(kind + ".body").Funcs(templateFuncs).Parse(body)),
+	}
+}
+
+var templates = map[string]messageTemplate{
+	KindWatchAlert: newTemplate(KindWatchAlert,
+		`{{.ProductName}} is down to {{if .MatchPrice}}{{price (deref .MatchPrice)}}{{else}}{{price .Price}}{{end}}`,
+		`{{.ProductName}} is {{price .Price}} at {{.Platform}}`+
+			`{{if .MatchPrice}}, which {{.PriceMatchAt}} would match at {{price (deref .MatchPrice)}}{{end}}. `+
+			`Your target was {{price .TargetPrice}}.`),
+	KindPriceProtection: newTemplate(KindPriceProtection,
+		`Claim {{price .Refund}} back from {{.Retailer}}`,
+		`{{.ProductName}} has dropped to {{price .NewPrice}} at {{.Retailer}}; you paid {{price .PricePaid}}. `+
+			`Claim the {{price .Refund}} difference under their price protection by {{date .ClaimBy}}.`),
+}
+
+// render fills in a notification's title and body from its kind's template.
+func render(n *Notification, data any) error {
+	t, ok := templates[n.Kind]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownKind, n.Kind)
+	}
+	var title, body bytes.Buffer
+	if err := t.title.Execute(&title, data); err != nil {
+		return err
+	}
+	if err := t.body.Execute(&body, data); err != nil {
+		return err
+	}
+	// Product names can be long; the title column holds 255 characters.
+	n.Title, n.Body = strutil.Truncate(title.String(), 255), body.String()
+	return nil
+}
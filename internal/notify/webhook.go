@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"never-price-match-server/internal/infra/netguard"
+)
+
+// Headers of a webhook request. The signature is "sha256=" followed by the hex
+// HMAC-SHA256, keyed by the user's webhook secret, of the timestamp, a dot and the
+// body; receivers should recompute it and reject stale timestamps to stop replays.
+const (
+	SignatureHeader = "X-Price-Match-Signature"
+	TimestampHeader = "X-Price-Match-Timestamp"
+	EventHeader     = "X-Price-Match-Event"
+)
+
+// DefaultWebhookTimeout bounds a webhook request when none is configured.
+const DefaultWebhookTimeout = 10 * time.Second
+
+var (
+	// ErrNoWebhook is returned when posting to a user without a webhook URL.
+	ErrNoWebhook = errors.New("user has no webhook URL")
+	// ErrWebhookNotPublic is returned for a webhook host that is, or resolves to, a
+	// loopback, private or link-local address, which would let webhooks reach into
+	// the server's own network.
+	ErrWebhookNotPublic = errors.New("webhook URL must point to a public address")
+)
+
+// webhookPayload is the JSON body of a webhook request.
+type webhookPayload struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Link      string    `json:"link,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// webhookSender posts notifications as signed JSON.
+type webhookSender struct {
+	client *http.Client
+}
+
+// NewWebhookSender creates a webhook sender whose requests time out after timeout.
+// It only connects to public addresses.
+func NewWebhookSender(timeout time.Duration) Sender {
+	return newWebhookSender(timeout, netguard.PublicAddr)
+}
+
+// newWebhookSender creates a webhook sender that only connects to addresses allowed
+// by allow.
+func newWebhookSender(timeout time.Duration, allow func(netip.Addr) bool) *webhookSender {
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	// The address is checked as it is dialled, after resolution, so a host that
+	// resolved to a public address when it was saved can't be pointed elsewhere later.
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !allow(addrPort.Addr()) {
+				return ErrWebhookNotPublic
+			}
+			return nil
+		},
+	}
+	return &webhookSender{client: &http.Client{
+		Timeout: timeout,
+		// No proxy, since it would be the proxy's address that is checked.
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		// A redirect would resend the payload somewhere the user never configured.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}}
+}
+
+// checkWebhookHost fails with ErrWebhookNotPublic unless every address host resolves
+// to is public.
+func checkWebhookHost(ctx context.Context, host string) error {
+	err := netguard.CheckHost(ctx, host)
+	if errors.Is(err, netguard.ErrNotPublic) {
+		return ErrWebhookNotPublic
+	}
+	return err
+}
+
+func (s *webhookSender) Send(ctx context.Context, to Recipient, n *Notification) error {
+	if to.WebhookURL == "" {
+		return ErrNoWebhook
+	}
+	body, err := json.Marshal(webhookPayload{
+		ID:        n.ID,
+		Kind:      n.Kind,
+		Title:     n.Title,
+		Body:      n.Body,
+		Link:      n.Link,
+		CreatedAt: n.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, to.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "never-price-match-webhook/1")
+	req.Header.Set(EventHeader, n.Kind)
+	req.Header.Set(TimestampHeader, ts)
+	req.Header.Set(SignatureHeader, Sign(to.WebhookSecret, ts, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature header value of a webhook request.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// Computed independently: HMAC-SHA256("whsec", `1700000000.{"id":"n1"}`).
+	want := "sha256=3897673bead02f754ade3825d2e737162d6ba063e2891b9656ccad7d78ad49d0"
+	if got := Sign("whsec", "1700000000", []byte(`{"id":"n1"}`)); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if Sign("other", "1700000000", []byte(`{"id":"n1"}`)) == want {
+		t.Error("signature doesn't depend on the secret")
+	}
+	if Sign("whsec", "1700000001", []byte(`{"id":"n1"}`)) == want {
+		t.Error("signature doesn't depend on the timestamp")
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	s := newWebhookSender(time.Second, func(netip.Addr) bool { return true })
+	n := &Notification{ID: "n1", Kind: "PRICE_DROP", Title: "Price drop", Body: "Now $199", CreatedAt: time.Now()}
+	if err := s.Send(context.Background(), Recipient{WebhookURL: srv.URL, WebhookSecret: "whsec"}, n); err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Get(EventHeader) != "PRICE_DROP" {
+		t.Errorf("event header %q", got.Header.Get(EventHeader))
+	}
+	want := Sign("whsec", got.Header.Get(TimestampHeader), body)
+	if got.Header.Get(SignatureHeader) != want {
+		t.Errorf("signature %q, want %q", got.Header.Get(SignatureHeader), want)
+	}
+}
+
+func TestWebhookRefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback server")
+	}))
+	defer srv.Close()
+
+	err := NewWebhookSender(time.Second).Send(context.Background(), Recipient{WebhookURL: srv.URL}, &Notification{})
+	if !errors.Is(err, ErrWebhookNotPublic) {
+		t.Errorf("Send to %s: %v, want ErrWebhookNotPublic", srv.URL, err)
+	}
+	if err := checkWebhookHost(context.Background(), "127.0.0.1"); !errors.Is(err, ErrWebhookNotPublic) {
+		t.Errorf("checkWebhookHost(127.0.0.1): %v, want ErrWebhookNotPublic", err)
+	}
+}
@@ -0,0 +1,54 @@
+package pricematch
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// measurePattern matches tokens that are quantities rather than model numbers,
+// such as 128GB, 65in, 2000mAh or 4K.
+var measurePattern = regexp.MustCompile(`^\d+(\.\d+)?(gb|tb|mb|mm|cm|m|l|ml|w|kg|g|mah|hz|inch|in|k|v|pc|pcs|pk|pack|x|p)$`)
+
+// modelNumbers returns the model numbers in a product name: tokens of at least four
+// characters mixing letters and digits, such as WH-1000XM5 or MQD83ZA/A. They are
+// upper-cased with separators removed, so "wh-1000xm5" and "WH1000XM5" compare equal.
+func modelNumbers(name string) []string {
+	var models []string
+	for _, field := range strings.FieldsFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(",()[]|", r)
+	}) {
+		token := strings.Trim(field, "-/.:;")
+		if len(token) < 4 || measurePattern.MatchString(strings.ToLower(token)) {
+			continue
+		}
+		var letters, digits bool
+		var b strings.Builder
+		for _, r := range token {
+			switch {
+			case unicode.IsLetter(r):
+				letters = true
+				b.WriteRune(unicode.ToUpper(r))
+			case unicode.IsDigit(r):
+				digits = true
+				b.WriteRune(r)
+			}
+		}
+		if letters && digits && b.Len() >= 4 {
+			models = append(models, b.String())
+		}
+	}
+	return models
+}
+
+// sharesModel reports whether two sets of model numbers have one in common.
+func sharesModel(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
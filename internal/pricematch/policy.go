@@ -0,0 +1,126 @@
+package pricematch
+
+import (
+	"sort"
+	"strings"
+)
+
+// Policy is a retailer's price-match rules.
+type Policy struct {
+	Retailer string
+	// Competitors are the platforms whose prices the retailer matches. Empty means any.
+	Competitors []string
+	// BeatPercent is how far below a matched price the retailer goes; 0 matches it exactly.
+	BeatPercent float64
+	// ExcludeMarketplace refuses offers sold by third-party marketplace sellers.
+	ExcludeMarketplace bool
+	// ExcludeClearance refuses clearance prices.
+	ExcludeClearance bool
+	// RequireInStock refuses offers the competitor has no stock for.
+	RequireInStock bool
+	// RequireIdenticalModel refuses offers for a different model number.
+	RequireIdenticalModel bool
+	// ProtectionDays is how long after a purchase the retailer refunds a drop in its
+	// own price; 0 means it offers no price protection.
+	ProtectionDays int
+}
+
+// defaultPolicies is the central configuration of retailers' price-match rules, keyed
+// by lower-case retailer name. They summarise each retailer's published terms, which
+// change from time to time; to add a retailer or update its rules, edit this map.
+var defaultPolicies = map[string]Policy{
+	"jb hi-fi": {
+		Retailer:              "JB Hi-Fi",
+		Competitors:           []string{"Amazon AU", "Big W", "EB Games", "Harvey Norman", "Officeworks", "The Good Guys"},
+		ExcludeMarketplace:    true,
+		ExcludeClearance:      true,
+		RequireInStock:        true,
+		RequireIdenticalModel: true,
+		ProtectionDays:        14,
+	},
+	"bunnings": {
+		Retailer:              "Bunnings",
+		BeatPercent:           10,
+		ExcludeMarketplace:    true,
+		ExcludeClearance:      true,
+		RequireInStock:        true,
+		RequireIdenticalModel: true,
+	},
+	"officeworks": {
+		Retailer:              "Officeworks",
+		BeatPercent:           5,
+		ExcludeMarketplace:    true,
+		RequireInStock:        true,
+		RequireIdenticalModel: true,
+		ProtectionDays:        14,
+	},
+	"big w": {
+		Retailer:              "Big W",
+		Competitors:           []string{"Amazon AU", "JB Hi-Fi", "EB Games", "Kmart", "Target"},
+		ExcludeMarketplace:    true,
+		ExcludeClearance:      true,
+		RequireInStock:        true,
+		RequireIdenticalModel: true,
+		ProtectionDays:        14,
+	},
+	"eb games": {
+		Retailer:           "EB Games",
+		Competitors:        []string{"Amazon AU", "Big W", "JB Hi-Fi"},
+		ExcludeMarketplace: true,
+		RequireInStock:     true,
+		ProtectionDays:     7,
+	},
+	"bcf": {
+		Retailer:              "BCF",
+		Competitors:           []string{"Anaconda", "Amazon AU"},
+		BeatPercent:           5,
+		ExcludeMarketplace:    true,
+		ExcludeClearance:      true,
+		RequireIdenticalModel: true,
+		ProtectionDays:        7,
+	},
+	"anaconda": {
+		Retailer:              "Anaconda",
+		Competitors:           []string{"BCF", "Amazon AU"},
+		BeatPercent:           5,
+		ExcludeMarketplace:    true,
+		ExcludeClearance:      true,
+		RequireInStock:        true,
+		RequireIdenticalModel: true,
+		ProtectionDays:        7,
+	},
+}
+
+// Policies returns every configured policy, sorted by retailer.
+func Policies() []Policy {
+	policies := make([]Policy, 0, len(defaultPolicies))
+	for _, p := range defaultPolicies {
+		policies = append(policies, p)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Retailer < policies[j].Retailer })
+	return policies
+}
+
+// Lookup returns the policy of a retailer, matched case-insensitively.
+func Lookup(retailer string) (Policy, bool) {
+	p, ok := defaultPolicies[strings.ToLower(strings.TrimSpace(retailer))]
+	return p, ok
+}
+
+// matchesCompetitor reports whether the retailer matches prices from platform.
+func (p Policy) matchesCompetitor(platform string) bool {
+	if len(p.Competitors) == 0 {
+		return true
+	}
+	for _, c := range p.Competitors {
+		if strings.EqualFold(c, platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPrice is the price the retailer charges when matching price.
+func (p Policy) matchPrice(price float64) float64 {
+	return roundCents(price * (1 - p.BeatPercent/100))
+}
@@ -0,0 +1,180 @@
+package pricematch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"never-price-match-server/internal/product"
+)
+
+// ErrUnknownRetailer is returned for a retailer without a price-match policy.
+var ErrUnknownRetailer = errors.New("no price-match policy for retailer")
+
+// Offer is a competitor's listing judged against a retailer's policy.
+type Offer struct {
+	Platform string
+	Product  product.ScrapedProduct
+	Eligible bool
+	// MatchPrice is what the retailer would charge; 0 when the offer isn't eligible.
+	MatchPrice float64
+	// Reasons say why the offer isn't eligible.
+	Reasons []string
+	// Notes are what staff will still check before matching an eligible offer.
+	Notes []string
+}
+
+// Options are the offers a retailer would match for a product search.
+type Options struct {
+	Policy Policy
+	// RetailerPrice is the retailer's own lowest price among the results, if found.
+	RetailerPrice *float64
+	// BestPrice is the lowest match price of the eligible offers, if any.
+	BestPrice *float64
+	// Saving is how much BestPrice is below RetailerPrice, when both are known.
+	Saving *float64
+	// Offers are eligible offers by match price, then the rest by price.
+	Offers []Offer
+}
+
+// Service defines the business logic interface for price matching.
+type Service interface {
+	// Options searches for a product and judges every offer found against the policy
+	// of the retailer, or returns ErrUnknownRetailer.
+	Options(ctx context.Context, productQuery, category, retailer string) (*Options, error)
+}
+
+type service struct {
+	products product.Service
+}
+
+// NewService creates a new price-match service instance. Offers are found through products.
+func NewService(products product.Service) Service {
+	return &service{products: products}
+}
+
+func (s *service) Options(ctx context.Context, productQuery, category, retailer string) (*Options, error) {
+	policy, ok := Lookup(retailer)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownRetailer, retailer)
+	}
+	results, err := s.products.SearchAndScrape(ctx, productQuery, category)
+	if err != nil {
+		return nil, err
+	}
+	return Evaluate(policy, productQuery, results), nil
+}
+
+// Evaluate judges the offers found for productQuery against policy. The retailer's own
+// listings set RetailerPrice; the model numbers in the query, or failing that in the
+// retailer's cheapest listing, are what an identical model must share.
+func Evaluate(policy Policy, productQuery string, results []product.ScrapeResult) *Options {
+	opts := &Options{Policy: policy}
+
+	reference := modelNumbers(productQuery)
+	var own *product.ScrapedProduct
+	for _, res := range results {
+		if !strings.EqualFold(res.Platform, policy.Retailer) {
+			continue
+		}
+		for i, p := range res.Products {
+			if own == nil || p.Price < own.Price {
+				own = &res.Products[i]
+			}
+		}
+	}
+	if own != nil {
+		price := own.Price
+		opts.RetailerPrice = &price
+		if len(reference) == 0 {
+			reference = modelNumbers(own.Name)
+		}
+	}
+
+	for _, res := range results {
+		if strings.EqualFold(res.Platform, policy.Retailer) {
+			continue
+		}
+		for _, p := range res.Products {
+			opts.Offers = append(opts.Offers, evaluateOffer(policy, reference, res.Platform, p))
+		}
+	}
+	sort.SliceStable(opts.Offers, func(i, j int) bool {
+		a, b := opts.Offers[i], opts.Offers[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if a.Eligible {
+			return a.MatchPrice < b.MatchPrice
+		}
+		return a.Product.Price < b.Product.Price
+	})
+
+	if len(opts.Offers) > 0 && opts.Offers[0].Eligible {
+		best := opts.Offers[0].MatchPrice
+		opts.BestPrice = &best
+		if opts.RetailerPrice != nil && *opts.RetailerPrice > best {
+			saving := roundCents(*opts.RetailerPrice - best)
+			opts.Saving = &saving
+		}
+	}
+	return opts
+}
+
+// EvaluateOffer judges a single listing against policy as an offer for productQuery,
+// whose model numbers an identical model must share. The retailer's own listings are
+// eligible at their own price.
+func EvaluateOffer(policy Policy, productQuery, platform string, p product.ScrapedProduct) Offer {
+	if strings.EqualFold(platform, policy.Retailer) {
+		return Offer{Platform: platform, Product: p, Eligible: true, MatchPrice: p.Price}
+	}
+	return evaluateOffer(policy, modelNumbers(productQuery), platform, p)
+}
+
+func evaluateOffer(policy Policy, reference []string, platform string, p product.ScrapedProduct) Offer {
+	offer := Offer{Platform: platform, Product: p}
+	if !policy.matchesCompetitor(platform) {
+		offer.Reasons = append(offer.Reasons, fmt.Sprintf("%s doesn't match %s prices", policy.Retailer, platform))
+	}
+	if policy.ExcludeMarketplace && p.ThirdParty {
+		offer.Reasons = append(offer.Reasons, fmt.Sprintf("sold by marketplace seller %s", p.Seller))
+	}
+	if policy.ExcludeClearance && p.Clearance {
+		offer.Reasons = append(offer.Reasons, "clearance price")
+	}
+	if policy.RequireInStock {
+		switch p.StockStatus {
+		case product.StockOutOfStock:
+			offer.Reasons = append(offer.Reasons, "out of stock")
+		case product.StockUnknown:
+			offer.Notes = append(offer.Notes, "stock wasn't shown; it must be in stock")
+		}
+	}
+	if policy.RequireIdenticalModel {
+		models := modelNumbers(p.Name)
+		switch {
+		case len(reference) == 0 || len(models) == 0:
+			offer.Notes = append(offer.Notes, "it must be the identical model")
+		case !sharesModel(reference, models):
+			offer.Reasons = append(offer.Reasons, fmt.Sprintf("different model (%s)", strings.Join(models, ", ")))
+		}
+	}
+	if p.Stale {
+		offer.Notes = append(offer.Notes, "price may have changed since it was scraped")
+	}
+
+	offer.Eligible = len(offer.Reasons) == 0
+	if offer.Eligible {
+		offer.MatchPrice = policy.matchPrice(p.Price)
+	} else {
+		offer.Notes = nil
+	}
+	return offer
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
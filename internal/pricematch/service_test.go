@@ -0,0 +1,161 @@
+package pricematch
+
+import (
+	"slices"
+	"testing"
+
+	"never-price-match-server/internal/product"
+)
+
+func TestModelNumbers(t *testing.T) {
+	cases := []struct {
+		name string
+		want []string
+	}{
+		{"Sony WH-1000XM5 Wireless Headphones", []string{"WH1000XM5"}},
+		{"Apple iPad 10.9in 64GB (MPQ03X/A)", []string{"MPQ03XA"}},
+		{"Samsung 65in 4K QLED TV QA65Q60C", []string{"QA65Q60C"}},
+		{"Anker PowerCore 20000mAh 2pack", nil},
+		{"wh1000xm5 black", []string{"WH1000XM5"}},
+	}
+	for _, c := range cases {
+		if got := modelNumbers(c.name); !slices.Equal(got, c.want) {
+			t.Errorf("modelNumbers(%q) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestEvaluateOffer(t *testing.T) {
+	strict := Policy{
+		Retailer:              "JB Hi-Fi",
+		Competitors:           []string{"Amazon AU", "Big W"},
+		BeatPercent:           5,
+		ExcludeMarketplace:    true,
+		ExcludeClearance:      true,
+		RequireInStock:        true,
+		RequireIdenticalModel: true,
+	}
+	inStock := func(name string, price float64) product.ScrapedProduct {
+		return product.ScrapedProduct{Name: name, Price: price, StockStatus: product.StockInStock}
+	}
+	cases := []struct {
+		name     string
+		policy   Policy
+		platform string
+		product  product.ScrapedProduct
+		eligible bool
+		price    float64
+		reasons  int
+		notes    int
+	}{
+		{"eligible, beaten by 5%", strict, "Amazon AU", inStock("Sony WH-1000XM5", 399), true, 379.05, 0, 0},
+		{"competitor matched case-insensitively", strict, "big w", inStock("Sony WH-1000XM5", 400), true, 380, 0, 0},
+		{"competitor not matched", strict, "Kmart", inStock("Sony WH-1000XM5", 399), false, 0, 1, 0},
+		{"any competitor", Policy{Retailer: "Officeworks"}, "Kmart", inStock("Sony WH-1000XM5", 399), true, 399, 0, 0},
+		{"marketplace seller", strict, "Amazon AU", product.ScrapedProduct{Name: "Sony WH-1000XM5", Price: 350, StockStatus: product.StockInStock, ThirdParty: true, Seller: "Gadgets4U"}, false, 0, 1, 0},
+		{"marketplace allowed", Policy{Retailer: "EB Games"}, "Amazon AU", product.ScrapedProduct{Name: "Sony WH-1000XM5", Price: 350, ThirdParty: true}, true, 350, 0, 0},
+		{"clearance", strict, "Amazon AU", product.ScrapedProduct{Name: "Sony WH-1000XM5", Price: 299, StockStatus: product.StockInStock, Clearance: true}, false, 0, 1, 0},
+		{"out of stock", strict, "Amazon AU", product.ScrapedProduct{Name: "Sony WH-1000XM5", Price: 299, StockStatus: product.StockOutOfStock}, false, 0, 1, 0},
+		{"stock not shown", strict, "Amazon AU", product.ScrapedProduct{Name: "Sony WH-1000XM5", Price: 300}, true, 285, 0, 1},
+		{"different model", strict, "Amazon AU", inStock("Sony WH-1000XM4", 249), false, 0, 1, 0},
+		{"model not shown", strict, "Amazon AU", inStock("Sony noise cancelling headphones", 300), true, 285, 0, 1},
+		{"stale price", strict, "Amazon AU", product.ScrapedProduct{Name: "Sony WH-1000XM5", Price: 300, StockStatus: product.StockInStock, Stale: true}, true, 285, 0, 1},
+		{"every rule broken", strict, "Kmart", product.ScrapedProduct{Name: "Sony WH-1000XM4", Price: 99, ThirdParty: true, Clearance: true, StockStatus: product.StockOutOfStock, Stale: true}, false, 0, 5, 0},
+		{"retailer's own listing", strict, "JB Hi-Fi", product.ScrapedProduct{Name: "Sony WH-1000XM4", Price: 420, StockStatus: product.StockOutOfStock}, true, 420, 0, 0},
+	}
+	for _, c := range cases {
+		got := EvaluateOffer(c.policy, "sony wh-1000xm5", c.platform, c.product)
+		if got.Eligible != c.eligible || got.MatchPrice != c.price || len(got.Reasons) != c.reasons || len(got.Notes) != c.notes {
+			t.Errorf("%s: eligible %v at %v, reasons %q, notes %q; want %v at %v, %d reasons, %d notes",
+				c.name, got.Eligible, got.MatchPrice, got.Reasons, got.Notes, c.eligible, c.price, c.reasons, c.notes)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	policy := Policy{Retailer: "JB Hi-Fi", ExcludeClearance: true}
+	offers := func(platform string, prices ...float64) product.ScrapeResult {
+		res := product.ScrapeResult{Platform: platform}
+		for _, price := range prices {
+			res.Products = append(res.Products, product.ScrapedProduct{Name: "Sony WH-1000XM5", Price: price})
+		}
+		return res
+	}
+	price := func(v float64) *float64 { return &v }
+	cases := []struct {
+		name      string
+		results   []product.ScrapeResult
+		retailer  *float64
+		best      *float64
+		saving    *float64
+		wantOrder []float64 // offer prices, in order
+	}{
+		{
+			name:      "cheaper elsewhere",
+			results:   []product.ScrapeResult{offers("JB Hi-Fi", 429, 399), offers("Amazon AU", 379), offers("Big W", 389, 359)},
+			retailer:  price(399),
+			best:      price(359),
+			saving:    price(40),
+			wantOrder: []float64{359, 379, 389},
+		},
+		{
+			name:      "retailer already cheapest",
+			results:   []product.ScrapeResult{offers("JB Hi-Fi", 349), offers("Amazon AU", 379)},
+			retailer:  price(349),
+			best:      price(379),
+			wantOrder: []float64{379},
+		},
+		{
+			name:      "same price",
+			results:   []product.ScrapeResult{offers("JB Hi-Fi", 379), offers("Amazon AU", 379)},
+			retailer:  price(379),
+			best:      price(379),
+			wantOrder: []float64{379},
+		},
+		{
+			name: "nothing eligible",
+			results: []product.ScrapeResult{offers("JB Hi-Fi", 399), {Platform: "Amazon AU", Products: []product.ScrapedProduct{
+				{Name: "Sony WH-1000XM5", Price: 299, Clearance: true},
+			}}},
+			retailer:  price(399),
+			wantOrder: []float64{299},
+		},
+		{
+			name:      "retailer not found",
+			results:   []product.ScrapeResult{offers("Amazon AU", 379)},
+			best:      price(379),
+			wantOrder: []float64{379},
+		},
+		{
+			name:    "no results",
+			results: nil,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := Evaluate(policy, "sony wh-1000xm5", c.results)
+			for _, f := range []struct {
+				name      string
+				got, want *float64
+			}{{"RetailerPrice", got.RetailerPrice, c.retailer}, {"BestPrice", got.BestPrice, c.best}, {"Saving", got.Saving, c.saving}} {
+				if (f.got == nil) != (f.want == nil) || f.got != nil && *f.got != *f.want {
+					t.Errorf("%s = %v, want %v", f.name, deref(f.got), deref(f.want))
+				}
+			}
+			var order []float64
+			for _, o := range got.Offers {
+				order = append(order, o.Product.Price)
+			}
+			if !slices.Equal(order, c.wantOrder) {
+				t.Errorf("offers by price %v, want %v", order, c.wantOrder)
+			}
+		})
+	}
+}
+
+func deref(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
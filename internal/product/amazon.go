@@ -1,16 +1,17 @@
 package product
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 )
 
-func scrapeAmazonSearch(searchTerm string) (ScrapeResult, error) {
+func scrapeAmazonSearch(ctx context.Context, b *Browser, searchTerm string) (ScrapeResult, error) {
 	if searchTerm == "" {
 		return ScrapeResult{}, fmt.Errorf("search term cannot be empty")
 	}
 	searchURL := fmt.Sprintf("https://www.amazon.com.au/s?k=%s", url.QueryEscape(searchTerm))
-	return scrapeProducts(scrapeProductParams{
+	return scrapeProducts(ctx, b, scrapeProductParams{
 		SearchTerm:        searchTerm,
 		Platform:          "Amazon AU",
 		SearchURL:         searchURL,
@@ -19,6 +20,17 @@ func scrapeAmazonSearch(searchTerm string) (ScrapeResult, error) {
 		PriceSelectors:    []string{`span.a-price span.a-offscreen`},
 		ImageSelector:     "img.s-image",
 		LinkSelector:      "a",
-		ImageAttr:         "src",
+		ImageAttrs:        defaultImageAttrs,
+		SponsoredSelectors: []string{
+			`.puis-sponsored-label-text`,
+			`.s-sponsored-label-text`,
+			`[data-component-type="sp-sponsored-result"]`,
+		},
+		BlockedResourceTypes: defaultBlockedResourceTypes,
+		BlockedURLPatterns: append([]string{
+			"amazon-adsystem.com",
+			"fls-fe.amazon.com.au",
+			"unagi.amazon.com.au",
+		}, defaultBlockedURLPatterns...),
 	})
 }
@@ -1,16 +1,17 @@
 package product
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 )
 
-func scrapeAnacondaSearch(searchTerm string) (ScrapeResult, error) {
+func scrapeAnacondaSearch(ctx context.Context, b *Browser, searchTerm string) (ScrapeResult, error) {
 	if searchTerm == "" {
 		return ScrapeResult{}, fmt.Errorf("search term cannot be empty")
 	}
 	searchURL := fmt.Sprintf("https://www.anacondastores.com/search?text=%s", url.QueryEscape(searchTerm))
-	return scrapeProducts(scrapeProductParams{
+	return scrapeProducts(ctx, b, scrapeProductParams{
 		SearchTerm:        searchTerm,
 		Platform:          "Anaconda",
 		SearchURL:         searchURL,
@@ -21,8 +22,10 @@ func scrapeAnacondaSearch(searchTerm string) (ScrapeResult, error) {
 			`p.price-regular span.amount`,
 			`p.price-standard span.amount`,
 		},
-		ImageSelector: `img.productdetailimg`,
-		ImageAttr:     "src",
-		LinkSelector:  "a",
+		ImageSelector:        `img.productdetailimg`,
+		ImageAttrs:           defaultImageAttrs,
+		LinkSelector:         "a",
+		BlockedResourceTypes: defaultBlockedResourceTypes,
+		BlockedURLPatterns:   defaultBlockedURLPatterns,
 	})
 }
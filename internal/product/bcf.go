@@ -1,16 +1,17 @@
 package product
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 )
 
-func scrapeBCFSearch(searchTerm string) (ScrapeResult, error) {
+func scrapeBCFSearch(ctx context.Context, b *Browser, searchTerm string) (ScrapeResult, error) {
 	if searchTerm == "" {
 		return ScrapeResult{}, fmt.Errorf("search term cannot be empty")
 	}
 	searchURL := fmt.Sprintf("https://www.bcf.com.au/search?q=%s", url.QueryEscape(searchTerm))
-	return scrapeProducts(scrapeProductParams{
+	return scrapeProducts(ctx, b, scrapeProductParams{
 		SearchTerm:        searchTerm,
 		Platform:          "BCF",
 		SearchURL:         searchURL,
@@ -19,9 +20,11 @@ func scrapeBCFSearch(searchTerm string) (ScrapeResult, error) {
 		PriceSelectors: []string{
 			`span.product-sales-price`,
 		},
-		ImageSelector: `div.product-image img`,
-		ImageAttr:     "src",
-		LinkSelector:  "a",
+		ImageSelector:        `div.product-image img`,
+		ImageAttrs:           defaultImageAttrs,
+		LinkSelector:         "a",
+		BlockedResourceTypes: defaultBlockedResourceTypes,
+		BlockedURLPatterns:   defaultBlockedURLPatterns,
 	})
 }
 
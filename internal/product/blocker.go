@@ -0,0 +1,176 @@
+package product
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// defaultBlockedResourceTypes are resources we never need to read a search page.
+// Images are included because we only keep their URLs, never their bytes.
+var defaultBlockedResourceTypes = []network.ResourceType{
+	network.ResourceTypeImage,
+	network.ResourceTypeMedia,
+	network.ResourceTypeFont,
+	network.ResourceTypePing,
+	network.ResourceTypeTextTrack,
+	network.ResourceTypeCSPViolationReport,
+}
+
+// defaultBlockedURLPatterns are ad, analytics and tracking hosts shared by most retailers.
+// A request is blocked when its URL contains any of these substrings.
+var defaultBlockedURLPatterns = []string{
+	"google-analytics.com",
+	"googletagmanager.com",
+	"googleadservices.com",
+	"doubleclick.net",
+	"connect.facebook.net",
+	"facebook.com/tr",
+	"bat.bing.com",
+	"hotjar.com",
+	"clarity.ms",
+	"tiktok.com/i18n/pixel",
+	"analytics.tiktok.com",
+	"criteo.com",
+	"criteo.net",
+	"taboola.com",
+	"newrelic.com",
+	"nr-data.net",
+	"quantummetric.com",
+	"segment.io",
+	"cdn.segment.com",
+}
+
+// estimatedResourceBytes is a rough average transfer size per resource type.
+// Blocked requests are never downloaded, so their real size is unknown;
+// these figures are only used to report an approximate saving.
+var estimatedResourceBytes = map[network.ResourceType]int64{
+	network.ResourceTypeImage:      40 * 1024,
+	network.ResourceTypeMedia:      500 * 1024,
+	network.ResourceTypeFont:       30 * 1024,
+	network.ResourceTypeScript:     60 * 1024,
+	network.ResourceTypeStylesheet: 20 * 1024,
+	network.ResourceTypeXHR:        5 * 1024,
+	network.ResourceTypeFetch:      5 * 1024,
+}
+
+// defaultEstimatedBytes is used for resource types missing from estimatedResourceBytes.
+const defaultEstimatedBytes = 2 * 1024
+
+// requestBlocker intercepts browser requests and fails the ones matching
+// the platform's blocked resource types or URL patterns.
+type requestBlocker struct {
+	resourceTypes map[network.ResourceType]bool
+	urlPatterns   []string
+	// checkHost, when set, must accept the host of every request, the page and its
+	// redirects included. Its answers are kept per host for the blocker's tab.
+	checkHost func(ctx context.Context, host string) error
+	hosts     sync.Map // host -> bool
+
+	blocked atomic.Int64
+	// estimatedBytesSaved adds up estimatedResourceBytes for the blocked requests; it
+	// is an estimate, not a measurement.
+	estimatedBytesSaved atomic.Int64
+}
+
+func newRequestBlocker(resourceTypes []network.ResourceType, urlPatterns []string) *requestBlocker {
+	b := &requestBlocker{
+		resourceTypes: make(map[network.ResourceType]bool, len(resourceTypes)),
+		urlPatterns:   urlPatterns,
+	}
+	for _, t := range resourceTypes {
+		b.resourceTypes[t] = true
+	}
+	return b
+}
+
+// enabled reports whether there is anything to block at all.
+func (b *requestBlocker) enabled() bool {
+	return len(b.resourceTypes) > 0 || len(b.urlPatterns) > 0 || b.checkHost != nil
+}
+
+// hostAllowed reports whether checkHost accepts the host of requestURL. Requests that
+// don't go over the network, such as data: URLs, are always allowed.
+func (b *requestBlocker) hostAllowed(ctx context.Context, requestURL string) bool {
+	if b.checkHost == nil {
+		return true
+	}
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "http", "https", "ws", "wss":
+	default:
+		return true
+	}
+	if ok, seen := b.hosts.Load(u.Hostname()); seen {
+		return ok.(bool)
+	}
+	ok := b.checkHost(ctx, u.Hostname()) == nil
+	b.hosts.Store(u.Hostname(), ok)
+	return ok
+}
+
+func (b *requestBlocker) shouldBlock(resourceType network.ResourceType, requestURL string) bool {
+	// Never block the page itself, even if it happens to match a pattern.
+	if resourceType == network.ResourceTypeDocument {
+		return false
+	}
+	if b.resourceTypes[resourceType] {
+		return true
+	}
+	lowerURL := strings.ToLower(requestURL)
+	for _, pattern := range b.urlPatterns {
+		if strings.Contains(lowerURL, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// attach enables request interception on the tab behind ctx.
+// It must be called before navigating.
+func (b *requestBlocker) attach(ctx context.Context) error {
+	if !b.enabled() {
+		return nil
+	}
+
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		e, ok := ev.(*fetch.EventRequestPaused)
+		if !ok {
+			return
+		}
+		// Event handlers must not block, so reply to the browser from a goroutine.
+		go func() {
+			execCtx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+			if b.shouldBlock(e.ResourceType, e.Request.URL) {
+				b.blocked.Add(1)
+				b.estimatedBytesSaved.Add(estimateResourceBytes(e.ResourceType))
+				_ = fetch.FailRequest(e.RequestID, network.ErrorReasonBlockedByClient).Do(execCtx)
+				return
+			}
+			if !b.hostAllowed(ctx, e.Request.URL) {
+				_ = fetch.FailRequest(e.RequestID, network.ErrorReasonAddressUnreachable).Do(execCtx)
+				return
+			}
+			_ = fetch.ContinueRequest(e.RequestID).Do(execCtx)
+		}()
+	})
+
+	return chromedp.Run(ctx, fetch.Enable())
+}
+
+func estimateResourceBytes(resourceType network.ResourceType) int64 {
+	if n, ok := estimatedResourceBytes[resourceType]; ok {
+		return n
+	}
+	return defaultEstimatedBytes
+}
@@ -0,0 +1,112 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"never-price-match-server/internal/infra/logger"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ErrBrowserClosed is returned for scrapes started after the browser was closed.
+var ErrBrowserClosed = errors.New("browser closed")
+
+// browserOptions are the Chrome flags every scrape runs with.
+var browserOptions = append(chromedp.DefaultExecAllocatorOptions[:],
+	chromedp.Flag("headless", true),
+	chromedp.Flag("disable-gpu", true),
+	chromedp.Flag("disable-extensions", true),
+	chromedp.Flag("disable-features", "Translate"),
+	chromedp.Flag("no-first-run", true),
+	chromedp.Flag("no-default-browser-check", true),
+	chromedp.Flag("disable-blink-features", "AutomationControlled"),
+	chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/108.0.0.0 Safari/537.36"),
+)
+
+// Browser is a pool of tabs in one shared headless Chrome. Chrome is started on
+// the first scrape and restarted if it dies; at most maxTabs scrapes run at once.
+type Browser struct {
+	log  *logger.Logger
+	tabs chan struct{}
+
+	mu            sync.Mutex
+	closed        bool
+	cancelAlloc   context.CancelFunc
+	browserCtx    context.Context
+	cancelBrowser context.CancelFunc
+}
+
+// NewBrowser creates a browser pool allowing maxTabs concurrent scrapes (at least one).
+func NewBrowser(maxTabs int, log *logger.Logger) *Browser {
+	if maxTabs < 1 {
+		maxTabs = 1
+	}
+	return &Browser{log: log, tabs: make(chan struct{}, maxTabs)}
+}
+
+// newTab waits for a free slot and opens a tab. The returned cancel closes the tab
+// and frees the slot.
+func (b *Browser) newTab(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	select {
+	case b.tabs <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	browserCtx, err := b.browser()
+	if err != nil {
+		<-b.tabs
+		return nil, nil, err
+	}
+	tabCtx, cancelTab := chromedp.NewContext(browserCtx)
+	// Stop the tab early if the caller gives up, not only when it calls cancel.
+	stop := context.AfterFunc(ctx, cancelTab)
+	return tabCtx, func() {
+		stop()
+		cancelTab()
+		<-b.tabs
+	}, nil
+}
+
+// browser returns the context of the running Chrome, starting it if needed.
+func (b *Browser) browser() (context.Context, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrBrowserClosed
+	}
+	if b.browserCtx != nil && b.browserCtx.Err() == nil {
+		return b.browserCtx, nil
+	}
+
+	b.shutdownLocked()
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), browserOptions...)
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	// Running with no actions starts Chrome, so a launch failure is reported here.
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancelBrowser()
+		cancelAlloc()
+		return nil, err
+	}
+	b.cancelAlloc, b.browserCtx, b.cancelBrowser = cancelAlloc, browserCtx, cancelBrowser
+	b.log.Info("Browser started", logger.Int("max_tabs", cap(b.tabs)))
+	return browserCtx, nil
+}
+
+// Close shuts Chrome down. Scrapes in progress fail and later ones return ErrBrowserClosed.
+func (b *Browser) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.shutdownLocked()
+}
+
+func (b *Browser) shutdownLocked() {
+	if b.cancelBrowser != nil {
+		b.cancelBrowser()
+		b.cancelAlloc()
+	}
+	b.cancelAlloc, b.browserCtx, b.cancelBrowser = nil, nil, nil
+}
@@ -0,0 +1,135 @@
+package product
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"never-price-match-server/internal/infra/metrics"
+)
+
+// ResultCache holds the stored listings found for recent searches, keyed by normalised
+// query, so repeated searches don't go back to the search index. The in-process LRU is
+// the only implementation; an external cache can be plugged in by implementing it.
+type ResultCache interface {
+	// Get returns the listings cached for key, if any.
+	Get(key string) ([]Product, bool)
+	// Generation counts the purges so far. It is read before looking up the listings
+	// to cache, so that Set can tell they may predate a purge.
+	Generation() uint64
+	// Set caches the listings found for key, unless the cache was purged since
+	// generation, in which case they may be missing newly saved listings.
+	Set(key string, generation uint64, products []Product)
+	// Purge drops every entry. Newly saved listings can match any cached query.
+	Purge()
+	// Stats returns the cache's counters. The app publishes them on /debug/vars.
+	Stats() CacheStats
+}
+
+// CacheStats are a result cache's counters since it was created.
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Entries   int
+}
+
+// cacheKey is the cache key of a search: its normalised term. The category isn't part
+// of it, since the search index doesn't look at the category either.
+func cacheKey(productName string) string {
+	return strings.Join(SearchTokens(productName), " ")
+}
+
+type lruEntry struct {
+	key       string
+	products  []Product
+	expiresAt time.Time
+}
+
+// lruCache is a size-bounded LRU cache whose entries also expire after a TTL.
+type lruCache struct {
+	size int
+	ttl  time.Duration
+
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List // most recently used first
+	generation uint64
+	stats      CacheStats
+}
+
+// NewLRUCache creates an in-process result cache of at most size queries, each kept
+// for at most ttl. A size below one disables caching.
+func NewLRUCache(size int, ttl time.Duration) ResultCache {
+	return &lruCache{size: size, ttl: ttl, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+func (c *lruCache) Get(key string) ([]Product, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if ok && time.Now().After(el.Value.(*lruEntry).expiresAt) {
+		c.removeLocked(el)
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		metrics.Add("result_cache", "misses", 1)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	c.stats.Hits++
+	metrics.Add("result_cache", "hits", 1)
+	return el.Value.(*lruEntry).products, true
+}
+
+func (c *lruCache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+func (c *lruCache) Set(key string, generation uint64, products []Product) {
+	if c.size < 1 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	entry := &lruEntry{key: key, products: products, expiresAt: time.Now().Add(c.ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		c.removeLocked(c.order.Back())
+		c.stats.Evictions++
+		metrics.Add("result_cache", "evictions", 1)
+	}
+}
+
+func (c *lruCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.generation++
+}
+
+func (c *lruCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
+
+func (c *lruCache) removeLocked(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*lruEntry).key)
+}
@@ -0,0 +1,166 @@
+package product
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// linkRule describes how to reduce a platform's product URLs to a stable form.
+type linkRule struct {
+	// redirectPaths are path prefixes of redirect wrappers (e.g. sponsored clicks)
+	// whose real destination is carried in a query parameter.
+	redirectPaths []string
+	// keepParams is an allowlist of query parameters that identify the product, such as
+	// the colour or size of a variant; entries ending in "_" match any parameter with
+	// that prefix. nil keeps everything except trackingParams; an empty slice drops all parameters.
+	keepParams []string
+	// rewrite applies platform-specific path normalisation after cleaning.
+	rewrite func(u *url.URL)
+}
+
+// redirectTargetParams are the query parameters redirect wrappers use for their destination.
+var redirectTargetParams = []string{"url", "u", "redirect", "redirectUrl", "redirect_url", "target", "dest"}
+
+// trackingParams are stripped from every URL regardless of platform.
+// Entries ending in "_" match any parameter with that prefix.
+var trackingParams = []string{
+	"utm_", "ref", "ref_", "tag", "gclid", "gclsrc", "dclid", "fbclid", "msclkid", "srsltid",
+	"sessionid", "jsessionid", "sid", "cid", "_ga", "mc_cid", "mc_eid", "irclickid",
+	"qid", "sr", "keywords", "crid", "sprefix", "dib", "dib_tag", "_encoding", "psc",
+	"pd_rd_", "pf_rd_", "content-id", "spla", "sp_csd", "smid", "th",
+}
+
+// amazonASIN matches the product ID in Amazon's /dp/ and /gp/product/ paths.
+var amazonASIN = regexp.MustCompile(`/(?:dp|gp/product|gp/aw/d)/([A-Z0-9]{10})(?:[/?]|$)`)
+
+// platformLinkRules holds the canonicalisation rules per platform.
+// Platforms without an entry only get the generic tracking-parameter cleanup.
+var platformLinkRules = map[string]linkRule{
+	"Amazon AU": {
+		redirectPaths: []string{"/sspa/click", "/gp/slredirect", "/gp/r.html"},
+		keepParams:    []string{},
+		rewrite: func(u *url.URL) {
+			// Every Amazon product URL collapses to /dp/<ASIN>; the slug and ref segments vary.
+			if m := amazonASIN.FindStringSubmatch(u.Path); m != nil {
+				u.Path = "/dp/" + m[1]
+			}
+		},
+	},
+	// Shopify-style stores select a variant (colour, size, edition) with ?variant=<id>.
+	"JB Hi-Fi": {keepParams: []string{"variant"}},
+	"EB Games": {keepParams: []string{"variant"}},
+	// Big W gives variants their own /p/<code> paths, but colour and size pickers add
+	// the chosen code as a parameter on some pages.
+	"Big W": {keepParams: []string{"variant", "sku"}},
+	// Salesforce Commerce Cloud stores carry variant attributes as
+	// dwvar_<product>_<attribute> (e.g. dwvar_123_color=BLK) and the variant as pid.
+	"BCF":      {keepParams: []string{"dwvar_", "pid"}},
+	"Anaconda": {keepParams: []string{"dwvar_", "pid"}},
+}
+
+// CanonicalLink returns a stable product URL for the given platform: redirect
+// wrappers are unwrapped, tracking and session parameters removed, and the
+// host and path normalised. Unparseable links are returned unchanged.
+func CanonicalLink(platform, rawLink string) string {
+	u, err := url.Parse(strings.TrimSpace(rawLink))
+	if err != nil || u.Host == "" {
+		return rawLink
+	}
+	rule := platformLinkRules[platform]
+
+	u = unwrapRedirect(u, rule.redirectPaths)
+
+	u.Scheme = "https"
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.User = nil
+
+	// Drop ";jsessionid=..." style path parameters and Amazon's "/ref=..." trailing segment.
+	if i := strings.IndexByte(u.Path, ';'); i >= 0 {
+		u.Path = u.Path[:i]
+	}
+	if i := strings.Index(u.Path, "/ref="); i >= 0 {
+		u.Path = u.Path[:i]
+	}
+	u.RawPath = ""
+
+	u.RawQuery = cleanQuery(u.Query(), rule.keepParams).Encode()
+
+	if rule.rewrite != nil {
+		rule.rewrite(u)
+	}
+	if len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+	}
+	return u.String()
+}
+
+// unwrapRedirect follows redirect wrappers such as Amazon's sponsored
+// /sspa/click links to the URL they point at, resolved against the wrapper.
+func unwrapRedirect(u *url.URL, redirectPaths []string) *url.URL {
+	for depth := 0; depth < 3; depth++ {
+		if !hasAnyPrefix(u.Path, redirectPaths) {
+			return u
+		}
+		q := u.Query()
+		var target *url.URL
+		for _, p := range redirectTargetParams {
+			if v := q.Get(p); v != "" {
+				if t, err := url.Parse(v); err == nil {
+					target = t
+					break
+				}
+			}
+		}
+		if target == nil {
+			return u
+		}
+		u = u.ResolveReference(target)
+	}
+	return u
+}
+
+// cleanQuery applies the allowlist if there is one, then removes tracking parameters.
+func cleanQuery(q url.Values, keep []string) url.Values {
+	if keep != nil {
+		kept := url.Values{}
+		for k, v := range q {
+			if matchesParam(k, keep) {
+				kept[k] = v
+			}
+		}
+		return kept
+	}
+	for k := range q {
+		if matchesParam(k, trackingParams) {
+			q.Del(k)
+		}
+	}
+	return q
+}
+
+// matchesParam reports whether a query parameter is one of patterns, case-insensitively;
+// patterns ending in "_" match any parameter with that prefix.
+func matchesParam(name string, patterns []string) bool {
+	lower := strings.ToLower(name)
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "_") && strings.HasPrefix(lower, p) {
+			return true
+		}
+		if lower == p {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"never-price-match-server/internal/infra/netguard"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// captureTimeout bounds loading and screenshotting one product page.
+	captureTimeout = 45 * time.Second
+	// screenshotQuality is the JPEG quality of full-page screenshots; PNGs of long
+	// product pages run to several megabytes.
+	screenshotQuality = 80
+)
+
+// captureBlockedResourceTypes are failed while capturing a page. Unlike a scrape,
+// images and fonts are kept so the screenshot looks like what a shopper sees.
+var captureBlockedResourceTypes = []network.ResourceType{
+	network.ResourceTypeMedia,
+	network.ResourceTypePing,
+	network.ResourceTypeCSPViolationReport,
+}
+
+// PageCapture is a product page as it was at CapturedAt.
+type PageCapture struct {
+	URL        string
+	CapturedAt time.Time
+	// Title is the product name the page shows, if it could be read.
+	Title string
+	// Price is the offer price from the page's structured data; 0 when it has none.
+	Price float64
+	// StockStatus is read from the structured data, falling back to the page text.
+	StockStatus StockStatus
+	// Screenshot is a full-page JPEG.
+	Screenshot []byte
+}
+
+// errCaptureLink is returned for a link that isn't a web page on a public host.
+var errCaptureLink = errors.New("not a public web page")
+
+// Capture opens a product page in a tab of the browser, reads its price and stock
+// status and takes a full-page screenshot. Stored links come from scraped pages, so
+// the page and everything it loads must be on public hosts: a crafted link can't
+// point the browser into the server's own network.
+func (b *Browser) Capture(ctx context.Context, link string) (*PageCapture, error) {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return nil, fmt.Errorf("capture %s: %w", link, errCaptureLink)
+	}
+	if err := netguard.CheckHost(ctx, u.Hostname()); err != nil {
+		return nil, fmt.Errorf("capture %s: %w", link, err)
+	}
+
+	tabCtx, cancelTab, err := b.newTab(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancelTab()
+
+	blocker := newRequestBlocker(captureBlockedResourceTypes, defaultBlockedURLPatterns)
+	blocker.checkHost = netguard.CheckHost
+	if err := blocker.attach(tabCtx); err != nil {
+		return nil, fmt.Errorf("could not enable request blocking: %w", err)
+	}
+
+	loadCtx, cancelLoad := context.WithTimeout(tabCtx, captureTimeout)
+	defer cancelLoad()
+
+	var title, bodyText string
+	var structured []string
+	capture := &PageCapture{URL: link}
+	err = chromedp.Run(loadCtx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument("Object.defineProperty(navigator, 'webdriver', {get: () => undefined})").Do(ctx)
+			return err
+		}),
+		chromedp.Navigate(link),
+		chromedp.Sleep(3*time.Second),
+		chromedp.Title(&title),
+		chromedp.Evaluate(`Array.from(document.querySelectorAll('script[type="application/ld+json"]')).map(s => s.textContent)`, &structured),
+		chromedp.Evaluate(`document.body ? document.body.innerText : ""`, &bodyText),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			capture.CapturedAt = time.Now()
+			return nil
+		}),
+		chromedp.FullScreenshot(&capture.Screenshot, screenshotQuality),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("capture %s: %w", link, err)
+	}
+
+	offer := readStructuredOffer(structured)
+	capture.Title = offer.name
+	if capture.Title == "" {
+		capture.Title = strings.TrimSpace(title)
+	}
+	capture.Price = offer.price
+	capture.StockStatus = offer.stock
+	if capture.StockStatus == StockUnknown {
+		capture.StockStatus = parseStockText(bodyText)
+	}
+	return capture, nil
+}
+
+// PrintPDF renders an HTML document to an A4 PDF in a tab of the browser.
+func (b *Browser) PrintPDF(ctx context.Context, html []byte) ([]byte, error) {
+	tabCtx, cancelTab, err := b.newTab(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancelTab()
+
+	printCtx, cancelPrint := context.WithTimeout(tabCtx, captureTimeout)
+	defer cancelPrint()
+
+	var pdf []byte
+	err = chromedp.Run(printCtx,
+		chromedp.Navigate("about:blank"),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			tree, err := page.GetFrameTree().Do(ctx)
+			if err != nil {
+				return err
+			}
+			return page.SetDocumentContent(tree.Frame.ID, string(html)).Do(ctx)
+		}),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			pdf, _, err = page.PrintToPDF().
+				WithPrintBackground(true).
+				WithPaperWidth(8.27).
+				WithPaperHeight(11.69).
+				Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("print pdf: %w", err)
+	}
+	return pdf, nil
+}
+
+// structuredOffer is what a page's schema.org Product data says about it.
+type structuredOffer struct {
+	name  string
+	price float64
+	stock StockStatus
+}
+
+// readStructuredOffer reads the first schema.org Product with an offer price from the
+// page's JSON-LD blocks. Blocks that don't parse are skipped.
+func readStructuredOffer(blocks []string) structuredOffer {
+	for _, block := range blocks {
+		var doc any
+		if err := json.Unmarshal([]byte(block), &doc); err != nil {
+			continue
+		}
+		if offer, ok := findProductOffer(doc); ok {
+			return offer
+		}
+	}
+	return structuredOffer{}
+}
+
+// findProductOffer searches a JSON-LD value, including @graph lists, for a Product
+// node with a priced offer.
+func findProductOffer(v any) (structuredOffer, bool) {
+	switch node := v.(type) {
+	case []any:
+		for _, item := range node {
+			if offer, ok := findProductOffer(item); ok {
+				return offer, true
+			}
+		}
+	case map[string]any:
+		if isProductNode(node) {
+			offer := structuredOffer{}
+			offer.name, _ = node["name"].(string)
+			offer.price, offer.stock = readOffers(node["offers"])
+			if offer.price > 0 {
+				return offer, true
+			}
+		}
+		if graph, ok := node["@graph"]; ok {
+			return findProductOffer(graph)
+		}
+	}
+	return structuredOffer{}, false
+}
+
+func isProductNode(node map[string]any) bool {
+	switch t := node["@type"].(type) {
+	case string:
+		return strings.EqualFold(t, "Product")
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok && strings.EqualFold(s, "Product") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readOffers returns the lowest price among a Product's offers, with that offer's
+// availability. Offers may be a single Offer, a list or an AggregateOffer.
+func readOffers(v any) (float64, StockStatus) {
+	switch offers := v.(type) {
+	case []any:
+		var best float64
+		var stock StockStatus
+		for _, o := range offers {
+			price, s := readOffers(o)
+			if price > 0 && (best == 0 || price < best) {
+				best, stock = price, s
+			}
+		}
+		return best, stock
+	case map[string]any:
+		price := jsonNumber(offers["price"])
+		if price == 0 {
+			price = jsonNumber(offers["lowPrice"])
+		}
+		availability, _ := offers["availability"].(string)
+		return price, availabilityStatus(availability)
+	}
+	return 0, StockUnknown
+}
+
+// availabilityStatus maps a schema.org ItemAvailability such as
+// "https://schema.org/InStock" to a stock status.
+func availabilityStatus(availability string) StockStatus {
+	value := availability[strings.LastIndex(availability, "/")+1:]
+	switch strings.ToLower(value) {
+	case "instock", "limitedavailability", "instoreonly", "onlineonly":
+		return StockInStock
+	case "outofstock", "soldout", "discontinued":
+		return StockOutOfStock
+	default:
+		return StockUnknown
+	}
+}
+
+// jsonNumber reads a JSON-LD number, which sites write as numbers or strings.
+func jsonNumber(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(n), ",", ""), 64)
+		if err == nil {
+			return f
+		}
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"never-price-match-server/internal/infra/netguard"
+)
+
+func TestCaptureRefusesInternalLinks(t *testing.T) {
+	var b Browser // never started: the links are refused before a tab is opened
+	for link, want := range map[string]error{
+		"http://127.0.0.1:8080/admin":              netguard.ErrNotPublic,
+		"https://localhost/p/1":                    netguard.ErrNotPublic,
+		"http://169.254.169.254/latest/meta-data/": netguard.ErrNotPublic,
+		"file:///etc/passwd":                       errCaptureLink,
+		"javascript:alert(1)":                      errCaptureLink,
+	} {
+		if _, err := b.Capture(context.Background(), link); !errors.Is(err, want) {
+			t.Errorf("Capture(%s): %v, want %v", link, err, want)
+		}
+	}
+}
+
+func TestRequestBlockerHostAllowed(t *testing.T) {
+	checked := 0
+	b := newRequestBlocker(nil, nil)
+	b.checkHost = func(_ context.Context, host string) error {
+		checked++
+		if host == "10.0.0.1" {
+			return netguard.ErrNotPublic
+		}
+		return nil
+	}
+	cases := []struct {
+		url  string
+		want bool
+	}{
+		{"https://www.jbhifi.com.au/products/airpods", true},
+		{"https://www.jbhifi.com.au/cdn/app.js", true}, // answered from the cache
+		{"http://10.0.0.1/internal", false},
+		{"data:image/png;base64,AAAA", true},
+	}
+	for _, c := range cases {
+		if got := b.hostAllowed(context.Background(), c.url); got != c.want {
+			t.Errorf("hostAllowed(%s) = %v, want %v", c.url, got, c.want)
+		}
+	}
+	if checked != 2 {
+		t.Errorf("checked %d hosts, want 2", checked)
+	}
+}
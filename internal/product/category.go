@@ -0,0 +1,46 @@
+package product
+
+// categoryKeywords maps words in a search term to the category they suggest.
+// It is deliberately small; it only needs to tell us which categories people
+// search for without picking one, so we know which retailers to add next.
+var categoryKeywords = map[string][]string{
+	"outdoors": {
+		"tent", "tents", "swag", "sleeping", "camping", "camp", "esky", "cooler", "kayak", "fishing",
+		"rod", "reel", "hiking", "backpack", "gazebo", "lantern", "torch", "headlamp", "stove", "bbq",
+		"jetboil", "oztrail", "coleman", "darche", "shimano", "daiwa",
+	},
+	"electronics": {
+		"airpods", "iphone", "ipad", "macbook", "laptop", "tv", "monitor", "headphones", "earbuds",
+		"speaker", "soundbar", "ps5", "playstation", "xbox", "switch", "nintendo", "camera", "kindle",
+		"galaxy", "pixel", "sony", "samsung", "bose", "jbl", "garmin", "charger", "ssd",
+	},
+}
+
+// keywordCategory is categoryKeywords inverted for lookup.
+var keywordCategory = func() map[string]string {
+	m := make(map[string]string)
+	for category, words := range categoryKeywords {
+		for _, w := range words {
+			m[w] = category
+		}
+	}
+	return m
+}()
+
+// InferCategory guesses the category of a search term from its words.
+// It returns the category with the most matching keywords, or "" if none match.
+func InferCategory(term string) string {
+	votes := make(map[string]int)
+	best, bestVotes := "", 0
+	for _, token := range SearchTokens(term) {
+		category, ok := keywordCategory[token]
+		if !ok {
+			continue
+		}
+		votes[category]++
+		if votes[category] > bestVotes || (votes[category] == bestVotes && category < best) {
+			best, bestVotes = category, votes[category]
+		}
+	}
+	return best
+}
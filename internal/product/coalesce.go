@@ -0,0 +1,70 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// errScrapePanicked is what callers waiting on a scrape get if the scrape panicked.
+var errScrapePanicked = errors.New("scrape panicked")
+
+// scrapeCall is a platform scrape in flight that later callers can wait on.
+type scrapeCall struct {
+	done   chan struct{}
+	result ScrapeResult
+	err    error
+}
+
+// scrapeGroup deduplicates concurrent scrapes of the same platform for the same
+// search, so identical searches arriving together launch one browser tab between them.
+type scrapeGroup struct {
+	mu    sync.Mutex
+	calls map[string]*scrapeCall
+}
+
+// scrapeKey identifies a platform scrape: the normalised term, category and platform.
+func scrapeKey(productName, category, platformName string) string {
+	return strings.Join(SearchTokens(productName), " ") + "\x00" + category + "\x00" + platformName
+}
+
+// do runs scrape for key unless a scrape for key is already running, in which case it
+// waits for that one instead, or until ctx is done. shared reports whether the result
+// came from another caller. Every caller gets its own copy of the products, so they
+// can be modified freely.
+func (g *scrapeGroup) do(ctx context.Context, key string, scrape func() (ScrapeResult, error)) (result ScrapeResult, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*scrapeCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.result.clone(), true, c.err
+		case <-ctx.Done():
+			return ScrapeResult{}, true, ctx.Err()
+		}
+	}
+	// err stays errScrapePanicked for the waiters unless scrape returns.
+	c := &scrapeCall{done: make(chan struct{}), err: errScrapePanicked}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	// Deferred so a panicking scrape still releases the key and its waiters.
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	c.result, c.err = scrape()
+	return c.result.clone(), false, c.err
+}
+
+// clone copies the result with its own products slice.
+func (r ScrapeResult) clone() ScrapeResult {
+	r.Products = append([]ScrapedProduct(nil), r.Products...)
+	return r
+}
@@ -1,12 +1,34 @@
 package product
 
+import "time"
+
 // ScrapedProduct defines the structure for a single scraped product.
 // This is the "information card" for each item we find.
 type ScrapedProduct struct {
-	Name     string  `json:"name"`
-	Price    float64 `json:"price"`
-	ImageURL string  `json:"image_url"`
-	Link     string  `json:"link"`
+	// ListingID is the stored listing's ID; 0 until the product has been saved.
+	ListingID uint    `json:"listing_id"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	ImageURL  string  `json:"image_url"`
+	Link      string  `json:"link"`
+	// Sponsored marks paid placements mixed into the results.
+	Sponsored bool `json:"sponsored"`
+	// Seller is who sells the offer ("sold by"); empty when the tile doesn't say.
+	Seller string `json:"seller"`
+	// FulfilledBy is who ships the offer, when shown separately from the seller.
+	FulfilledBy string `json:"fulfilled_by"`
+	// ThirdParty is true when a marketplace seller, not the platform itself, sells the offer.
+	ThirdParty bool `json:"third_party"`
+	// StockStatus is the availability shown on the tile, if any.
+	StockStatus StockStatus `json:"stock_status"`
+	// Clearance marks clearance prices, which some retailers won't match.
+	Clearance bool `json:"clearance"`
+	// Source says whether the product came from the cache or a live scrape.
+	Source ResultSource `json:"source"`
+	// ScrapedAt is when the price was scraped from the platform.
+	ScrapedAt time.Time `json:"scraped_at"`
+	// Stale is true for cached products older than the freshness policy allows.
+	Stale bool `json:"stale"`
 }
 
 // ScrapeResult holds all the results from a single scraping platform.
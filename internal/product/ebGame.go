@@ -1,24 +1,27 @@
 package product
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 )
 
-func scrapeEBGamesSearch(searchTerm string) (ScrapeResult, error) {
+func scrapeEBGamesSearch(ctx context.Context, b *Browser, searchTerm string) (ScrapeResult, error) {
 	if searchTerm == "" {
 		return ScrapeResult{}, fmt.Errorf("search term cannot be empty")
 	}
 	searchURL := fmt.Sprintf("https://www.ebgames.com.au/search?q=%s", url.QueryEscape(searchTerm))
-	return scrapeProducts(scrapeProductParams{
-		SearchTerm:        searchTerm,
-		Platform:          "EB Games",
-		SearchURL:         searchURL,
-		ContainerSelector: "div.product-tile",
-		TitleSelector:     "div.name",
-		PriceSelectors:    []string{"span.current-price"},
-		ImageSelector:     "img",
-		LinkSelector:      "a",
-		ImageAttr:         "src",
+	return scrapeProducts(ctx, b, scrapeProductParams{
+		SearchTerm:           searchTerm,
+		Platform:             "EB Games",
+		SearchURL:            searchURL,
+		ContainerSelector:    "div.product-tile",
+		TitleSelector:        "div.name",
+		PriceSelectors:       []string{"span.current-price"},
+		ImageSelector:        "img",
+		LinkSelector:         "a",
+		ImageAttrs:           defaultImageAttrs,
+		BlockedResourceTypes: defaultBlockedResourceTypes,
+		BlockedURLPatterns:   defaultBlockedURLPatterns,
 	})
 }
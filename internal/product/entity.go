@@ -1,21 +1,60 @@
 package product
 
-import "time"
+import (
+	"time"
 
-// Product represents a single product item scraped from a platform.
-// Instead of a unique name, each entry is a distinct record of what was found.
+	"github.com/chromedp/cdproto/network"
+)
+
+// Product is a listing: one product on one platform, identified by the platform
+// and its canonical link. Price holds the latest observed price; the full history
+// is kept in PriceObservation rows.
 type Product struct {
-	ID        uint   `gorm:"primarykey"`
-	Name      string `gorm:"index"` // Name is indexed for faster searching but is not unique.
-	Platform  string
-	Price     float64
-	Link      string
-	ImageURL  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID       uint   `gorm:"primarykey"`
+	Name     string `gorm:"type:varchar(255);not null;index"` // Name is indexed for faster searching but is not unique.
+	Platform string `gorm:"type:varchar(64);not null;uniqueIndex:idx_products_platform_link,priority:1"`
+	Price    float64
+	// Link is the canonical product URL. Together with Platform it uniquely identifies the listing.
+	// Its length keeps the composite unique key within MySQL's 3072-byte index limit.
+	Link     string `gorm:"type:varchar(700);not null;uniqueIndex:idx_products_platform_link,priority:2"`
+	ImageURL string `gorm:"type:varchar(1024)"`
+	// Category is the search category the listing was last found under.
+	Category string `gorm:"type:varchar(64);index:idx_products_category"`
+	// Sponsored, Seller, FulfilledBy and ThirdParty describe how the offer is sold.
+	Sponsored   bool
+	Seller      string `gorm:"type:varchar(255)"`
+	FulfilledBy string `gorm:"type:varchar(255)"`
+	ThirdParty  bool
+	// StockStatus and Clearance are as last scraped.
+	StockStatus StockStatus `gorm:"type:varchar(16);not null;default:''"`
+	Clearance   bool        `gorm:"not null;default:false"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// PriceObservation is one price seen for a listing at a point in time.
+// Rows are only ever appended, so they form the listing's price history.
+type PriceObservation struct {
+	ID         uint      `gorm:"primarykey"`
+	ProductID  uint      `gorm:"not null;index:idx_price_observations_product_time,priority:1"`
+	Price      float64   `gorm:"not null"`
+	ObservedAt time.Time `gorm:"not null;index:idx_price_observations_product_time,priority:2"`
+}
+
+// SearchScrape records when a platform was last scraped for a search term and category,
+// whatever the scrape found. Freshness is judged by it rather than by the listings'
+// UpdatedAt, since a re-scrape doesn't touch listings it no longer returns.
+type SearchScrape struct {
+	// Term is the normalised search term, see ScrapeTerm.
+	Term      string    `gorm:"type:varchar(255);primaryKey"`
+	Category  string    `gorm:"type:varchar(64);primaryKey"`
+	Platform  string    `gorm:"type:varchar(64);primaryKey"`
+	ScrapedAt time.Time `gorm:"not null"`
+	// Failed is true when the last scrape errored; failed platforms are retried sooner.
+	Failed bool `gorm:"not null"`
 }
 
-// Price struct is no longer needed as Price is now part of the Product itself.
+func (SearchScrape) TableName() string { return "search_scrapes" }
 
 type scrapeProductParams struct {
 	SearchTerm        string
@@ -26,5 +65,17 @@ type scrapeProductParams struct {
 	PriceSelectors    []string
 	ImageSelector     string
 	LinkSelector      string
-	ImageAttr         string
+	// ImageAttrs lists the image attributes to try, in order of preference.
+	// Defaults to defaultImageAttrs when empty.
+	ImageAttrs []string
+	// SponsoredSelectors mark a tile as a paid placement when any of them matches.
+	// SellerSelectors and FulfilledBySelectors are tried in order for the seller details;
+	// when none match, "Sold by ..." / "Fulfilled by ..." is read from the tile text.
+	SponsoredSelectors   []string
+	SellerSelectors      []string
+	FulfilledBySelectors []string
+	// BlockedResourceTypes and BlockedURLPatterns are intercepted in the browser
+	// and never downloaded. URL patterns are matched as lower-case substrings.
+	BlockedResourceTypes []network.ResourceType
+	BlockedURLPatterns   []string
 }
@@ -0,0 +1,135 @@
+package product
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"never-price-match-server/internal/infra/strutil"
+)
+
+// ResultSource says where a search result came from.
+type ResultSource string
+
+const (
+	// SourceCache marks a result served from previously scraped data.
+	SourceCache ResultSource = "CACHE"
+	// SourceLive marks a result scraped during the current request.
+	SourceLive ResultSource = "LIVE"
+)
+
+// FreshnessPolicy decides how long scraped prices may be served from the database
+// before a background refresh is due.
+type FreshnessPolicy struct {
+	// Default applies when neither the category nor the platform has its own max age.
+	Default time.Duration
+	// Category and Platform override Default. When both are set the shorter one wins.
+	Category map[string]time.Duration
+	Platform map[string]time.Duration
+	// FailedRetry is how long after a failed scrape a platform is tried again.
+	FailedRetry time.Duration
+}
+
+// defaultFreshness is the central configuration for cache max ages.
+// Fast-moving categories and platforms with frequent price changes get shorter windows.
+var defaultFreshness = FreshnessPolicy{
+	Default: 24 * time.Hour,
+	Category: map[string]time.Duration{
+		"electronics": 6 * time.Hour,
+		"outdoors":    24 * time.Hour,
+	},
+	Platform: map[string]time.Duration{
+		"Amazon AU": 6 * time.Hour,
+	},
+	FailedRetry: 15 * time.Minute,
+}
+
+// MaxAge returns how old a result for the given category and platform may be.
+func (p FreshnessPolicy) MaxAge(category, platform string) time.Duration {
+	maxAge := p.Default
+	if d, ok := p.Category[category]; ok {
+		maxAge = d
+	}
+	if d, ok := p.Platform[platform]; ok && d < maxAge {
+		maxAge = d
+	}
+	return maxAge
+}
+
+// IsStale reports whether something scraped at scrapedAt is too old to serve as current.
+func (p FreshnessPolicy) IsStale(category, platform string, scrapedAt, now time.Time) bool {
+	return now.Sub(scrapedAt) > p.MaxAge(category, platform)
+}
+
+// markCached flags every cached product with its source and staleness.
+func markCached(results []ScrapeResult, policy FreshnessPolicy, category string, now time.Time) {
+	for i := range results {
+		for j := range results[i].Products {
+			p := &results[i].Products[j]
+			p.Source = SourceCache
+			p.Stale = policy.IsStale(category, results[i].Platform, p.ScrapedAt, now)
+		}
+	}
+}
+
+// markLive flags freshly scraped products as live results scraped at now.
+func markLive(results []ScrapeResult, now time.Time) {
+	for i := range results {
+		for j := range results[i].Products {
+			p := &results[i].Products[j]
+			p.Source = SourceLive
+			p.ScrapedAt = now
+			p.Stale = false
+		}
+	}
+}
+
+// duePlatforms returns the platforms of a search that are due a re-scrape, sorted by
+// name: those never scraped for the term, those scraped longer ago than the policy
+// allows, and those whose last scrape failed more than FailedRetry ago.
+func duePlatforms(platforms []string, scrapes []SearchScrape, policy FreshnessPolicy, category string, now time.Time) []string {
+	last := make(map[string]SearchScrape, len(scrapes))
+	for _, s := range scrapes {
+		last[s.Platform] = s
+	}
+	var due []string
+	for _, platform := range platforms {
+		s, ok := last[platform]
+		switch {
+		case !ok:
+			// Never scraped for this term: the cached listings were found under others.
+		case s.Failed && now.Sub(s.ScrapedAt) <= policy.FailedRetry:
+			continue
+		case !s.Failed && !policy.IsStale(category, platform, s.ScrapedAt, now):
+			continue
+		}
+		due = append(due, platform)
+	}
+	sort.Strings(due)
+	return due
+}
+
+// unansweredPlatforms returns the platforms of a cached answer, sorted by name, that
+// have no listings in results and whose last scrape for the term didn't succeed.
+func unansweredPlatforms(platforms []string, results []ScrapeResult, scrapes []SearchScrape) []string {
+	answered := make(map[string]bool, len(results)+len(scrapes))
+	for _, res := range results {
+		answered[res.Platform] = answered[res.Platform] || len(res.Products) > 0
+	}
+	for _, s := range scrapes {
+		answered[s.Platform] = answered[s.Platform] || !s.Failed
+	}
+	var unanswered []string
+	for _, platform := range platforms {
+		if !answered[platform] {
+			unanswered = append(unanswered, platform)
+		}
+	}
+	sort.Strings(unanswered)
+	return unanswered
+}
+
+// ScrapeTerm is the normalised form of a search term that scrapes are recorded under.
+func ScrapeTerm(productName string) string {
+	return strutil.Truncate(strings.Join(SearchTokens(productName), " "), 255)
+}
@@ -0,0 +1,131 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrListingNotFound is returned when a listing ID doesn't exist.
+var ErrListingNotFound = errors.New("listing not found")
+
+// Granularity is the bucket size used to aggregate a price history.
+type Granularity string
+
+const (
+	// GranularityRaw returns every observation as its own point.
+	GranularityRaw Granularity = "RAW"
+	// GranularityHour aggregates observations per hour.
+	GranularityHour Granularity = "HOUR"
+	// GranularityDay aggregates observations per calendar day.
+	GranularityDay Granularity = "DAY"
+)
+
+// defaultHistoryWindow is how far back a price history goes when no start is given.
+const defaultHistoryWindow = 90 * 24 * time.Hour
+
+// historyLocation is the time zone whose calendar days are used for daily buckets.
+var historyLocation = time.Local
+
+// PricePoint is the aggregated price of a listing over one period.
+type PricePoint struct {
+	PeriodStart  time.Time
+	Min          float64
+	Max          float64
+	Close        float64 // the last price observed in the period
+	Observations int
+}
+
+// PriceHistory is the price history of a single listing.
+type PriceHistory struct {
+	Listing Product
+	From    time.Time
+	To      time.Time
+	Points  []PricePoint
+}
+
+// bucketStart returns the start of the period t falls into.
+func (g Granularity) bucketStart(t time.Time) time.Time {
+	switch g {
+	case GranularityHour:
+		return t.Truncate(time.Hour)
+	case GranularityDay:
+		local := t.In(historyLocation)
+		return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, historyLocation)
+	default:
+		return t
+	}
+}
+
+// StartOfDay returns the start of the calendar day t falls into, in the time zone
+// used for daily price history.
+func StartOfDay(t time.Time) time.Time {
+	return GranularityDay.bucketStart(t)
+}
+
+// aggregatePriceHistory groups observations into periods of the given granularity.
+// Observations may arrive in any order; points are returned oldest first.
+func aggregatePriceHistory(observations []PriceObservation, g Granularity) []PricePoint {
+	sorted := make([]PriceObservation, len(observations))
+	copy(sorted, observations)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ObservedAt.Before(sorted[j].ObservedAt) })
+
+	var points []PricePoint
+	for _, o := range sorted {
+		start := g.bucketStart(o.ObservedAt)
+		if g != GranularityRaw && len(points) > 0 && points[len(points)-1].PeriodStart.Equal(start) {
+			last := &points[len(points)-1]
+			if o.Price < last.Min {
+				last.Min = o.Price
+			}
+			if o.Price > last.Max {
+				last.Max = o.Price
+			}
+			last.Close = o.Price
+			last.Observations++
+			continue
+		}
+		points = append(points, PricePoint{
+			PeriodStart:  start,
+			Min:          o.Price,
+			Max:          o.Price,
+			Close:        o.Price,
+			Observations: 1,
+		})
+	}
+	return points
+}
+
+// PriceHistory returns the aggregated price history of a listing between from and to.
+// A zero `to` means now and a zero `from` means defaultHistoryWindow before `to`.
+func (s *service) PriceHistory(ctx context.Context, listingID uint, from, to time.Time, granularity Granularity) (*PriceHistory, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultHistoryWindow)
+	}
+	if from.After(to) {
+		return nil, errors.New("history start must not be after its end")
+	}
+	if granularity == "" {
+		granularity = GranularityDay
+	}
+
+	listing, err := s.repo.GetProductByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	observations, err := s.repo.GetPriceObservations(ctx, listingID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PriceHistory{
+		Listing: *listing,
+		From:    from,
+		To:      to,
+		Points:  aggregatePriceHistory(observations, granularity),
+	}, nil
+}
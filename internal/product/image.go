@@ -0,0 +1,144 @@
+package product
+
+import (
+	"strconv"
+	"strings"
+)
+
+// defaultImageAttrs is the order in which image attributes are tried.
+// Lazy-loading tiles usually keep a placeholder in `src` and the real image
+// in one of the data-* attributes, so those come first.
+var defaultImageAttrs = []string{
+	"data-srcset",
+	"srcset",
+	"data-src",
+	"data-lazy-src",
+	"data-original",
+	"src",
+}
+
+// placeholderMarkers are substrings of well-known lazy-load placeholder images.
+var placeholderMarkers = []string{
+	"placeholder",
+	"blank.gif",
+	"spacer.gif",
+	"pixel.gif",
+	"transparent.gif",
+	"transparent.png",
+	"grey-pixel",
+	"1x1",
+}
+
+// resolveImageURL picks the best image URL from an element's attributes.
+// Attributes are tried in the given order; srcset-style attributes yield their
+// highest-resolution candidate. Placeholders and data URIs are rejected and the
+// result is made absolute against pageURL. It returns "" if nothing usable is found.
+func resolveImageURL(attrs map[string]string, order []string, pageURL string) string {
+	for _, name := range order {
+		value := strings.TrimSpace(attrs[name])
+		if value == "" {
+			continue
+		}
+
+		candidate := value
+		if strings.HasSuffix(name, "srcset") {
+			candidate = bestSrcsetCandidate(value)
+		}
+		if isPlaceholderImage(candidate) {
+			continue
+		}
+		return absoluteURL(pageURL, candidate)
+	}
+	return ""
+}
+
+// isPlaceholderImage reports whether src is empty, inline or a known placeholder.
+func isPlaceholderImage(src string) bool {
+	lower := strings.ToLower(strings.TrimSpace(src))
+	if lower == "" || lower == "about:blank" || lower == "#" {
+		return true
+	}
+	if strings.HasPrefix(lower, "data:") || strings.HasPrefix(lower, "blob:") {
+		return true
+	}
+	for _, marker := range placeholderMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// srcsetCandidate is a single "url descriptor" entry of a srcset attribute.
+type srcsetCandidate struct {
+	url     string
+	width   float64 // from a "w" descriptor, 0 if absent
+	density float64 // from an "x" descriptor, 1 if absent
+}
+
+// bestSrcsetCandidate returns the highest-resolution usable URL in a srcset.
+// Width descriptors win over density descriptors when both kinds are present.
+func bestSrcsetCandidate(srcset string) string {
+	var best *srcsetCandidate
+	for _, c := range parseSrcset(srcset) {
+		if isPlaceholderImage(c.url) {
+			continue
+		}
+		if best == nil || c.width > best.width || (c.width == best.width && c.density > best.density) {
+			best = &c
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.url
+}
+
+// parseSrcset splits a srcset attribute into its candidates.
+// URLs may legitimately contain commas (e.g. CDN transform parameters), so a
+// candidate URL runs until whitespace, following the HTML parsing rules.
+func parseSrcset(srcset string) []srcsetCandidate {
+	var candidates []srcsetCandidate
+	s := srcset
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\f,")
+		if s == "" {
+			return candidates
+		}
+
+		end := strings.IndexAny(s, " \t\n\r\f")
+		if end < 0 {
+			end = len(s)
+		}
+		rawURL := s[:end]
+		s = s[end:]
+
+		var descriptor string
+		if strings.HasSuffix(rawURL, ",") {
+			// "url," has no descriptors.
+			rawURL = strings.TrimRight(rawURL, ",")
+		} else {
+			comma := strings.IndexByte(s, ',')
+			if comma < 0 {
+				comma = len(s)
+			}
+			descriptor = strings.TrimSpace(s[:comma])
+			s = s[comma:]
+		}
+
+		c := srcsetCandidate{url: rawURL, density: 1}
+		for _, d := range strings.Fields(descriptor) {
+			n, err := strconv.ParseFloat(d[:len(d)-1], 64)
+			if err != nil {
+				continue
+			}
+			switch d[len(d)-1] {
+			case 'w':
+				c.width = n
+			case 'x':
+				c.density = n
+			}
+		}
+		candidates = append(candidates, c)
+	}
+}
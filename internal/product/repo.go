@@ -1,11 +1,27 @@
 package product
 
+import (
+	"context"
+	"time"
+)
+
 // Repo defines the interface for product data persistence.
 type Repo interface {
-	// SearchProductsByName finds products by a search term.
-	SearchProductsByName(name string) ([]Product, error)
-	// SaveProducts saves a list of products to the database.
-	SaveProducts(products []Product) error
-	// GetProductNamesByName retrieves a list of unique product names for suggestions.
-	GetProductNamesByName(name string) ([]string, error)
+	// ListProducts pages through all listings in ID order, starting after afterID.
+	ListProducts(ctx context.Context, afterID uint, limit int) ([]Product, error)
+	// UpsertListings inserts listings, or refreshes the stored listing with the same
+	// platform + link, and fills in their IDs.
+	UpsertListings(ctx context.Context, products []Product) error
+	// AddPriceObservations appends observations to listings' price histories.
+	AddPriceObservations(ctx context.Context, observations []PriceObservation) error
+	// GetProductsByIDs returns the listings with the given IDs that exist, in no particular order.
+	GetProductsByIDs(ctx context.Context, ids []uint) ([]Product, error)
+	// GetProductByID returns a single listing, or ErrListingNotFound.
+	GetProductByID(ctx context.Context, id uint) (*Product, error)
+	// GetPriceObservations returns a listing's observations between from and to, oldest first.
+	GetPriceObservations(ctx context.Context, productID uint, from, to time.Time) ([]PriceObservation, error)
+	// SearchScrapes returns when each platform was last scraped for a normalised term and category.
+	SearchScrapes(ctx context.Context, term, category string) ([]SearchScrape, error)
+	// RecordSearchScrape creates or replaces the scrape record of a term, category and platform.
+	RecordSearchScrape(ctx context.Context, s *SearchScrape) error
 }
@@ -0,0 +1,110 @@
+package product
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SearchHit is a listing matched by a SearchIndex with its raw relevance score.
+// Scores are only comparable within the results of a single query.
+type SearchHit struct {
+	Product   Product
+	Relevance float64
+}
+
+// Indexer receives listings as they are stored.
+type Indexer interface {
+	// Index adds or refreshes listings. Indexes backed by the database itself may ignore it.
+	Index(products []Product) error
+}
+
+// Remover is implemented by indexes that hold their own copy of listings.
+type Remover interface {
+	// Remove drops deleted listings from the index.
+	Remove(ids ...uint)
+}
+
+// SearchIndex finds stored listings by free text, regardless of word order.
+type SearchIndex interface {
+	Indexer
+	// Search returns up to limit listings matching every word of query.
+	Search(ctx context.Context, query string, limit int) ([]SearchHit, error)
+}
+
+const (
+	// searchLimit caps how many cached listings a search returns.
+	searchLimit = 100
+	// recencyHalfLife is the age at which a listing's recency boost halves.
+	recencyHalfLife = 14 * 24 * time.Hour
+	// recencyFloor is the share of relevance an arbitrarily old listing keeps,
+	// so a strong old match still beats a weak fresh one.
+	recencyFloor = 0.3
+)
+
+// rankHits orders hits by relevance weighted by recency, best first.
+// Relevance is normalised against the best hit so every index ranks on the same scale.
+func rankHits(hits []SearchHit, now time.Time) []Product {
+	maxRelevance := 0.0
+	for _, h := range hits {
+		maxRelevance = math.Max(maxRelevance, h.Relevance)
+	}
+
+	type scored struct {
+		product Product
+		score   float64
+	}
+	ranked := make([]scored, 0, len(hits))
+	for _, h := range hits {
+		relevance := 1.0
+		if maxRelevance > 0 {
+			relevance = h.Relevance / maxRelevance
+		}
+		age := now.Sub(h.Product.UpdatedAt)
+		if age < 0 {
+			age = 0
+		}
+		decay := math.Pow(0.5, float64(age)/float64(recencyHalfLife))
+		ranked = append(ranked, scored{product: h.Product, score: relevance * (recencyFloor + (1-recencyFloor)*decay)})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	products := make([]Product, len(ranked))
+	for i, r := range ranked {
+		products[i] = r.product
+	}
+	return products
+}
+
+// SearchTokens splits text into lower-case alphanumeric words, the unit every search index matches on.
+func SearchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// indexBatchSize is how many listings BuildIndex loads per query.
+const indexBatchSize = 1000
+
+// BuildIndex loads every stored listing into index. Indexes kept in memory need this on start.
+func BuildIndex(ctx context.Context, repo Repo, index Indexer) (int, error) {
+	var afterID uint
+	total := 0
+	for {
+		batch, err := repo.ListProducts(ctx, afterID, indexBatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(batch) == 0 {
+			return total, nil
+		}
+		if err := index.Index(batch); err != nil {
+			return total, err
+		}
+		total += len(batch)
+		afterID = batch[len(batch)-1].ID
+	}
+}
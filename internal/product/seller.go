@@ -0,0 +1,115 @@
+package product
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// firstPartySellers lists the seller names under which each platform sells its own stock.
+// Any other seller is a marketplace (third-party) seller that most retailers won't price-match.
+var firstPartySellers = map[string][]string{
+	"Amazon AU": {"amazon", "amazon au", "amazon.com.au", "amazon australia", "amazon commercial services pty ltd"},
+	"Big W":     {"big w"},
+}
+
+// marketplacePlatforms host third-party sellers. On every other platform the
+// platform itself is the seller, so a missing seller means first-party.
+var marketplacePlatforms = map[string]bool{
+	"Amazon AU": true,
+	"Big W":     true,
+}
+
+// Patterns used to read seller details from a tile's visible text when no
+// dedicated selector matches, e.g. "Sold by Acme Pty Ltd and fulfilled by Amazon".
+var (
+	soldByPattern      = regexp.MustCompile(`(?i)\bsold by:?\s+(.+?)(?:\s+and\s+(?:fulfilled|shipped|dispatched)\b|\s*[·|]|$)`)
+	fulfilledByPattern = regexp.MustCompile(`(?i)\b(?:fulfilled|shipped|dispatched|ships) (?:by|from):?\s+(.+?)(?:\s*[·|.]|$)`)
+)
+
+// sponsoredRedirectPaths are link paths only used by paid placements.
+var sponsoredRedirectPaths = []string{"/sspa/click", "/gp/slredirect"}
+
+// isSponsoredTile reports whether a tile is a paid placement, judging by its
+// text (a line reading just "Sponsored") or by a sponsored-click link.
+func isSponsoredTile(tileText, rawLink string) bool {
+	for _, line := range strings.Split(tileText, "\n") {
+		if strings.EqualFold(strings.TrimSpace(line), "sponsored") {
+			return true
+		}
+	}
+	if u, err := url.Parse(rawLink); err == nil && hasAnyPrefix(u.Path, sponsoredRedirectPaths) {
+		return true
+	}
+	return false
+}
+
+// parseSellerText extracts the sold-by and fulfilled-by names from tile text.
+// Each line is inspected separately; empty strings mean "not shown".
+func parseSellerText(text string) (soldBy, fulfilledBy string) {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if soldBy == "" {
+			if m := soldByPattern.FindStringSubmatch(line); m != nil {
+				soldBy = strings.TrimSpace(m[1])
+			}
+		}
+		if fulfilledBy == "" {
+			if m := fulfilledByPattern.FindStringSubmatch(line); m != nil {
+				fulfilledBy = strings.TrimSpace(m[1])
+			}
+		}
+	}
+	return soldBy, fulfilledBy
+}
+
+// resolveSeller fills in the seller for platforms that only sell their own stock
+// and reports whether the listing is sold by a third party.
+// On marketplace platforms an unknown seller is not assumed to be third-party.
+func resolveSeller(platform, seller string) (string, bool) {
+	seller = strings.TrimSpace(seller)
+	if seller == "" {
+		if marketplacePlatforms[platform] {
+			return "", false
+		}
+		return platform, false
+	}
+	if strings.EqualFold(seller, platform) {
+		return seller, false
+	}
+	for _, name := range firstPartySellers[platform] {
+		if strings.EqualFold(seller, name) {
+			return seller, false
+		}
+	}
+	return seller, true
+}
+
+// ListingFilter narrows search results by how each offer is sold.
+type ListingFilter struct {
+	ExcludeSponsored  bool
+	ExcludeThirdParty bool
+}
+
+// FilterListings drops the products excluded by f, keeping platforms even if they end up empty.
+func FilterListings(results []ScrapeResult, f ListingFilter) []ScrapeResult {
+	if !f.ExcludeSponsored && !f.ExcludeThirdParty {
+		return results
+	}
+	filtered := make([]ScrapeResult, 0, len(results))
+	for _, res := range results {
+		kept := make([]ScrapedProduct, 0, len(res.Products))
+		for _, p := range res.Products {
+			if f.ExcludeSponsored && p.Sponsored {
+				continue
+			}
+			if f.ExcludeThirdParty && p.ThirdParty {
+				continue
+			}
+			kept = append(kept, p)
+		}
+		res.Products = kept
+		filtered = append(filtered, res)
+	}
+	return filtered
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"net/url"
 	"never-price-match-server/internal/infra/logger" // <--- 1. 添加 "os" 包
+	"never-price-match-server/internal/infra/metrics"
+	"never-price-match-server/internal/infra/txn"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/chromedp/cdproto/cdp"
@@ -19,50 +21,344 @@ import (
 // Service defines the business logic interface for products.
 // It now correctly uses the ScrapeResult type.
 type Service interface {
-	SearchAndScrape(productName string, category string) ([]ScrapeResult, error)
+	SearchAndScrape(ctx context.Context, productName string, category string) ([]ScrapeResult, error)
+	// Search is SearchAndScrape with the listings filtered by filter and summarised:
+	// the best deal, price spread and which platforms answered.
+	Search(ctx context.Context, productName, category string, filter ListingFilter) (*SearchResponse, error)
 	GetProductSuggestions(name string) ([]string, error)
+	RecordSuggestionClick(text string)
+	PriceHistory(ctx context.Context, listingID uint, from, to time.Time, granularity Granularity) (*PriceHistory, error)
+	// Refresh re-scrapes the given platforms for a search term and saves what it finds
+	// under category, returning how many listings were found.
+	Refresh(ctx context.Context, productName, category string, platformNames []string) (int, error)
+	// ScrapePlatform scrapes one platform live for a search term and saves what it finds under category.
+	ScrapePlatform(ctx context.Context, productName, category, platformName string) (ScrapeResult, error)
+	// Listings returns stored listings by ID, grouped by platform in the order of ids.
+	Listings(ctx context.Context, ids []uint) ([]ScrapeResult, error)
+	// ForgetListings drops deleted listings from the search index, suggestions and result cache.
+	ForgetListings(ids []uint)
+	// Close cancels background refreshes and scrapes in progress and waits for them.
+	Close()
+}
+
+// ScrapeObserver is told about listings as soon as a live scrape has saved them.
+type ScrapeObserver interface {
+	// ListingsScraped receives a platform's saved results, with their listing IDs.
+	// It is called on the scrape's goroutine, so it must not block for long.
+	ListingsScraped(ctx context.Context, results []ScrapeResult)
 }
 
 type service struct {
-	repo Repo
+	repo      Repo
+	tx        txn.Runner
+	index     SearchIndex
+	cache     ResultCache
+	suggester *Suggester
+	observer  ScrapeObserver
+	browser   *Browser
+	log       *logger.Logger
+	freshness FreshnessPolicy
+
+	// refreshing holds the keys of background refreshes in progress,
+	// so a burst of searches for a stale term triggers only one re-scrape.
+	refreshing sync.Map
+	background sync.WaitGroup
+	// ctx is the service's lifetime: background refreshes and shared scrapes run under
+	// it, and Close cancels it so they stop with their browser tabs.
+	ctx  context.Context
+	stop context.CancelFunc
+	// inflight shares a platform scrape between concurrent identical searches.
+	inflight scrapeGroup
 }
 
 // NewService creates a new product service instance.
-func NewService(repo Repo) Service {
-	return &service{repo: repo}
+// Cached listings are looked up through index, with recent lookups kept in cache, and
+// suggestions come from suggester; listings saved by the service are added to both
+// indexes and purge the cache, then are passed to observer, which may be nil. Live
+// scrapes run in browser.
+func NewService(repo Repo, tx txn.Runner, index SearchIndex, cache ResultCache, suggester *Suggester, observer ScrapeObserver, browser *Browser, log *logger.Logger) Service {
+	ctx, stop := context.WithCancel(context.Background())
+	return &service{
+		repo:      repo,
+		tx:        tx,
+		index:     index,
+		cache:     cache,
+		suggester: suggester,
+		observer:  observer,
+		browser:   browser,
+		log:       log,
+		freshness: defaultFreshness,
+		ctx:       ctx,
+		stop:      stop,
+	}
 }
 
 // SearchAndScrape is now fully updated to use ScrapeResult.
-func (s *service) SearchAndScrape(productName string, category string) ([]ScrapeResult, error) {
-	// 1. First, try to find the product in the database.
-	cachedProducts, err := s.repo.SearchProductsByName(productName)
+func (s *service) SearchAndScrape(ctx context.Context, productName string, category string) ([]ScrapeResult, error) {
+	results, _, _, err := s.search(ctx, productName, category)
+	return results, err
+}
+
+func (s *service) Search(ctx context.Context, productName, category string, filter ListingFilter) (*SearchResponse, error) {
+	results, searched, failed, err := s.search(ctx, productName, category)
+	if err != nil {
+		return nil, err
+	}
+	results = FilterListings(results, filter)
+	return &SearchResponse{Results: results, Summary: Summarize(results, searched, failed)}, nil
+}
+
+// search answers a search from stored listings or, when there are none, a live scrape.
+// It also returns the category's platforms, which are those searched, and those among
+// them that failed. A cached answer counts a platform without listings as failed unless
+// its last scrape for the term succeeded.
+func (s *service) search(ctx context.Context, productName string, category string) (results []ScrapeResult, searched, failed []string, err error) {
+	// 1. First, try to find the product in the database, best and freshest matches first.
+	cachedProducts, err := s.searchCached(ctx, productName)
 	if err != nil {
 		// Log the error but don't block. We can still proceed with scraping.
-		logger.L.Warn("Failed to search for cached products", logger.Err(err))
+		s.log.Warn("Failed to search for cached products", logger.Err(err))
 	}
 
 	// If we found cached products, format them into the ScrapeResult structure and return.
+	// Stale data is still served immediately; the category's platforms that are due a
+	// re-scrape for the term, including ones that failed or were never scraped, are
+	// re-scraped in the background.
 	if len(cachedProducts) > 0 {
-		return formatProductsToScrapeResults(cachedProducts), nil
+		now := time.Now()
+		results := formatProductsToScrapeResults(cachedProducts)
+		markCached(results, s.freshness, category, now)
+		s.suggester.RecordQuery(productName, len(cachedProducts))
+		searched = s.platformsForCategory(category)
+		// If the scrape records can't be read nothing is refreshed, rather than everything.
+		scrapes, err := s.repo.SearchScrapes(ctx, ScrapeTerm(productName), category)
+		if err != nil {
+			s.log.Warn("Failed to read search scrape records", logger.Err(err))
+		} else if due := duePlatforms(searched, scrapes, s.freshness, category, now); len(due) > 0 {
+			s.refreshInBackground(productName, category, due)
+		}
+		return results, searched, unansweredPlatforms(searched, results, scrapes), nil
 	}
 
 	// 2. If not found in the database, proceed with live scraping.
-	scrapedResults, err := s.performScraping(productName, category)
+	// Each platform's results are saved for future searches before responding, so they
+	// carry their listing IDs; a failed save is logged but doesn't fail the search.
+	searched = s.platformsForCategory(category)
+	scrapedResults, failed, err := s.scrapePlatforms(ctx, productName, category, searched)
 	if err != nil {
-		return nil, err // If scraping itself fails catastrophically, return the error.
+		return nil, nil, nil, err // If scraping itself fails catastrophically, return the error.
 	}
+	s.suggester.RecordQuery(productName, countProducts(scrapedResults))
 
-	// 3. Asynchronously save the new results to the database for future searches.
-	if len(scrapedResults) > 0 {
-		go func() {
-			productsToSave := convertScrapeResultsToProducts(scrapedResults)
-			if err := s.repo.SaveProducts(productsToSave); err != nil {
-				logger.L.Error("Failed to save scraped products to database", logger.Err(err))
-			}
-		}()
+	return scrapedResults, searched, failed, nil
+}
+
+// recordScrape notes that a platform was scraped for a term, successfully or not.
+func (s *service) recordScrape(ctx context.Context, productName, category, platformName string, failed bool) {
+	err := s.repo.RecordSearchScrape(ctx, &SearchScrape{
+		Term:      ScrapeTerm(productName),
+		Category:  category,
+		Platform:  platformName,
+		ScrapedAt: time.Now(),
+		Failed:    failed,
+	})
+	if err != nil {
+		s.log.Warn("Failed to record search scrape", logger.Str("platform", platformName), logger.Err(err))
 	}
+}
 
-	return scrapedResults, nil
+// searchCached looks up stored listings through the result cache, falling back to the
+// search index, ranked by relevance and recency.
+func (s *service) searchCached(ctx context.Context, productName string) ([]Product, error) {
+	key := cacheKey(productName)
+	if products, ok := s.cache.Get(key); ok {
+		return products, nil
+	}
+	generation := s.cache.Generation()
+	hits, err := s.index.Search(ctx, productName, searchLimit)
+	if err != nil {
+		return nil, err
+	}
+	products := rankHits(hits, time.Now())
+	s.cache.Set(key, generation, products)
+	return products, nil
+}
+
+// saveResults stores scraped results for future searches under the search category
+// and copies the resulting listing IDs back onto them, logging any failure. Saved
+// results are then passed to the scrape observer.
+func (s *service) saveResults(ctx context.Context, results []ScrapeResult, category string) {
+	productsToSave := convertScrapeResultsToProducts(results, category)
+	if err := s.saveListings(ctx, productsToSave); err != nil {
+		s.log.Error("Failed to save scraped products to database", logger.Err(err))
+		return
+	}
+	if err := s.index.Index(productsToSave); err != nil {
+		s.log.Warn("Failed to index saved products", logger.Err(err))
+	}
+	s.cache.Purge()
+	_ = s.suggester.Index(productsToSave)
+
+	listingIDs := make(map[string]uint, len(productsToSave))
+	for _, p := range productsToSave {
+		listingIDs[listingKey(p.Platform, p.Link)] = p.ID
+	}
+	for i := range results {
+		for j := range results[i].Products {
+			p := &results[i].Products[j]
+			p.ListingID = listingIDs[listingKey(results[i].Platform, p.Link)]
+		}
+	}
+	if s.observer != nil {
+		s.observer.ListingsScraped(ctx, results)
+	}
+}
+
+// saveListings upserts listings and appends a price observation for each of them,
+// as one unit of work so a listing is never stored without its price history.
+func (s *service) saveListings(ctx context.Context, products []Product) error {
+	observedAt := time.Now()
+	return s.tx.Run(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpsertListings(ctx, products); err != nil {
+			return err
+		}
+		observations := make([]PriceObservation, 0, len(products))
+		for _, p := range products {
+			observations = append(observations, PriceObservation{ProductID: p.ID, Price: p.Price, ObservedAt: observedAt})
+		}
+		return s.repo.AddPriceObservations(ctx, observations)
+	})
+}
+
+// listingKey is the identity of a listing: its platform and canonical link.
+func listingKey(platform, link string) string {
+	return platform + "\x00" + link
+}
+
+// refreshInBackground re-scrapes the given platforms for a search term and saves the
+// results, unless a refresh for the same term and category is already running.
+func (s *service) refreshInBackground(productName, category string, platformNames []string) {
+	key := strings.ToLower(strings.TrimSpace(productName)) + "|" + category
+	if _, running := s.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		defer s.refreshing.Delete(key)
+		s.log.Info("Refreshing stale cached products",
+			logger.Str("term", productName),
+			logger.Str("category", category),
+			logger.Field("platforms", platformNames),
+		)
+		if _, _, err := s.scrapePlatforms(s.ctx, productName, category, platformNames); err != nil {
+			s.log.Warn("Background refresh failed", logger.Str("term", productName), logger.Err(err))
+		}
+	}()
+}
+
+func (s *service) ForgetListings(ids []uint) {
+	if r, ok := s.index.(Remover); ok {
+		r.Remove(ids...)
+	}
+	s.suggester.Remove(ids...)
+	s.cache.Purge()
+}
+
+func (s *service) Close() {
+	s.stop()
+	s.background.Wait()
+}
+
+func (s *service) Refresh(ctx context.Context, productName, category string, platformNames []string) (int, error) {
+	results, _, err := s.scrapePlatforms(ctx, productName, category, platformNames)
+	if err != nil {
+		return 0, err
+	}
+	return countProducts(results), nil
+}
+
+func (s *service) ScrapePlatform(ctx context.Context, productName, category, platformName string) (ScrapeResult, error) {
+	return s.scrapePlatform(ctx, productName, category, platformName)
+}
+
+// scrapePlatform scrapes one platform live and saves what it finds under category.
+// Callers asking for the same platform, category and normalised term while a scrape
+// is running share that scrape and its result instead of starting another.
+func (s *service) scrapePlatform(ctx context.Context, productName, category, platformName string) (ScrapeResult, error) {
+	scraper, exists := allScrapers[platformName]
+	if !exists {
+		return ScrapeResult{}, fmt.Errorf("scraper not defined for platform %q", platformName)
+	}
+	result, shared, err := s.inflight.do(ctx, scrapeKey(productName, category, platformName), func() (ScrapeResult, error) {
+		// Shared with other callers, so neither the scrape nor the record and save
+		// depend on this caller staying; the scrape stops when the service closes.
+		result, err := scraper(s.ctx, s.browser, productName)
+		s.recordScrape(context.WithoutCancel(ctx), productName, category, platformName, err != nil)
+		if err != nil {
+			return ScrapeResult{}, err
+		}
+		result.Platform = platformName
+		results := []ScrapeResult{result}
+		markLive(results, time.Now())
+		if len(result.Products) > 0 {
+			s.saveResults(context.WithoutCancel(ctx), results, category)
+		}
+		return results[0], nil
+	})
+	if shared {
+		metrics.Add("scrapes_coalesced", platformName, 1)
+		s.log.Info("Joined an identical scrape in flight",
+			logger.Str("term", productName),
+			logger.Str("category", category),
+			logger.Str("platform", platformName),
+		)
+	}
+	return result, err
+}
+
+func (s *service) Listings(ctx context.Context, ids []uint) ([]ScrapeResult, error) {
+	products, err := s.repo.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	var results []ScrapeResult
+	platformIndex := make(map[string]int)
+	for _, id := range ids {
+		p, ok := byID[id]
+		if !ok {
+			continue
+		}
+		i, ok := platformIndex[p.Platform]
+		if !ok {
+			i = len(results)
+			platformIndex[p.Platform] = i
+			results = append(results, ScrapeResult{Platform: p.Platform})
+		}
+		results[i].Products = append(results[i].Products, ScrapedProduct{
+			ListingID: p.ID,
+			Name:      p.Name,
+			Price:     p.Price,
+			ImageURL:  p.ImageURL,
+			Link:      p.Link,
+
+			Sponsored:   p.Sponsored,
+			Seller:      p.Seller,
+			FulfilledBy: p.FulfilledBy,
+			ThirdParty:  p.ThirdParty,
+			StockStatus: p.StockStatus,
+			Clearance:   p.Clearance,
+			Source:      SourceCache,
+			ScrapedAt:   p.UpdatedAt,
+		})
+	}
+	return results, nil
 }
 
 // formatProductsToScrapeResults converts a flat list of DB product entities
@@ -72,10 +368,19 @@ func formatProductsToScrapeResults(products []Product) []ScrapeResult {
 
 	for _, p := range products {
 		sp := ScrapedProduct{
-			Name:     p.Name,
-			Price:    p.Price,
-			ImageURL: p.ImageURL,
-			Link:     p.Link,
+			ListingID: p.ID,
+			Name:      p.Name,
+			Price:     p.Price,
+			ImageURL:  p.ImageURL,
+			Link:      p.Link,
+
+			Sponsored:   p.Sponsored,
+			Seller:      p.Seller,
+			FulfilledBy: p.FulfilledBy,
+			ThirdParty:  p.ThirdParty,
+			StockStatus: p.StockStatus,
+			Clearance:   p.Clearance,
+			ScrapedAt:   p.UpdatedAt,
 		}
 		groupedByPlatform[p.Platform] = append(groupedByPlatform[p.Platform], sp)
 	}
@@ -92,16 +397,31 @@ func formatProductsToScrapeResults(products []Product) []ScrapeResult {
 
 // convertScrapeResultsToProducts flattens the grouped ScrapeResult structure
 // into a flat list of Product entities suitable for saving to the database.
-func convertScrapeResultsToProducts(results []ScrapeResult) []Product {
+// Listings repeated within one scrape (same platform and canonical link) are kept once.
+func convertScrapeResultsToProducts(results []ScrapeResult, category string) []Product {
 	var products []Product
+	seen := make(map[string]bool)
 	for _, res := range results {
 		for _, p := range res.Products {
+			key := listingKey(res.Platform, p.Link)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
 			products = append(products, Product{
 				Name:     p.Name,
 				Platform: res.Platform,
 				Price:    p.Price,
 				Link:     p.Link,
 				ImageURL: p.ImageURL,
+				Category: category,
+
+				Sponsored:   p.Sponsored,
+				Seller:      p.Seller,
+				FulfilledBy: p.FulfilledBy,
+				ThirdParty:  p.ThirdParty,
+				StockStatus: p.StockStatus,
+				Clearance:   p.Clearance,
 			})
 		}
 	}
@@ -109,8 +429,8 @@ func convertScrapeResultsToProducts(results []ScrapeResult) []Product {
 }
 
 // scraperFunc defines a standard signature for all scraper functions.
-// This makes them interchangeable.
-type scraperFunc func(productName string) (ScrapeResult, error)
+// This makes them interchangeable. The scrape's tab is closed when ctx is cancelled.
+type scraperFunc func(ctx context.Context, b *Browser, productName string) (ScrapeResult, error)
 
 // allScrapers acts as a central registry for all available scraping functions.
 // To add a new scraper, simply add it to this map.
@@ -145,33 +465,45 @@ var categoryPlatforms = map[string][]string{
 	},
 }
 
-func (s *service) performScraping(productName string, category string) ([]ScrapeResult, error) {
-	// Look up the list of platform names for the given category.
-	platformNames, ok := categoryPlatforms[category]
-	if !ok {
-		// If the category is not found in our map, use the 'default' list as a fallback.
-		logger.L.Info("Category not found, using default platforms", logger.Str("category", category))
-		platformNames = categoryPlatforms["default"]
+// platformsForCategory looks up the list of platform names for the given category.
+func (s *service) platformsForCategory(category string) []string {
+	if _, ok := categoryPlatforms[category]; !ok {
+		s.log.Info("Category not found, using default platforms", logger.Str("category", category))
 	}
+	return CategoryPlatforms(category)
+}
 
+// CategoryPlatforms returns the platforms scraped for a category, falling back to
+// the 'default' list for categories that aren't configured.
+func CategoryPlatforms(category string) []string {
+	if platformNames, ok := categoryPlatforms[category]; ok {
+		return platformNames
+	}
+	return categoryPlatforms["default"]
+}
+
+// scrapePlatforms runs the scrapers of the given platforms for a search term and saves
+// the results under category. It also returns the platforms that couldn't be scraped.
+func (s *service) scrapePlatforms(ctx context.Context, productName, category string, platformNames []string) ([]ScrapeResult, []string, error) {
 	resultsChan := make(chan ScrapeResult, len(platformNames))
 	errChan := make(chan error, len(platformNames))
+	var failed []string
 
 	// Run scrapers sequentially for the selected platforms to avoid being blocked.
 	for _, platformName := range platformNames {
-		scraper, exists := allScrapers[platformName]
-		if !exists {
-			logger.L.Warn("Scraper not defined for platform", logger.Str("platform", platformName))
+		if _, exists := allScrapers[platformName]; !exists {
+			s.log.Warn("Scraper not defined for platform", logger.Str("platform", platformName))
+			failed = append(failed, platformName)
 			continue
 		}
 
 		// Execute the scraper function.
-		result, err := scraper(productName)
+		result, err := s.scrapePlatform(ctx, productName, category, platformName)
 		if err != nil {
 			errChan <- fmt.Errorf("failed to scrape %s: %w", platformName, err)
+			failed = append(failed, platformName)
 			continue
 		}
-		result.Platform = platformName
 		resultsChan <- result
 	}
 
@@ -185,13 +517,13 @@ func (s *service) performScraping(productName string, category string) ([]Scrape
 
 	// Log any errors that occurred during scraping.
 	for err := range errChan {
-		logger.L.Warn("Scraping error", logger.Err(err))
+		s.log.Warn("Scraping error", logger.Err(err))
 	}
 
-	return finalResults, nil
+	return finalResults, failed, nil
 }
 
-func scrapeWithChromeDP(params scrapeProductParams) (ScrapeResult, error) {
+func scrapeWithChromeDP(ctx context.Context, b *Browser, params scrapeProductParams) (ScrapeResult, error) {
 	var result ScrapeResult
 	searchURL := params.SearchURL
 	itemSelector := params.ContainerSelector
@@ -199,7 +531,10 @@ func scrapeWithChromeDP(params scrapeProductParams) (ScrapeResult, error) {
 	priceSelectors := params.PriceSelectors
 	imageSelector := params.ImageSelector
 	linkSelector := params.LinkSelector
-	imageAttr := params.ImageAttr
+	imageAttrs := params.ImageAttrs
+	if len(imageAttrs) == 0 {
+		imageAttrs = defaultImageAttrs
+	}
 
 	// A helper function to create a non-fatal "click if exists" action.
 	// It waits for the selector to be visible and then clicks, ignoring any errors.
@@ -217,21 +552,11 @@ func scrapeWithChromeDP(params scrapeProductParams) (ScrapeResult, error) {
 		})
 	}
 
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("disable-features", "Translate"),
-		chromedp.Flag("no-first-run", true),
-		chromedp.Flag("no-default-browser-check", true),
-		chromedp.Flag("disable-blink-features", "AutomationControlled"),
-		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/108.0.0.0 Safari/537.36"),
-	)
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer cancel()
-
-	// This is the main context for the browser tab.
-	taskCtx, cancelTask := chromedp.NewContext(allocCtx)
+	// Each scrape gets its own tab in the shared browser.
+	taskCtx, cancelTask, err := b.newTab(ctx)
+	if err != nil {
+		return ScrapeResult{}, err
+	}
 	defer cancelTask()
 
 	// --- ANTI-BOT DETECTION ---
@@ -253,13 +578,31 @@ func scrapeWithChromeDP(params scrapeProductParams) (ScrapeResult, error) {
 		return ScrapeResult{}, err // If we can't set up stealth, we shouldn't proceed.
 	}
 
+	// --- RESOURCE BLOCKING ---
+	// Fonts, media, images and trackers are failed before they are downloaded.
+	// We only need the DOM, and image URLs are read from attributes.
+	blocker := newRequestBlocker(params.BlockedResourceTypes, params.BlockedURLPatterns)
+	if err := blocker.attach(taskCtx); err != nil {
+		return ScrapeResult{}, fmt.Errorf("could not enable request blocking: %w", err)
+	}
+	defer func() {
+		blocked, saved := blocker.blocked.Load(), blocker.estimatedBytesSaved.Load()
+		metrics.Add("scrape_blocked_requests", params.Platform, blocked)
+		metrics.Add("scrape_estimated_bytes_saved", params.Platform, saved)
+		b.log.Info("Request blocking summary",
+			logger.Str("platform", params.Platform),
+			logger.Field("blocked_requests", blocked),
+			logger.Field("estimated_bytes_saved", saved),
+		)
+	}()
+
 	// 1. Create a context specifically for loading the page.
 	loadCtx, cancelLoad := context.WithTimeout(taskCtx, 45*time.Second)
 	defer cancelLoad()
 
 	var nodes []*cdp.Node
 	// Use the loading context for the initial page load and node retrieval.
-	err := chromedp.Run(loadCtx,
+	err = chromedp.Run(loadCtx,
 		chromedp.Navigate(searchURL),
 		chromedp.Sleep(2*time.Second),
 
@@ -312,12 +655,19 @@ func scrapeWithChromeDP(params scrapeProductParams) (ScrapeResult, error) {
 		}
 
 		// Extract image
-		err = chromedp.Run(extractCtx, chromedp.AttributeValue(imageSelector, imageAttr, &img, nil, chromedp.ByQuery, chromedp.FromNode(node)))
+		// All attributes are read at once so lazy-load attributes (data-src, srcset...)
+		// can be preferred over a placeholder in src.
+		var imgAttrs map[string]string
+		err = chromedp.Run(extractCtx, chromedp.Attributes(imageSelector, &imgAttrs, chromedp.ByQuery, chromedp.FromNode(node)))
 		if err != nil {
-			log.Printf("[Product %d] Failed to extract image with selector '%s' (attr '%s'): %v", i+1, imageSelector, imageAttr, err)
+			log.Printf("[Product %d] Failed to extract image with selector '%s': %v", i+1, imageSelector, err)
 			cancelExtract()
 			continue
 		}
+		img = resolveImageURL(imgAttrs, imageAttrs, searchURL)
+		if img == "" {
+			log.Printf("[Product %d] No usable image in attributes %v", i+1, imageAttrs)
+		}
 
 		// Extract link
 		err = chromedp.Run(extractCtx, chromedp.AttributeValue(linkSelector, "href", &link, nil, chromedp.ByQuery, chromedp.FromNode(node)))
@@ -327,6 +677,21 @@ func scrapeWithChromeDP(params scrapeProductParams) (ScrapeResult, error) {
 			continue
 		}
 
+		// Extract sponsorship and seller details. These are optional, so nothing here waits
+		// for an element to appear or skips the product on failure.
+		var tileText string
+		_ = chromedp.Run(extractCtx, chromedp.Text([]cdp.NodeID{node.NodeID}, &tileText, chromedp.ByNodeID))
+		sponsored := isSponsoredTile(tileText, link) || anyNodeExists(extractCtx, node, params.SponsoredSelectors)
+		soldBy, fulfilledBy := parseSellerText(tileText)
+		if t := firstNodeText(extractCtx, node, params.SellerSelectors); t != "" {
+			soldBy = t
+		}
+		if t := firstNodeText(extractCtx, node, params.FulfilledBySelectors); t != "" {
+			fulfilledBy = t
+		}
+		seller, thirdParty := resolveSeller(params.Platform, soldBy)
+		stock, clearance := parseStockText(tileText), isClearanceTile(tileText)
+
 		// --- DYNAMIC PRICE EXTRACTION ---
 		var priceFound bool
 
@@ -396,20 +761,21 @@ func scrapeWithChromeDP(params scrapeProductParams) (ScrapeResult, error) {
 		cancelExtract() // Release context resources for this iteration.
 
 		parsedPrice, _ := parsePrice(price)
-		absoluteLink := link
-		if !strings.HasPrefix(link, "http") {
-			baseURL, _ := url.Parse(searchURL)
-			relativeURL, _ := url.Parse(link)
-			if baseURL != nil && relativeURL != nil {
-				absoluteLink = baseURL.ResolveReference(relativeURL).String()
-			}
-		}
+		// Store the stable product URL, not the tracking/redirect variant we scraped.
+		absoluteLink := CanonicalLink(params.Platform, absoluteURL(searchURL, link))
 
 		product := ScrapedProduct{
 			Name:     strings.TrimSpace(name),
 			Price:    parsedPrice,
-			ImageURL: strings.TrimSpace(img),
+			ImageURL: img,
 			Link:     absoluteLink,
+
+			Sponsored:   sponsored,
+			Seller:      seller,
+			FulfilledBy: fulfilledBy,
+			ThirdParty:  thirdParty,
+			StockStatus: stock,
+			Clearance:   clearance,
 		}
 
 		if product.Name != "" && product.Price > 0 {
@@ -420,8 +786,32 @@ func scrapeWithChromeDP(params scrapeProductParams) (ScrapeResult, error) {
 	return result, nil
 }
 
-func scrapeProducts(input scrapeProductParams) (ScrapeResult, error) {
-	scrapedData, err := scrapeWithChromeDP(input)
+// anyNodeExists reports whether any selector matches inside node, without waiting.
+func anyNodeExists(ctx context.Context, node *cdp.Node, selectors []string) bool {
+	for _, selector := range selectors {
+		var found []*cdp.Node
+		err := chromedp.Run(ctx, chromedp.Nodes(selector, &found, chromedp.ByQueryAll, chromedp.FromNode(node), chromedp.AtLeast(0)))
+		if err == nil && len(found) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// firstNodeText returns the text of the first selector that matches inside node, without waiting.
+func firstNodeText(ctx context.Context, node *cdp.Node, selectors []string) string {
+	for _, selector := range selectors {
+		var text string
+		err := chromedp.Run(ctx, chromedp.Text(selector, &text, chromedp.ByQuery, chromedp.FromNode(node), chromedp.AtLeast(0)))
+		if err == nil && strings.TrimSpace(text) != "" {
+			return strings.TrimSpace(text)
+		}
+	}
+	return ""
+}
+
+func scrapeProducts(ctx context.Context, b *Browser, input scrapeProductParams) (ScrapeResult, error) {
+	scrapedData, err := scrapeWithChromeDP(ctx, b, input)
 	if err != nil {
 		return ScrapeResult{}, fmt.Errorf("failed to scrape %s: %w", input.Platform, err)
 	}
@@ -461,12 +851,30 @@ func scrapeProducts(input scrapeProductParams) (ScrapeResult, error) {
 	return ScrapeResult{Products: filteredProducts, Platform: input.Platform}, nil
 }
 
-// GetProductSuggestions returns a list of product names for search-as-you-type suggestions.
-// It only queries the database if the search term is 2 or more characters long.
+// suggestionLimit caps how many names GetProductSuggestions returns.
+const suggestionLimit = 10
+
+// GetProductSuggestions returns product names and popular past searches for
+// search-as-you-type suggestions, tolerating typos.
+// It only looks anything up if the search term is 2 or more characters long.
 func (s *service) GetProductSuggestions(name string) ([]string, error) {
-	// To avoid excessive queries, only search if the input is non-trivial.
+	// To avoid noisy suggestions, only search if the input is non-trivial.
 	if len(name) < 2 {
 		return []string{}, nil // Return empty slice if not enough characters
 	}
-	return s.repo.GetProductNamesByName(name)
+	return s.suggester.Suggest(name, suggestionLimit), nil
+}
+
+// RecordSuggestionClick counts a user picking a suggestion, which ranks it higher next time.
+func (s *service) RecordSuggestionClick(text string) {
+	s.suggester.RecordClick(text)
+}
+
+// countProducts returns the total number of products across all platforms.
+func countProducts(results []ScrapeResult) int {
+	n := 0
+	for _, r := range results {
+		n += len(r.Products)
+	}
+	return n
 }
@@ -0,0 +1,37 @@
+package product
+
+import "regexp"
+
+// StockStatus is whether a listing could be bought when it was scraped.
+type StockStatus string
+
+const (
+	StockUnknown    StockStatus = ""
+	StockInStock    StockStatus = "IN_STOCK"
+	StockOutOfStock StockStatus = "OUT_OF_STOCK"
+)
+
+// Patterns used to read availability from a tile's visible text. Out-of-stock wording
+// wins over in-stock wording, as tiles often keep a disabled "Add to cart" button.
+var (
+	outOfStockPattern = regexp.MustCompile(`(?i)\b(out of stock|sold out|currently unavailable|no longer available|unavailable online|temporarily unavailable)\b`)
+	inStockPattern    = regexp.MustCompile(`(?i)\b(in stock|add to (cart|bag|trolley)|available (now|online|for delivery))\b`)
+	clearancePattern  = regexp.MustCompile(`(?i)\bclearance\b`)
+)
+
+// parseStockText reads a tile's availability from its text.
+func parseStockText(text string) StockStatus {
+	switch {
+	case outOfStockPattern.MatchString(text):
+		return StockOutOfStock
+	case inStockPattern.MatchString(text):
+		return StockInStock
+	default:
+		return StockUnknown
+	}
+}
+
+// isClearanceTile reports whether a tile marks its price as a clearance price.
+func isClearanceTile(text string) bool {
+	return clearancePattern.MatchString(text)
+}
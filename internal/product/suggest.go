@@ -0,0 +1,469 @@
+package product
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Suggester is an in-memory search-as-you-type engine over product names and past
+// search queries. Every word start of an entry is indexed in a prefix trie, so
+// "pro" finds "AirPods Pro"; lookups tolerate typos and rank by popularity.
+// Each trie node keeps its subtree's most popular entries, so a lookup ranks while
+// it walks instead of collecting whole subtrees. Entries are capped in number and
+// length; past the cap the least popular are evicted.
+type Suggester struct {
+	mu        sync.RWMutex
+	root      *trieNode
+	entries   map[string]*suggestion // normalised text -> entry
+	byListing map[uint]*suggestion   // stored listing ID -> the entry of its name
+	added     uint64                 // entries ever added, to order them by age
+}
+
+// suggestion is one suggestable text and its popularity signals.
+type suggestion struct {
+	key       string // normalised text
+	text      string // display form, as first seen
+	isProduct bool   // a stored product name (as opposed to only a past query)
+	listings  []uint // the stored listings with this name, when known
+	// rank is the popularity the trie's top lists are ordered by, updated under the write lock.
+	rank        float64
+	seq         uint64 // when the entry was added; older entries are evicted first on ties
+	queries     atomic.Int64
+	clicks      atomic.Int64
+	impressions atomic.Int64
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	// ends holds the entries whose indexed word suffix ends here.
+	ends []trieEnd
+	// top holds the best-ranked entries ending in this subtree, at most suggestTopK, best first.
+	top []trieEnd
+}
+
+type trieEnd struct {
+	entry *suggestion
+	// fromStart is true when the suffix is the whole text, not a later word.
+	fromStart bool
+}
+
+const (
+	// suggestTopK is how many of its subtree's entries each trie node keeps for lookups.
+	suggestTopK = 20
+	// maxSuggestEntries caps the number of entries; adding past it evicts the least
+	// popular tenth.
+	maxSuggestEntries = 50000
+	// maxSuggestionRunes caps an entry's length; longer texts are cut at a word boundary.
+	maxSuggestionRunes = 80
+	// Typo tolerance by normalised query length: none for very short input,
+	// one edit from fuzzyOneEditLen runes, two from fuzzyTwoEditsLen.
+	fuzzyOneEditLen  = 4
+	fuzzyTwoEditsLen = 8
+)
+
+// NewSuggester creates an empty suggestion engine.
+func NewSuggester() *Suggester {
+	return &Suggester{root: &trieNode{}, entries: make(map[string]*suggestion), byListing: make(map[uint]*suggestion)}
+}
+
+// Index adds the names of stored products, so it can be fed like a SearchIndex.
+func (s *Suggester) Index(products []Product) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range products {
+		e := s.add(p.Name)
+		if e == nil {
+			continue
+		}
+		if p.ID != 0 && s.byListing[p.ID] != e {
+			// A listing renamed since it was indexed no longer backs its old name.
+			s.unlink(p.ID)
+			s.byListing[p.ID] = e
+			e.listings = append(e.listings, p.ID)
+		}
+		if !e.isProduct {
+			e.isProduct = true
+			s.rerank(e)
+		}
+	}
+	return nil
+}
+
+// Remove forgets deleted listings. A name no stored listing has any more stops being
+// a product name: it is dropped, or kept with only its query popularity if it was
+// also searched for.
+func (s *Suggester) Remove(ids ...uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		s.unlink(id)
+	}
+}
+
+// unlink detaches a listing from the entry of its name; the caller holds the write lock.
+func (s *Suggester) unlink(id uint) {
+	e, ok := s.byListing[id]
+	if !ok {
+		return
+	}
+	delete(s.byListing, id)
+	e.listings = slices.DeleteFunc(e.listings, func(l uint) bool { return l == id })
+	if len(e.listings) > 0 {
+		return
+	}
+	s.remove(e)
+	if e.queries.Load() == 0 {
+		return
+	}
+	// Ranks only grow in place, so the entry is added again at its lower rank.
+	if kept := s.add(e.text); kept != nil {
+		kept.seq = e.seq
+		kept.queries.Store(e.queries.Load())
+		kept.clicks.Store(e.clicks.Load())
+		kept.impressions.Store(e.impressions.Load())
+		s.rerank(kept)
+	}
+}
+
+// RecordQuery counts a search for query. Queries that found nothing are not
+// added as suggestions, but still count towards an existing entry.
+func (s *Suggester) RecordQuery(query string, resultCount int) {
+	key := suggestionKey(query)
+	if key == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entries[key]
+	if e == nil {
+		if resultCount == 0 {
+			return
+		}
+		e = s.add(query)
+	}
+	e.queries.Add(1)
+	s.rerank(e)
+}
+
+// RecordClick counts a user picking text from the suggestions.
+func (s *Suggester) RecordClick(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.entries[suggestionKey(text)]; e != nil {
+		e.clicks.Add(1)
+		s.rerank(e)
+	}
+}
+
+// add registers text and indexes each of its word starts; the caller holds the write lock.
+func (s *Suggester) add(text string) *suggestion {
+	text = suggestionText(text)
+	key := normaliseSuggestion(text)
+	if key == "" {
+		return nil
+	}
+	if e, ok := s.entries[key]; ok {
+		return e
+	}
+	if len(s.entries) >= maxSuggestEntries {
+		s.evict()
+	}
+	s.added++
+	e := &suggestion{key: key, text: text, seq: s.added}
+	e.rank = e.popularity()
+	s.entries[key] = e
+
+	for i, suffix := range wordSuffixes(key) {
+		end := trieEnd{entry: e, fromStart: i == 0}
+		node := s.root
+		for _, r := range suffix {
+			if node.children == nil {
+				node.children = make(map[rune]*trieNode)
+			}
+			child, ok := node.children[r]
+			if !ok {
+				child = &trieNode{}
+				node.children[r] = child
+			}
+			node = child
+			node.offer(end)
+		}
+		node.ends = append(node.ends, end)
+	}
+	return e
+}
+
+// rerank updates e's rank and its place in the top lists along its suffixes; the
+// caller holds the write lock. Ranks only grow, so offering e again is enough.
+func (s *Suggester) rerank(e *suggestion) {
+	e.rank = e.popularity()
+	for i, suffix := range wordSuffixes(e.key) {
+		end := trieEnd{entry: e, fromStart: i == 0}
+		for _, node := range s.path(suffix)[1:] {
+			node.offer(end)
+		}
+	}
+}
+
+// evict drops the least popular tenth of the entries; the caller holds the write lock.
+func (s *Suggester) evict() {
+	all := make([]*suggestion, 0, len(s.entries))
+	for _, e := range s.entries {
+		all = append(all, e)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].rank != all[j].rank {
+			return all[i].rank < all[j].rank
+		}
+		return all[i].seq < all[j].seq
+	})
+	for _, e := range all[:len(all)-maxSuggestEntries*9/10] {
+		s.remove(e)
+	}
+}
+
+// remove unindexes e, pruning emptied trie nodes and refilling the top lists it was
+// in; the caller holds the write lock.
+func (s *Suggester) remove(e *suggestion) {
+	delete(s.entries, e.key)
+	for _, id := range e.listings {
+		delete(s.byListing, id)
+	}
+	for _, suffix := range wordSuffixes(e.key) {
+		runes := []rune(suffix)
+		path := s.path(suffix)
+		if len(path) != len(runes)+1 {
+			continue
+		}
+		last := path[len(path)-1]
+		last.ends = slices.DeleteFunc(last.ends, func(end trieEnd) bool { return end.entry == e })
+
+		for i := len(path) - 1; i >= 1; i-- {
+			node := path[i]
+			if len(node.ends) == 0 && len(node.children) == 0 {
+				delete(path[i-1].children, runes[i-1])
+				continue
+			}
+			if slices.ContainsFunc(node.top, func(end trieEnd) bool { return end.entry == e }) {
+				node.refillTop()
+			}
+		}
+	}
+}
+
+// path returns the nodes from the root along suffix, stopping early where the trie does.
+func (s *Suggester) path(suffix string) []*trieNode {
+	path := []*trieNode{s.root}
+	node := s.root
+	for _, r := range suffix {
+		child, ok := node.children[r]
+		if !ok {
+			break
+		}
+		node = child
+		path = append(path, node)
+	}
+	return path
+}
+
+// offer adds end to the node's top list if it ranks among the best, or re-sorts the
+// list if its entry is already there.
+func (n *trieNode) offer(end trieEnd) {
+	i := slices.IndexFunc(n.top, func(t trieEnd) bool { return t.entry == end.entry })
+	switch {
+	case i >= 0:
+		n.top[i].fromStart = n.top[i].fromStart || end.fromStart
+	case len(n.top) < suggestTopK:
+		n.top = append(n.top, end)
+	case end.entry.rank > n.top[len(n.top)-1].entry.rank:
+		n.top[len(n.top)-1] = end
+	default:
+		return
+	}
+	sort.SliceStable(n.top, func(i, j int) bool {
+		if n.top[i].entry.rank != n.top[j].entry.rank {
+			return n.top[i].entry.rank > n.top[j].entry.rank
+		}
+		return n.top[i].entry.text < n.top[j].entry.text
+	})
+}
+
+// refillTop rebuilds the node's top list from its own entries and its children's lists.
+func (n *trieNode) refillTop() {
+	n.top = nil
+	for _, end := range n.ends {
+		n.offer(end)
+	}
+	for _, child := range n.children {
+		for _, end := range child.top {
+			n.offer(end)
+		}
+	}
+}
+
+// candidate is an entry matched by a lookup and how well it matched.
+type candidate struct {
+	entry     *suggestion
+	distance  int
+	fromStart bool
+	score     float64
+}
+
+// Suggest returns up to limit suggestions for a partially typed query, best first.
+func (s *Suggester) Suggest(query string, limit int) []string {
+	q := []rune(normaliseSuggestion(query))
+	if len(q) == 0 || limit <= 0 {
+		return []string{}
+	}
+	maxEdits := 0
+	switch {
+	case len(q) >= fuzzyTwoEditsLen:
+		maxEdits = 2
+	case len(q) >= fuzzyOneEditLen:
+		maxEdits = 1
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	found := make(map[*suggestion]candidate)
+	row := make([]int, len(q)+1)
+	for i := range row {
+		row[i] = i
+	}
+	for r, child := range s.root.children {
+		fuzzyWalk(child, r, 0, q, row, nil, maxEdits, found)
+	}
+
+	// Scores are computed once up front, since the counters behind them change concurrently.
+	candidates := make([]candidate, 0, len(found))
+	for _, c := range found {
+		c.score = suggestionScore(c)
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].entry.text < candidates[j].entry.text
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	texts := make([]string, len(candidates))
+	for i, c := range candidates {
+		c.entry.impressions.Add(1)
+		texts[i] = c.entry.text
+	}
+	return texts
+}
+
+// fuzzyWalk extends the edit-distance row for query q by rune r at node, where prevRune
+// and prevPrev are the path's previous rune and row. Swapped adjacent letters count as a
+// single edit. Wherever the whole query is within maxEdits of the path so far,
+// the node's most popular entries match.
+func fuzzyWalk(node *trieNode, r, prevRune rune, q []rune, prev, prevPrev []int, maxEdits int, found map[*suggestion]candidate) {
+	row := make([]int, len(prev))
+	row[0] = prev[0] + 1
+	minCost := row[0]
+	for i := 1; i < len(row); i++ {
+		cost := 1
+		if q[i-1] == r {
+			cost = 0
+		}
+		row[i] = min(prev[i]+1, row[i-1]+1, prev[i-1]+cost)
+		if i > 1 && prevPrev != nil && q[i-1] == prevRune && q[i-2] == r {
+			row[i] = min(row[i], prevPrev[i-2]+1)
+		}
+		minCost = min(minCost, row[i])
+	}
+	if minCost > maxEdits {
+		return
+	}
+
+	if d := row[len(q)]; d <= maxEdits {
+		collectTop(node, d, found)
+		if d == 0 {
+			// Nothing below can match better than exactly.
+			return
+		}
+	}
+	for cr, child := range node.children {
+		fuzzyWalk(child, cr, r, q, row, prev, maxEdits, found)
+	}
+}
+
+// collectTop records the node's most popular entries with the given edit distance,
+// keeping the best match per entry.
+func collectTop(node *trieNode, distance int, found map[*suggestion]candidate) {
+	for _, end := range node.top {
+		c, seen := found[end.entry]
+		if !seen || distance < c.distance || (distance == c.distance && end.fromStart && !c.fromStart) {
+			found[end.entry] = candidate{entry: end.entry, distance: distance, fromStart: end.fromStart}
+		}
+	}
+}
+
+// popularity is how often e was searched and picked, plus a bonus for stored products.
+func (e *suggestion) popularity() float64 {
+	p := 1 + math.Log1p(float64(e.queries.Load())) + 2*math.Log1p(float64(e.clicks.Load()))
+	if e.isProduct {
+		p += 0.5
+	}
+	return p
+}
+
+// suggestionScore combines match quality with popularity: how often the text was searched,
+// how often it was picked, and how often it was picked when shown (click-through rate).
+func suggestionScore(c candidate) float64 {
+	e := c.entry
+	popularity := e.popularity()
+	if impressions := float64(e.impressions.Load()); impressions > 0 {
+		popularity += 2 * float64(e.clicks.Load()) / impressions
+	}
+
+	match := 1 / float64(1+2*c.distance)
+	if c.fromStart {
+		match *= 1.5
+	}
+	return popularity * match
+}
+
+// normaliseSuggestion lower-cases text and collapses it to single-spaced words.
+func normaliseSuggestion(text string) string {
+	return strings.Join(SearchTokens(text), " ")
+}
+
+// suggestionText trims text to at most maxSuggestionRunes runes, cutting at a word boundary.
+func suggestionText(text string) string {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= maxSuggestionRunes {
+		return text
+	}
+	runes := []rune(text)
+	if i := strings.LastIndexFunc(string(runes[:maxSuggestionRunes+1]), unicode.IsSpace); i > 0 {
+		return strings.TrimSpace(string(runes[:maxSuggestionRunes+1])[:i])
+	}
+	return string(runes[:maxSuggestionRunes])
+}
+
+// suggestionKey returns the entry key text is stored under.
+func suggestionKey(text string) string {
+	return normaliseSuggestion(suggestionText(text))
+}
+
+// wordSuffixes returns the suffixes of a normalised key that start at a word, the whole key first.
+func wordSuffixes(key string) []string {
+	words := strings.Fields(key)
+	suffixes := make([]string, len(words))
+	for i := range words {
+		suffixes[i] = strings.Join(words[i:], " ")
+	}
+	return suffixes
+}
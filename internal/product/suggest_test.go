@@ -0,0 +1,36 @@
+package product
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSuggesterRemove(t *testing.T) {
+	s := NewSuggester()
+	_ = s.Index([]Product{
+		{ID: 1, Name: "Sony WH-1000XM5"},
+		{ID: 2, Name: "Sony WH-1000XM5"},
+		{ID: 3, Name: "Sony WF-1000XM4"},
+		{ID: 4, Name: "Sony SRS-XB13"},
+	})
+	s.RecordQuery("Sony SRS-XB13", 1)
+
+	s.Remove(1, 3, 4)
+	got := s.Suggest("sony", 10)
+	if !slices.Contains(got, "Sony WH-1000XM5") {
+		t.Errorf("Suggest = %v, lost a name another listing still has", got)
+	}
+	if slices.Contains(got, "Sony WF-1000XM4") {
+		t.Errorf("Suggest = %v, kept a purged listing", got)
+	}
+	if !slices.Contains(got, "Sony SRS-XB13") {
+		t.Errorf("Suggest = %v, dropped a name that was searched for", got)
+	}
+
+	// A listing renamed since it was indexed only backs its new name.
+	_ = s.Index([]Product{{ID: 2, Name: "Sony WH-1000XM5 Black"}})
+	s.Remove(2)
+	if got := s.Suggest("sony wh", 10); len(got) != 0 {
+		t.Errorf("Suggest = %v after every listing was purged", got)
+	}
+}
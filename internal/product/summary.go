@@ -0,0 +1,94 @@
+package product
+
+import (
+	"math"
+	"sort"
+)
+
+// SearchResponse is a search's listings with their summary.
+type SearchResponse struct {
+	Results []ScrapeResult
+	Summary SearchSummary
+}
+
+// SearchSummary is the best deal among a search's offers and how the platforms fared.
+// Offers that are unpriced or out of stock aren't counted; without any offers, the
+// price fields are zero and the platform fields empty.
+type SearchSummary struct {
+	// Offers is how many offers the prices below are taken from.
+	Offers int
+	// LowestPrice is the cheapest offer, found at LowestPlatform as listing LowestListingID.
+	LowestPrice     float64
+	LowestPlatform  string
+	LowestListingID uint
+	MedianPrice     float64
+	HighestPrice    float64
+	// PriceSpread is the gap between the dearest and the cheapest offer.
+	PriceSpread float64
+	// HighestPlatform is where the dearest offer is; PotentialSaving is what buying the
+	// cheapest offer saves over it.
+	HighestPlatform string
+	PotentialSaving float64
+	// PotentialSavingPercent is PotentialSaving as a percentage of HighestPrice.
+	PotentialSavingPercent float64
+
+	// PlatformsSearched counts the platforms looked up, of which PlatformsSucceeded
+	// answered (with or without offers) and PlatformsFailed couldn't be scraped.
+	PlatformsSearched  int
+	PlatformsSucceeded int
+	PlatformsFailed    int
+	FailedPlatforms    []string
+}
+
+// Summarize works out the summary of a search's results, given the platforms searched
+// and those among them that failed.
+func Summarize(results []ScrapeResult, searched, failed []string) SearchSummary {
+	sum := SearchSummary{
+		PlatformsSearched:  len(searched),
+		PlatformsSucceeded: len(searched) - len(failed),
+		PlatformsFailed:    len(failed),
+		FailedPlatforms:    failed,
+	}
+
+	var prices []float64
+	for _, res := range results {
+		for _, p := range res.Products {
+			if p.Price <= 0 || p.StockStatus == StockOutOfStock {
+				continue
+			}
+			prices = append(prices, p.Price)
+			// Ties go to the platform first by name, then the lower listing ID, whatever
+			// order results come in.
+			if sum.Offers == 0 || p.Price < sum.LowestPrice || (p.Price == sum.LowestPrice &&
+				(res.Platform < sum.LowestPlatform || (res.Platform == sum.LowestPlatform && p.ListingID < sum.LowestListingID))) {
+				sum.LowestPrice = p.Price
+				sum.LowestPlatform = res.Platform
+				sum.LowestListingID = p.ListingID
+			}
+			if p.Price > sum.HighestPrice || (p.Price == sum.HighestPrice && res.Platform < sum.HighestPlatform) {
+				sum.HighestPrice = p.Price
+				sum.HighestPlatform = res.Platform
+			}
+			sum.Offers++
+		}
+	}
+	if sum.Offers == 0 {
+		return sum
+	}
+
+	sort.Float64s(prices)
+	mid := len(prices) / 2
+	if len(prices)%2 == 1 {
+		sum.MedianPrice = prices[mid]
+	} else {
+		sum.MedianPrice = roundCents((prices[mid-1] + prices[mid]) / 2)
+	}
+	sum.PriceSpread = roundCents(sum.HighestPrice - sum.LowestPrice)
+	sum.PotentialSaving = sum.PriceSpread
+	sum.PotentialSavingPercent = math.Round(sum.PotentialSaving/sum.HighestPrice*1000) / 10
+	return sum
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,64 @@
+package product
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	results := []ScrapeResult{
+		{Platform: "bestbuy", Products: []ScrapedProduct{{ListingID: 1, Price: 249}, {ListingID: 2, Price: 0}}},
+		{Platform: "amazon", Products: []ScrapedProduct{{ListingID: 3, Price: 199}, {ListingID: 4, Price: 299}}},
+		{Platform: "walmart", Products: []ScrapedProduct{{ListingID: 5, Price: 150, StockStatus: StockOutOfStock}}},
+	}
+	got := Summarize(results, []string{"amazon", "bestbuy", "target", "walmart"}, []string{"target"})
+	want := SearchSummary{
+		Offers:                 3,
+		LowestPrice:            199,
+		LowestPlatform:         "amazon",
+		LowestListingID:        3,
+		MedianPrice:            249,
+		HighestPrice:           299,
+		PriceSpread:            100,
+		HighestPlatform:        "amazon",
+		PotentialSaving:        100,
+		PotentialSavingPercent: 33.4,
+		PlatformsSearched:      4,
+		PlatformsSucceeded:     3,
+		PlatformsFailed:        1,
+		FailedPlatforms:        []string{"target"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := Summarize(nil, nil, nil); got.Offers != 0 || got.LowestPlatform != "" || got.PotentialSaving != 0 {
+		t.Errorf("Summarize without offers = %+v", got)
+	}
+}
+
+func TestSummarizeTiesIgnoreOrder(t *testing.T) {
+	// Every platform has offers at both the lowest and the highest price.
+	results := []ScrapeResult{
+		{Platform: "walmart", Products: []ScrapedProduct{{ListingID: 9, Price: 99}, {ListingID: 10, Price: 150}}},
+		{Platform: "amazon", Products: []ScrapedProduct{{ListingID: 7, Price: 150}, {ListingID: 6, Price: 99}, {ListingID: 5, Price: 99}}},
+		{Platform: "bestbuy", Products: []ScrapedProduct{{ListingID: 1, Price: 99}, {ListingID: 2, Price: 150}}},
+	}
+	for i := range 50 {
+		shuffled := make([]ScrapeResult, len(results))
+		for j, res := range results {
+			shuffled[j] = ScrapeResult{Platform: res.Platform, Products: append([]ScrapedProduct(nil), res.Products...)}
+			rand.Shuffle(len(shuffled[j].Products), func(a, b int) {
+				shuffled[j].Products[a], shuffled[j].Products[b] = shuffled[j].Products[b], shuffled[j].Products[a]
+			})
+		}
+		rand.Shuffle(len(shuffled), func(a, b int) { shuffled[a], shuffled[b] = shuffled[b], shuffled[a] })
+
+		got := Summarize(shuffled, nil, nil)
+		if got.LowestPlatform != "amazon" || got.LowestListingID != 5 || got.HighestPlatform != "amazon" {
+			t.Fatalf("run %d: lowest %s #%d, highest %s; want amazon #5, amazon",
+				i, got.LowestPlatform, got.LowestListingID, got.HighestPlatform)
+		}
+	}
+}
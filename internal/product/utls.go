@@ -2,6 +2,7 @@ package product
 
 import (
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 )
@@ -26,3 +27,18 @@ func parsePrice(s string) (float64, error) {
 	}
 	return strconv.ParseFloat(strings.TrimSpace(s), 64)
 }
+
+// absoluteURL resolves ref against the page it was found on.
+// Already-absolute URLs are returned unchanged.
+func absoluteURL(pageURL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "http") {
+		return ref
+	}
+	baseURL, _ := url.Parse(pageURL)
+	relativeURL, _ := url.Parse(ref)
+	if baseURL == nil || relativeURL == nil {
+		return ref
+	}
+	return baseURL.ResolveReference(relativeURL).String()
+}
@@ -0,0 +1,96 @@
+package purchase
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AlertStatus is where a price-protection alert is in its life.
+type AlertStatus string
+
+const (
+	// AlertClaimable is a price drop the user can still claim a refund for.
+	AlertClaimable AlertStatus = "CLAIMABLE"
+	// AlertClaimed is a refund the user says they have claimed.
+	AlertClaimed AlertStatus = "CLAIMED"
+	// AlertDismissed is a drop the user chose not to claim.
+	AlertDismissed AlertStatus = "DISMISSED"
+	// AlertExpired is a claimable drop whose claim window has closed. It is never
+	// stored; Alert.State reports it.
+	AlertExpired AlertStatus = "EXPIRED"
+)
+
+// Purchase is something a user bought at a retailer, tied to the listing that is
+// re-checked while the retailer's price protection lasts.
+type Purchase struct {
+	ID        string `gorm:"type:varchar(36);primaryKey"`
+	UserID    string `gorm:"type:varchar(36);not null;index"`
+	ListingID uint   `gorm:"not null;index"`
+	// Retailer, ProductName and Link are copied from the listing when the purchase is recorded.
+	Retailer    string  `gorm:"type:varchar(64);not null"`
+	ProductName string  `gorm:"type:varchar(255);not null"`
+	Link        string  `gorm:"type:varchar(700);not null"`
+	PricePaid   float64 `gorm:"not null"`
+	PurchasedAt time.Time
+	// ProtectionEndsAt is when the retailer stops refunding price drops; nil when it
+	// offers no price protection.
+	ProtectionEndsAt *time.Time `gorm:"index"`
+	// LowestPrice is the lowest price seen since the purchase, starting at PricePaid.
+	LowestPrice   float64 `gorm:"not null"`
+	LastCheckedAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (Purchase) TableName() string { return "purchases" }
+
+func (p *Purchase) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Protected reports whether price drops are still refunded at now.
+func (p *Purchase) Protected(now time.Time) bool {
+	return p.ProtectionEndsAt != nil && now.Before(*p.ProtectionEndsAt)
+}
+
+// Alert is a drop in the price of a purchase, within its protection window, that the
+// retailer should refund. A purchase has at most one claimable alert, which follows
+// the price down as it keeps dropping.
+type Alert struct {
+	ID         string      `gorm:"type:varchar(36);primaryKey"`
+	PurchaseID string      `gorm:"type:varchar(36);not null;index"`
+	UserID     string      `gorm:"type:varchar(36);not null;index"`
+	Status     AlertStatus `gorm:"type:varchar(16);not null"`
+	PricePaid  float64     `gorm:"not null"` // what the refund is counted from
+	NewPrice   float64     `gorm:"not null"`
+	Refund     float64     `gorm:"not null"`
+	ObservedAt time.Time   `gorm:"not null"`
+	ClaimBy    time.Time   `gorm:"not null"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	// Purchase is loaded with the user's alerts, never saved through them.
+	Purchase *Purchase `gorm:"foreignKey:PurchaseID"`
+}
+
+func (Alert) TableName() string { return "price_protection_alerts" }
+
+func (a *Alert) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// State is the alert's status at now: a claimable alert past ClaimBy has expired.
+func (a *Alert) State(now time.Time) AlertStatus {
+	if a.Status == AlertClaimable && !now.Before(a.ClaimBy) {
+		return AlertExpired
+	}
+	return a.Status
+}
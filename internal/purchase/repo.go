@@ -0,0 +1,38 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrPurchaseNotFound is returned for a purchase that doesn't exist or isn't the user's.
+	ErrPurchaseNotFound = errors.New("purchase not found")
+	// ErrAlertNotFound is returned for an alert that doesn't exist or isn't the user's.
+	ErrAlertNotFound = errors.New("price-protection alert not found")
+)
+
+// Repo defines the interface for purchase persistence.
+type Repo interface {
+	// Create stores a new purchase.
+	Create(ctx context.Context, p *Purchase) error
+	// ListByUser returns a user's purchases, most recent first.
+	ListByUser(ctx context.Context, userID string) ([]Purchase, error)
+	// Delete deletes one of a user's purchases with its alerts, or returns ErrPurchaseNotFound.
+	Delete(ctx context.Context, userID, id string) error
+	// Protected returns the purchases whose protection window is open at now.
+	Protected(ctx context.Context, now time.Time) ([]Purchase, error)
+	// Save stores a purchase's check state.
+	Save(ctx context.Context, p *Purchase) error
+
+	// Alerts returns a user's alerts with their purchases, newest first, optionally only
+	// with the given status.
+	Alerts(ctx context.Context, userID string, status AlertStatus) ([]Alert, error)
+	// PurchaseAlerts returns the alerts of the given purchases, newest first.
+	PurchaseAlerts(ctx context.Context, purchaseIDs []string) ([]Alert, error)
+	// GetAlert returns one of a user's alerts with its purchase, or ErrAlertNotFound.
+	GetAlert(ctx context.Context, userID, id string) (*Alert, error)
+	// SaveAlert creates or updates an alert, leaving its purchase as it is.
+	SaveAlert(ctx context.Context, a *Alert) error
+}
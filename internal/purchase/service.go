@@ -0,0 +1,407 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/infra/txn"
+	"never-price-match-server/internal/notify"
+	"never-price-match-server/internal/pricematch"
+	"never-price-match-server/internal/product"
+)
+
+var (
+	// ErrInvalidPrice is returned for a price paid that isn't positive.
+	ErrInvalidPrice = errors.New("price paid must be positive")
+	// ErrFuturePurchase is returned for a purchase date in the future.
+	ErrFuturePurchase = errors.New("purchase date is in the future")
+	// ErrAlertClosed is returned when claiming an alert that is no longer claimable.
+	ErrAlertClosed = errors.New("price-protection alert is no longer claimable")
+)
+
+// DefaultInterval is how often protected purchases are checked when Config.Interval is zero.
+const DefaultInterval = 6 * time.Hour
+
+// Config tunes the price-protection monitor.
+type Config struct {
+	// Interval is how often protected purchases are checked. A listing is re-scraped
+	// when nothing else has scraped it within the interval.
+	Interval time.Duration
+}
+
+// Summary is a purchase with its current price and alerts.
+type Summary struct {
+	Purchase Purchase
+	// CurrentPrice is the listing's latest price; nil when the listing has been purged.
+	CurrentPrice *float64
+	Alerts       []Alert
+	// Claimed is what the user has claimed back; Claimable what they still can.
+	Claimed   float64
+	Claimable float64
+}
+
+// Savings totals a user's price-protection refunds.
+type Savings struct {
+	Purchases int
+	Claimed   float64
+	Claimable float64
+}
+
+// Service defines the business logic interface for purchases and price protection.
+type Service interface {
+	// Record stores what a user paid for a listing. A zero purchasedAt means now. The
+	// protection window comes from the price-match policy of the listing's retailer.
+	Record(ctx context.Context, userID string, listingID uint, pricePaid float64, purchasedAt time.Time) (*Summary, error)
+	// List returns a user's purchases, most recent first.
+	List(ctx context.Context, userID string) ([]Summary, error)
+	// Delete removes one of a user's purchases and its alerts.
+	Delete(ctx context.Context, userID, id string) error
+	// Alerts returns a user's alerts, newest first; an empty status returns all of them.
+	Alerts(ctx context.Context, userID string, status AlertStatus) ([]Alert, error)
+	// Claim marks a claimable alert as claimed, or returns ErrAlertClosed.
+	Claim(ctx context.Context, userID, alertID string) (*Alert, error)
+	// Dismiss marks an alert that hasn't been claimed as dismissed.
+	Dismiss(ctx context.Context, userID, alertID string) (*Alert, error)
+	// Savings totals a user's claimed and claimable refunds.
+	Savings(ctx context.Context, userID string) (*Savings, error)
+	// Check re-checks the price of every protected purchase once and raises alerts for drops.
+	Check(ctx context.Context) error
+	// Start runs Check every Interval until ctx is cancelled.
+	Start(ctx context.Context)
+	// Close waits for a running check to finish.
+	Close()
+}
+
+type service struct {
+	repo     Repo
+	tx       txn.Runner
+	listings product.Repo
+	products product.Service
+	notifier notify.Service
+	cfg      Config
+	log      *logger.Logger
+	running  sync.WaitGroup
+}
+
+// NewService creates a new purchase service instance. Listings are read from listings
+// and re-scraped through products, and users are told of price drops through notifier.
+func NewService(repo Repo, tx txn.Runner, listings product.Repo, products product.Service, notifier notify.Service, cfg Config, log *logger.Logger) Service {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	return &service{repo: repo, tx: tx, listings: listings, products: products, notifier: notifier, cfg: cfg, log: log}
+}
+
+func (s *service) Record(ctx context.Context, userID string, listingID uint, pricePaid float64, purchasedAt time.Time) (*Summary, error) {
+	now := time.Now()
+	if pricePaid <= 0 {
+		return nil, ErrInvalidPrice
+	}
+	if purchasedAt.IsZero() {
+		purchasedAt = now
+	}
+	if purchasedAt.After(now) {
+		return nil, ErrFuturePurchase
+	}
+	listing, err := s.listings.GetProductByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Purchase{
+		UserID:      userID,
+		ListingID:   listing.ID,
+		Retailer:    listing.Platform,
+		ProductName: listing.Name,
+		Link:        listing.Link,
+		PricePaid:   pricePaid,
+		PurchasedAt: purchasedAt,
+		LowestPrice: pricePaid,
+	}
+	if policy, ok := pricematch.Lookup(listing.Platform); ok && policy.ProtectionDays > 0 {
+		ends := purchasedAt.AddDate(0, 0, policy.ProtectionDays)
+		p.ProtectionEndsAt = &ends
+	}
+	if err := s.repo.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	s.log.Info("purchase recorded", logger.Str("id", p.ID), logger.Str("retailer", p.Retailer))
+	return &Summary{Purchase: *p, CurrentPrice: &listing.Price}, nil
+}
+
+func (s *service) List(ctx context.Context, userID string) ([]Summary, error) {
+	purchases, err := s.repo.ListByUser(ctx, userID)
+	if err != nil || len(purchases) == 0 {
+		return nil, err
+	}
+	ids := make([]string, 0, len(purchases))
+	listingIDs := make([]uint, 0, len(purchases))
+	for _, p := range purchases {
+		ids = append(ids, p.ID)
+		listingIDs = append(listingIDs, p.ListingID)
+	}
+	alerts, err := s.repo.PurchaseAlerts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	listings, err := s.listings.GetProductsByIDs(ctx, listingIDs)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[uint]float64, len(listings))
+	for _, l := range listings {
+		prices[l.ID] = l.Price
+	}
+	byPurchase := make(map[string][]Alert)
+	for _, a := range alerts {
+		byPurchase[a.PurchaseID] = append(byPurchase[a.PurchaseID], a)
+	}
+
+	now := time.Now()
+	summaries := make([]Summary, 0, len(purchases))
+	for _, p := range purchases {
+		sum := Summary{Purchase: p, Alerts: byPurchase[p.ID]}
+		if price, ok := prices[p.ListingID]; ok {
+			sum.CurrentPrice = &price
+		}
+		sum.Claimed, sum.Claimable = refunds(sum.Alerts, now)
+		summaries = append(summaries, sum)
+	}
+	return summaries, nil
+}
+
+func (s *service) Delete(ctx context.Context, userID, id string) error {
+	return s.repo.Delete(ctx, userID, id)
+}
+
+func (s *service) Alerts(ctx context.Context, userID string, status AlertStatus) ([]Alert, error) {
+	stored := status
+	if status == AlertExpired {
+		stored = AlertClaimable
+	}
+	alerts, err := s.repo.Alerts(ctx, userID, stored)
+	if err != nil || status == "" {
+		return alerts, err
+	}
+	now := time.Now()
+	matching := alerts[:0]
+	for _, a := range alerts {
+		if a.State(now) == status {
+			matching = append(matching, a)
+		}
+	}
+	return matching, nil
+}
+
+func (s *service) Claim(ctx context.Context, userID, alertID string) (*Alert, error) {
+	return s.updateAlert(ctx, userID, alertID, AlertClaimed, func(state AlertStatus) bool {
+		return state == AlertClaimable
+	})
+}
+
+func (s *service) Dismiss(ctx context.Context, userID, alertID string) (*Alert, error) {
+	return s.updateAlert(ctx, userID, alertID, AlertDismissed, func(state AlertStatus) bool {
+		return state == AlertClaimable || state == AlertExpired
+	})
+}
+
+// updateAlert moves an alert to status if allowed says its current state permits it.
+func (s *service) updateAlert(ctx context.Context, userID, alertID string, status AlertStatus, allowed func(AlertStatus) bool) (*Alert, error) {
+	a, err := s.repo.GetAlert(ctx, userID, alertID)
+	if err != nil {
+		return nil, err
+	}
+	if a.Status == status {
+		return a, nil
+	}
+	if !allowed(a.State(time.Now())) {
+		return nil, ErrAlertClosed
+	}
+	a.Status = status
+	if err := s.repo.SaveAlert(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *service) Savings(ctx context.Context, userID string) (*Savings, error) {
+	purchases, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := s.repo.Alerts(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	savings := &Savings{Purchases: len(purchases)}
+	savings.Claimed, savings.Claimable = refunds(alerts, time.Now())
+	return savings, nil
+}
+
+// refunds totals the refunds of claimed alerts and of alerts that can still be claimed.
+func refunds(alerts []Alert, now time.Time) (claimed, claimable float64) {
+	for _, a := range alerts {
+		switch a.State(now) {
+		case AlertClaimed:
+			claimed += a.Refund
+		case AlertClaimable:
+			claimable += a.Refund
+		}
+	}
+	return roundCents(claimed), roundCents(claimable)
+}
+
+func (s *service) Check(ctx context.Context) error {
+	now := time.Now()
+	purchases, err := s.repo.Protected(ctx, now)
+	if err != nil || len(purchases) == 0 {
+		return err
+	}
+
+	byListing := make(map[uint][]Purchase)
+	var listingIDs []uint
+	for _, p := range purchases {
+		if _, ok := byListing[p.ListingID]; !ok {
+			listingIDs = append(listingIDs, p.ListingID)
+		}
+		byListing[p.ListingID] = append(byListing[p.ListingID], p)
+	}
+	listings, err := s.listings.GetProductsByIDs(ctx, listingIDs)
+	if err != nil {
+		return err
+	}
+
+	var raised int
+	for _, stored := range listings {
+		listing := s.recheck(ctx, stored, now)
+		for _, p := range byListing[listing.ID] {
+			ok, err := s.evaluate(ctx, &p, listing, now)
+			if err != nil {
+				s.log.Warn("Price-protection check failed", logger.Str("purchase", p.ID), logger.Err(err))
+				continue
+			}
+			if ok {
+				raised++
+			}
+		}
+	}
+	s.log.Info("Price-protection check done", logger.Int("purchases", len(purchases)), logger.Int("alerts", raised))
+	return ctx.Err()
+}
+
+// recheck re-scrapes a listing that nothing has scraped within the interval and
+// returns it as stored afterwards. Failures are logged and the stored listing used.
+func (s *service) recheck(ctx context.Context, listing product.Product, now time.Time) product.Product {
+	if now.Sub(listing.UpdatedAt) < s.cfg.Interval {
+		return listing
+	}
+	if _, err := s.products.Refresh(ctx, listing.Name, listing.Category, []string{listing.Platform}); err != nil {
+		s.log.Warn("Failed to re-scrape purchased listing", logger.Field("listing", listing.ID), logger.Err(err))
+		return listing
+	}
+	refreshed, err := s.listings.GetProductByID(ctx, listing.ID)
+	if err != nil {
+		s.log.Warn("Failed to reload purchased listing", logger.Field("listing", listing.ID), logger.Err(err))
+		return listing
+	}
+	return *refreshed
+}
+
+// evaluate records the listing's price against a purchase and raises or lowers its
+// claimable alert when the price is the lowest seen since the purchase. It reports
+// whether an alert was raised or updated.
+func (s *service) evaluate(ctx context.Context, p *Purchase, listing product.Product, now time.Time) (bool, error) {
+	price := listing.Price
+	p.LastCheckedAt = &now
+	if price <= 0 || price >= p.LowestPrice || listing.UpdatedAt.Before(p.PurchasedAt) {
+		return false, s.repo.Save(ctx, p)
+	}
+
+	var raised bool
+	var open *Alert
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		alerts, err := s.repo.PurchaseAlerts(ctx, []string{p.ID})
+		if err != nil {
+			return err
+		}
+		// A refund already claimed lowers what later drops are counted from.
+		paid := p.PricePaid
+		for i, a := range alerts {
+			switch a.Status {
+			case AlertClaimed:
+				paid = math.Min(paid, a.NewPrice)
+			case AlertClaimable:
+				if open == nil {
+					open = &alerts[i]
+				}
+			}
+		}
+		if open == nil {
+			open = &Alert{PurchaseID: p.ID, UserID: p.UserID, Status: AlertClaimable, ClaimBy: *p.ProtectionEndsAt}
+		}
+		open.PricePaid = paid
+		open.NewPrice = price
+		open.Refund = roundCents(paid - price)
+		open.ObservedAt = listing.UpdatedAt
+
+		p.LowestPrice = price
+		if raised = open.Refund > 0; raised {
+			if err := s.repo.SaveAlert(ctx, open); err != nil {
+				return err
+			}
+		}
+		return s.repo.Save(ctx, p)
+	})
+	if err != nil || !raised {
+		return false, err
+	}
+	s.log.Info("Price drop within protection window",
+		logger.Str("purchase", p.ID),
+		logger.Field("price_paid", p.PricePaid),
+		logger.Field("new_price", price),
+	)
+	err = s.notifier.Notify(ctx, p.UserID, notify.KindPriceProtection, p.Link, notify.PriceProtection{
+		ProductName: p.ProductName,
+		Retailer:    p.Retailer,
+		PricePaid:   open.PricePaid,
+		NewPrice:    open.NewPrice,
+		Refund:      open.Refund,
+		ClaimBy:     open.ClaimBy,
+	})
+	if err != nil {
+		// The alert is saved and claimable either way.
+		s.log.Warn("Failed to notify price drop", logger.Str("purchase", p.ID), logger.Err(err))
+	}
+	return true, nil
+}
+
+func (s *service) Start(ctx context.Context) {
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			if err := s.Check(ctx); err != nil && ctx.Err() == nil {
+				s.log.Warn("Price-protection check failed", logger.Err(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *service) Close() {
+	s.running.Wait()
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,214 @@
+package purchase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/notify"
+	"never-price-match-server/internal/product"
+)
+
+// The fakes embed their interface, so calling anything they don't override panics.
+type fakeRepo struct {
+	Repo
+	alerts  []Alert
+	created *Purchase
+	saved   []Alert
+}
+
+func (r *fakeRepo) Create(_ context.Context, p *Purchase) error {
+	r.created = p
+	return nil
+}
+
+func (r *fakeRepo) Save(context.Context, *Purchase) error { return nil }
+
+func (r *fakeRepo) PurchaseAlerts(context.Context, []string) ([]Alert, error) { return r.alerts, nil }
+
+func (r *fakeRepo) SaveAlert(_ context.Context, a *Alert) error {
+	r.saved = append(r.saved, *a)
+	return nil
+}
+
+type fakeListings struct {
+	product.Repo
+	listing product.Product
+}
+
+func (f *fakeListings) GetProductByID(context.Context, uint) (*product.Product, error) {
+	l := f.listing
+	return &l, nil
+}
+
+type fakeNotifier struct {
+	notify.Service
+	sent []notify.PriceProtection
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, _, _, _ string, data any) error {
+	f.sent = append(f.sent, data.(notify.PriceProtection))
+	return nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Run(ctx context.Context, fn func(ctx context.Context) error) error { return fn(ctx) }
+
+func TestProtected(t *testing.T) {
+	ends := time.Date(2026, 3, 24, 10, 30, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		ends *time.Time
+		at   time.Time
+		want bool
+	}{
+		{"no protection", nil, ends.AddDate(0, 0, -1), false},
+		{"inside the window", &ends, ends.AddDate(0, 0, -1), true},
+		{"last moment", &ends, ends.Add(-time.Nanosecond), true},
+		{"at the end", &ends, ends, false},
+		{"after the end", &ends, ends.Add(time.Hour), false},
+	}
+	for _, c := range cases {
+		p := Purchase{ProtectionEndsAt: c.ends}
+		if got := p.Protected(c.at); got != c.want {
+			t.Errorf("%s: Protected = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestAlertState(t *testing.T) {
+	claimBy := time.Date(2026, 3, 24, 10, 30, 0, 0, time.UTC)
+	cases := []struct {
+		status AlertStatus
+		at     time.Time
+		want   AlertStatus
+	}{
+		{AlertClaimable, claimBy.Add(-time.Nanosecond), AlertClaimable},
+		{AlertClaimable, claimBy, AlertExpired},
+		{AlertClaimable, claimBy.AddDate(0, 0, 1), AlertExpired},
+		{AlertClaimed, claimBy.AddDate(0, 0, 1), AlertClaimed},
+		{AlertDismissed, claimBy.AddDate(0, 0, 1), AlertDismissed},
+	}
+	for _, c := range cases {
+		a := Alert{Status: c.status, ClaimBy: claimBy}
+		if got := a.State(c.at); got != c.want {
+			t.Errorf("%s alert at %s: State = %s, want %s", c.status, c.at.Sub(claimBy), got, c.want)
+		}
+	}
+}
+
+func TestRecordProtectionWindow(t *testing.T) {
+	// Sydney's clocks go back an hour on 5 April 2026, inside JB Hi-Fi's 14 days.
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+	bought := time.Date(2026, 3, 28, 18, 45, 0, 0, sydney)
+	cases := []struct {
+		name     string
+		retailer string
+		want     *time.Time
+	}{
+		{"14 days", "JB Hi-Fi", ptr(time.Date(2026, 4, 11, 18, 45, 0, 0, sydney))},
+		{"7 days", "EB Games", ptr(time.Date(2026, 4, 4, 18, 45, 0, 0, sydney))},
+		{"retailer name in any case", "eb games", ptr(time.Date(2026, 4, 4, 18, 45, 0, 0, sydney))},
+		{"no price protection", "Bunnings", nil},
+		{"no policy", "Kmart", nil},
+	}
+	for _, c := range cases {
+		repo := &fakeRepo{}
+		listings := &fakeListings{listing: product.Product{ID: 7, Platform: c.retailer, Name: "Sony WH-1000XM5", Price: 399}}
+		s := NewService(repo, fakeTx{}, listings, nil, nil, Config{}, logger.Nop())
+
+		if _, err := s.Record(context.Background(), "u1", 7, 399, bought); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		got := repo.created.ProtectionEndsAt
+		if (got == nil) != (c.want == nil) || got != nil && !got.Equal(*c.want) {
+			t.Errorf("%s: protection ends %v, want %v", c.name, got, c.want)
+		}
+		if c.want != nil && !repo.created.Protected(c.want.AddDate(0, 0, -1)) {
+			t.Errorf("%s: not protected on the last day of the window", c.name)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	bought := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	ends := bought.AddDate(0, 0, 14)
+	now := bought.AddDate(0, 0, 3)
+	cases := []struct {
+		name    string
+		lowest  float64 // lowest price seen so far
+		price   float64
+		alerts  []Alert
+		scraped time.Time // when the listing's price was scraped
+
+		wantRaised bool
+		wantPaid   float64
+		wantRefund float64
+		wantLowest float64
+	}{
+		{name: "drop", lowest: 399, price: 349, wantRaised: true, wantPaid: 399, wantRefund: 50, wantLowest: 349},
+		{name: "same price", lowest: 399, price: 399, wantLowest: 399},
+		{name: "rise", lowest: 399, price: 429, wantLowest: 399},
+		{name: "no price", lowest: 399, price: 0, wantLowest: 399},
+		{name: "scraped before the purchase", lowest: 399, price: 299, scraped: bought.Add(-time.Minute), wantLowest: 399},
+		{
+			name: "open alert follows the price down", lowest: 349, price: 329,
+			alerts:     []Alert{{ID: "a1", Status: AlertClaimable, PricePaid: 399, NewPrice: 349, Refund: 50, ClaimBy: ends}},
+			wantRaised: true, wantPaid: 399, wantRefund: 70, wantLowest: 329,
+		},
+		{
+			name: "counted from the claimed refund", lowest: 349, price: 329,
+			alerts:     []Alert{{ID: "a1", Status: AlertClaimed, PricePaid: 399, NewPrice: 349, Refund: 50, ClaimBy: ends}},
+			wantRaised: true, wantPaid: 349, wantRefund: 20, wantLowest: 329,
+		},
+		{
+			name: "below the lowest but not the claimed price", lowest: 359, price: 355,
+			alerts:     []Alert{{ID: "a1", Status: AlertClaimed, PricePaid: 399, NewPrice: 349, Refund: 50, ClaimBy: ends}},
+			wantLowest: 355,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := &fakeRepo{alerts: c.alerts}
+			notifier := &fakeNotifier{}
+			s := NewService(repo, fakeTx{}, nil, nil, notifier, Config{}, logger.Nop()).(*service)
+			p := &Purchase{ID: "p1", UserID: "u1", PricePaid: 399, PurchasedAt: bought, ProtectionEndsAt: &ends, LowestPrice: c.lowest}
+			scraped := c.scraped
+			if scraped.IsZero() {
+				scraped = now
+			}
+
+			raised, err := s.evaluate(context.Background(), p, product.Product{Price: c.price, UpdatedAt: scraped}, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if raised != c.wantRaised || p.LowestPrice != c.wantLowest {
+				t.Errorf("raised %v, lowest %v; want %v, %v", raised, p.LowestPrice, c.wantRaised, c.wantLowest)
+			}
+			if !c.wantRaised {
+				if len(repo.saved) != 0 || len(notifier.sent) != 0 {
+					t.Errorf("saved %+v and sent %+v without a drop", repo.saved, notifier.sent)
+				}
+				return
+			}
+			if len(repo.saved) != 1 || len(notifier.sent) != 1 {
+				t.Fatalf("saved %d alerts and sent %d notifications, want 1 each", len(repo.saved), len(notifier.sent))
+			}
+			a := repo.saved[0]
+			if a.PricePaid != c.wantPaid || a.NewPrice != c.price || a.Refund != c.wantRefund || !a.ClaimBy.Equal(ends) {
+				t.Errorf("alert paid %v, new %v, refund %v, claim by %s; want %v, %v, %v, %s",
+					a.PricePaid, a.NewPrice, a.Refund, a.ClaimBy, c.wantPaid, c.price, c.wantRefund, ends)
+			}
+			if len(c.alerts) > 0 && c.alerts[0].Status == AlertClaimable && a.ID != c.alerts[0].ID {
+				t.Errorf("raised alert %q instead of updating the open one", a.ID)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
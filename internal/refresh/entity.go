@@ -0,0 +1,39 @@
+package refresh
+
+import (
+	"time"
+
+	"never-price-match-server/internal/infra/date"
+)
+
+// Kinds of refresh job.
+const (
+	// KindQuery re-scrapes a popular search term; Target is the normalised term.
+	KindQuery = "query"
+	// KindListing re-scrapes a watched listing; Target is the listing ID.
+	KindListing = "listing"
+)
+
+// Job is the persisted schedule of one refresh target, so restarts resume where they left off.
+type Job struct {
+	ID     uint   `gorm:"primarykey"`
+	Kind   string `gorm:"type:varchar(16);not null;uniqueIndex:idx_refresh_jobs_target,priority:1"`
+	Target string `gorm:"type:varchar(255);not null;uniqueIndex:idx_refresh_jobs_target,priority:2"`
+	// Category picks the job's schedule and, for queries, the platforms to scrape.
+	Category  string     `gorm:"type:varchar(64)"`
+	NextRunAt time.Time  `gorm:"not null;index"`
+	LastRunAt *time.Time // nil until the first run
+	LastError string     `gorm:"type:varchar(1024)"`
+	Runs      int        `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Job) TableName() string { return "refresh_jobs" }
+
+// ScrapeBudget counts the scheduled scrapes of one platform on one day.
+type ScrapeBudget struct {
+	Day      date.Date `gorm:"primaryKey;type:date"`
+	Platform string    `gorm:"primaryKey;type:varchar(64)"`
+	Used     int       `gorm:"not null"`
+}
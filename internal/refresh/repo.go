@@ -0,0 +1,27 @@
+package refresh
+
+import (
+	"context"
+	"time"
+
+	"never-price-match-server/internal/infra/date"
+)
+
+// Repo defines the interface for refresh job persistence.
+type Repo interface {
+	// EnsureJobs adds jobs for new targets; existing targets keep their schedule
+	// and only have their category updated.
+	EnsureJobs(ctx context.Context, jobs []Job) error
+	// DeleteJobsExcept removes the jobs of kind whose target is not in targets.
+	DeleteJobsExcept(ctx context.Context, kind string, targets []string) (int64, error)
+	// DueJobs returns up to limit jobs due at now, the most overdue first.
+	DueJobs(ctx context.Context, now time.Time, limit int) ([]Job, error)
+	// ClaimJob moves a job that is still due at now to leaseUntil, so no other instance
+	// runs it meanwhile, reporting false if another instance claimed it first.
+	ClaimJob(ctx context.Context, id uint, now, leaseUntil time.Time) (bool, error)
+	// SaveJob stores a job's run state and next run time.
+	SaveJob(ctx context.Context, job *Job) error
+	// ConsumeBudget uses one of the limit scrapes of platform on day, reporting
+	// false if none are left.
+	ConsumeBudget(ctx context.Context, day date.Date, platform string, limit int) (bool, error)
+}
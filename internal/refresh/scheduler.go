@@ -0,0 +1,362 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"never-price-match-server/internal/infra/date"
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/infra/strutil"
+	"never-price-match-server/internal/product"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// DefaultSchedule is the Schedules and DailyBudget key used when nothing more specific is configured.
+	DefaultSchedule = "default"
+	// WatchedSchedule is the Schedules key of watched listings.
+	WatchedSchedule = "watched"
+
+	// syncInterval is how often the set of jobs is matched to the current targets.
+	syncInterval = 15 * time.Minute
+	// dueBatchSize caps how many due jobs one check runs.
+	dueBatchSize = 20
+	// maxErrorLength is the size of Job.LastError, in runes.
+	maxErrorLength = 1024
+	// claimLease is how long a claimed job is kept from other instances. A run that
+	// finishes reschedules the job; one whose instance died is retried after this.
+	claimLease = 30 * time.Minute
+)
+
+// errNoBudget is recorded for a run that found every platform's daily budget spent.
+var errNoBudget = errors.New("daily scrape budget spent on every platform")
+
+// Query is a search term worth keeping fresh.
+type Query struct {
+	Term     string
+	Category string
+}
+
+// QuerySource supplies the most popular search terms.
+type QuerySource interface {
+	TopQueries(ctx context.Context, limit int) ([]Query, error)
+}
+
+// WatchSource supplies the IDs of the listings users watch.
+type WatchSource interface {
+	WatchedListings(ctx context.Context) ([]uint, error)
+}
+
+// Sources are where the scheduler finds its targets. Either may be nil.
+type Sources struct {
+	Queries QuerySource
+	Watches WatchSource
+}
+
+// Config tunes the scheduler.
+type Config struct {
+	// Schedules maps a category, or WatchedSchedule, to a five-field cron spec.
+	// Categories without one use the DefaultSchedule spec.
+	Schedules map[string]string
+	// Jitter is the most a run is delayed past its scheduled time, so jobs that
+	// share a schedule don't all scrape at once.
+	Jitter time.Duration
+	// DailyBudget caps the scheduled scrapes per platform and day, keyed by lower-case
+	// platform name with DefaultSchedule as the fallback. Without either there is no cap.
+	DailyBudget map[string]int
+	// TopQueries is how many of the most searched terms are kept fresh.
+	TopQueries int
+	// Interval is how often due jobs are checked.
+	Interval time.Duration
+}
+
+// DefaultConfig is used for any Config field left at its zero value.
+var DefaultConfig = Config{
+	Schedules:   map[string]string{DefaultSchedule: "0 */12 * * *", WatchedSchedule: "0 */4 * * *"},
+	Jitter:      10 * time.Minute,
+	DailyBudget: map[string]int{DefaultSchedule: 200},
+	TopQueries:  50,
+	Interval:    time.Minute,
+}
+
+// Scheduler periodically re-scrapes popular searches and watched listings.
+type Scheduler interface {
+	// RunOnce brings the jobs in line with the sources and runs the due ones.
+	RunOnce(ctx context.Context) error
+	// Start runs RunOnce every Interval until ctx is cancelled.
+	Start(ctx context.Context)
+	// Close waits for Start's loop to stop.
+	Close()
+}
+
+type scheduler struct {
+	repo      Repo
+	listings  product.Repo
+	products  product.Service
+	sources   Sources
+	cfg       Config
+	schedules map[string]cron.Schedule
+	log       *logger.Logger
+
+	lastSync time.Time
+	running  sync.WaitGroup
+}
+
+// NewScheduler creates a refresh scheduler. It fails if a schedule is not a valid cron spec.
+func NewScheduler(repo Repo, listings product.Repo, products product.Service, sources Sources, cfg Config, log *logger.Logger) (Scheduler, error) {
+	cfg = withDefaults(cfg)
+	schedules := make(map[string]cron.Schedule, len(cfg.Schedules))
+	for category, spec := range cfg.Schedules {
+		sched, err := cron.ParseStandard(spec)
+		if err != nil {
+			return nil, fmt.Errorf("refresh schedule %q: %w", category, err)
+		}
+		schedules[category] = sched
+	}
+	if _, ok := schedules[DefaultSchedule]; !ok {
+		schedules[DefaultSchedule], _ = cron.ParseStandard(DefaultConfig.Schedules[DefaultSchedule])
+	}
+	return &scheduler{
+		repo:      repo,
+		listings:  listings,
+		products:  products,
+		sources:   sources,
+		cfg:       cfg,
+		schedules: schedules,
+		log:       log,
+	}, nil
+}
+
+func withDefaults(cfg Config) Config {
+	if cfg.Schedules == nil {
+		cfg.Schedules = DefaultConfig.Schedules
+	}
+	if cfg.Jitter == 0 {
+		cfg.Jitter = DefaultConfig.Jitter
+	}
+	if cfg.DailyBudget == nil {
+		cfg.DailyBudget = DefaultConfig.DailyBudget
+	}
+	if cfg.TopQueries == 0 {
+		cfg.TopQueries = DefaultConfig.TopQueries
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultConfig.Interval
+	}
+	return cfg
+}
+
+func (s *scheduler) Start(ctx context.Context) {
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			if err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				s.log.Warn("Refresh run failed", logger.Err(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *scheduler) Close() {
+	s.running.Wait()
+}
+
+func (s *scheduler) RunOnce(ctx context.Context) error {
+	now := time.Now()
+	if now.Sub(s.lastSync) >= syncInterval {
+		if err := s.syncTargets(ctx, now); err != nil {
+			s.log.Warn("Failed to sync refresh targets", logger.Err(err))
+		} else {
+			s.lastSync = now
+		}
+	}
+
+	jobs, err := s.repo.DueJobs(ctx, now, dueBatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Other instances see the same due jobs; only the one that claims a job runs it.
+		claimed, err := s.repo.ClaimJob(ctx, jobs[i].ID, now, time.Now().Add(claimLease))
+		if err != nil {
+			return err
+		}
+		if claimed {
+			s.run(ctx, &jobs[i])
+		}
+	}
+	return nil
+}
+
+// syncTargets adds jobs for new top queries and watched listings and drops the jobs
+// of targets that are neither any more. New jobs wait for their first scheduled run.
+func (s *scheduler) syncTargets(ctx context.Context, now time.Time) error {
+	if s.sources.Queries != nil {
+		queries, err := s.sources.Queries.TopQueries(ctx, s.cfg.TopQueries)
+		if err != nil {
+			return err
+		}
+		jobs := make([]Job, 0, len(queries))
+		targets := make([]string, 0, len(queries))
+		for _, q := range queries {
+			jobs = append(jobs, Job{Kind: KindQuery, Target: q.Term, Category: q.Category, NextRunAt: s.nextRun(q.Category, now)})
+			targets = append(targets, q.Term)
+		}
+		if err := s.replaceJobs(ctx, KindQuery, jobs, targets); err != nil {
+			return err
+		}
+	}
+
+	if s.sources.Watches != nil {
+		ids, err := s.sources.Watches.WatchedListings(ctx)
+		if err != nil {
+			return err
+		}
+		jobs := make([]Job, 0, len(ids))
+		targets := make([]string, 0, len(ids))
+		for _, id := range ids {
+			target := strconv.FormatUint(uint64(id), 10)
+			jobs = append(jobs, Job{Kind: KindListing, Target: target, Category: WatchedSchedule, NextRunAt: s.nextRun(WatchedSchedule, now)})
+			targets = append(targets, target)
+		}
+		if err := s.replaceJobs(ctx, KindListing, jobs, targets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *scheduler) replaceJobs(ctx context.Context, kind string, jobs []Job, targets []string) error {
+	if err := s.repo.EnsureJobs(ctx, jobs); err != nil {
+		return err
+	}
+	removed, err := s.repo.DeleteJobsExcept(ctx, kind, targets)
+	if err != nil {
+		return err
+	}
+	if removed > 0 {
+		s.log.Info("Dropped refresh jobs", logger.Str("kind", kind), logger.Field("jobs", removed))
+	}
+	return nil
+}
+
+// run refreshes one job's target and schedules its next run, whatever the outcome.
+func (s *scheduler) run(ctx context.Context, job *Job) {
+	var found int
+	var err error
+	switch job.Kind {
+	case KindQuery:
+		found, err = s.refreshQuery(ctx, job)
+	case KindListing:
+		found, err = s.refreshListing(ctx, job)
+	default:
+		err = fmt.Errorf("unknown job kind %q", job.Kind)
+	}
+
+	now := time.Now()
+	job.LastRunAt = &now
+	job.Runs++
+	job.LastError = ""
+	if err != nil {
+		job.LastError = strutil.Truncate(err.Error(), maxErrorLength)
+		s.log.Warn("Refresh job failed", logger.Str("kind", job.Kind), logger.Str("target", job.Target), logger.Err(err))
+	} else {
+		s.log.Info("Refresh job done", logger.Str("kind", job.Kind), logger.Str("target", job.Target), logger.Int("listings", found))
+	}
+	job.NextRunAt = s.nextRun(job.Category, now)
+	if err := s.repo.SaveJob(ctx, job); err != nil {
+		s.log.Warn("Failed to save refresh job", logger.Str("target", job.Target), logger.Err(err))
+	}
+}
+
+func (s *scheduler) refreshQuery(ctx context.Context, job *Job) (int, error) {
+	platforms, err := s.withinBudget(ctx, product.CategoryPlatforms(job.Category))
+	if err != nil {
+		return 0, err
+	}
+	return s.products.Refresh(ctx, job.Target, job.Category, platforms)
+}
+
+// refreshListing re-runs the search the listing came from on its own platform,
+// which is how its stored price is updated.
+func (s *scheduler) refreshListing(ctx context.Context, job *Job) (int, error) {
+	id, err := strconv.ParseUint(job.Target, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid listing id %q", job.Target)
+	}
+	listing, err := s.listings.GetProductByID(ctx, uint(id))
+	if err != nil {
+		return 0, err
+	}
+	platforms, err := s.withinBudget(ctx, []string{listing.Platform})
+	if err != nil {
+		return 0, err
+	}
+	return s.products.Refresh(ctx, listing.Name, listing.Category, platforms)
+}
+
+// withinBudget takes one scrape from each platform's budget for today and returns
+// the platforms that still had one. Days are UTC dates, like the search log rollups
+// the popular queries come from.
+func (s *scheduler) withinBudget(ctx context.Context, platforms []string) ([]string, error) {
+	today := date.Of(time.Now())
+
+	var allowed []string
+	for _, platform := range platforms {
+		limit, capped := s.budget(platform)
+		if capped {
+			ok, err := s.repo.ConsumeBudget(ctx, today, platform, limit)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		allowed = append(allowed, platform)
+	}
+	if len(allowed) == 0 {
+		return nil, errNoBudget
+	}
+	return allowed, nil
+}
+
+// budget returns the daily scrape cap of platform, or false if it has none.
+func (s *scheduler) budget(platform string) (int, bool) {
+	if limit, ok := s.cfg.DailyBudget[strings.ToLower(platform)]; ok {
+		return limit, true
+	}
+	limit, ok := s.cfg.DailyBudget[DefaultSchedule]
+	return limit, ok
+}
+
+// nextRun is the next scheduled time of category after now, plus jitter.
+func (s *scheduler) nextRun(category string, now time.Time) time.Time {
+	sched, ok := s.schedules[category]
+	if !ok {
+		sched = s.schedules[DefaultSchedule]
+	}
+	next := sched.Next(now)
+	if s.cfg.Jitter > 0 {
+		next = next.Add(rand.N(s.cfg.Jitter))
+	}
+	return next
+}
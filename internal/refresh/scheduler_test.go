@@ -0,0 +1,235 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"never-price-match-server/internal/infra/date"
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/product"
+)
+
+// The fakes embed their interface, so calling anything they don't override panics.
+type fakeRepo struct {
+	Repo
+	due      []Job
+	taken    map[uint]bool // jobs another instance claimed first
+	claimErr error
+	used     map[string]int // scrapes used today, by platform
+	days     []date.Date    // the days budget was taken from
+	saved    []Job
+}
+
+func (r *fakeRepo) DueJobs(context.Context, time.Time, int) ([]Job, error) { return r.due, nil }
+
+func (r *fakeRepo) ClaimJob(_ context.Context, id uint, _, _ time.Time) (bool, error) {
+	return !r.taken[id], r.claimErr
+}
+
+func (r *fakeRepo) SaveJob(_ context.Context, job *Job) error {
+	r.saved = append(r.saved, *job)
+	return nil
+}
+
+func (r *fakeRepo) ConsumeBudget(_ context.Context, day date.Date, platform string, limit int) (bool, error) {
+	r.days = append(r.days, day)
+	if r.used[platform] >= limit {
+		return false, nil
+	}
+	r.used[platform]++
+	return true, nil
+}
+
+type fakeProducts struct {
+	product.Service
+	refreshed []string // "term: platforms"
+}
+
+func (f *fakeProducts) Refresh(_ context.Context, term, _ string, platforms []string) (int, error) {
+	f.refreshed = append(f.refreshed, term+": "+strings.Join(platforms, ","))
+	return len(platforms), nil
+}
+
+func newTestScheduler(t *testing.T, repo *fakeRepo, products product.Service, budget map[string]int) *scheduler {
+	t.Helper()
+	if repo.used == nil {
+		repo.used = make(map[string]int)
+	}
+	s, err := NewScheduler(repo, nil, products, Sources{}, Config{DailyBudget: budget}, logger.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.(*scheduler)
+}
+
+func TestWithinBudget(t *testing.T) {
+	cases := []struct {
+		name      string
+		budget    map[string]int
+		used      map[string]int
+		platforms []string
+		want      []string
+		wantErr   error
+	}{
+		{
+			name:      "budget left",
+			budget:    map[string]int{DefaultSchedule: 5},
+			used:      map[string]int{"amazon": 3},
+			platforms: []string{"amazon", "bestbuy"},
+			want:      []string{"amazon", "bestbuy"},
+		},
+		{
+			name:      "last scrape of the day",
+			budget:    map[string]int{DefaultSchedule: 5},
+			used:      map[string]int{"amazon": 4},
+			platforms: []string{"amazon"},
+			want:      []string{"amazon"},
+		},
+		{
+			name:      "one platform exhausted",
+			budget:    map[string]int{DefaultSchedule: 5},
+			used:      map[string]int{"amazon": 5},
+			platforms: []string{"amazon", "bestbuy"},
+			want:      []string{"bestbuy"},
+		},
+		{
+			name:      "every platform exhausted",
+			budget:    map[string]int{DefaultSchedule: 5},
+			used:      map[string]int{"amazon": 5, "bestbuy": 5},
+			platforms: []string{"amazon", "bestbuy"},
+			wantErr:   errNoBudget,
+		},
+		{
+			name:      "zero budget",
+			budget:    map[string]int{DefaultSchedule: 0},
+			platforms: []string{"amazon"},
+			wantErr:   errNoBudget,
+		},
+		{
+			name:      "platform budget overrides the default",
+			budget:    map[string]int{DefaultSchedule: 5, "amazon": 10},
+			used:      map[string]int{"Amazon": 7, "BestBuy": 5},
+			platforms: []string{"Amazon", "BestBuy"},
+			want:      []string{"Amazon"},
+		},
+		{
+			name:      "uncapped",
+			budget:    map[string]int{},
+			used:      map[string]int{"amazon": 1000},
+			platforms: []string{"amazon"},
+			want:      []string{"amazon"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := &fakeRepo{used: c.used}
+			s := newTestScheduler(t, repo, nil, c.budget)
+
+			got, err := s.withinBudget(context.Background(), c.platforms)
+			if !errors.Is(err, c.wantErr) {
+				t.Fatalf("withinBudget: %v, want %v", err, c.wantErr)
+			}
+			if !slices.Equal(got, c.want) {
+				t.Errorf("withinBudget = %v, want %v", got, c.want)
+			}
+			today := date.Of(time.Now().UTC())
+			for _, day := range repo.days {
+				if !day.Equal(today.Time) {
+					t.Errorf("budget taken from %s, want %s", day.Format(time.DateOnly), today.Format(time.DateOnly))
+				}
+			}
+		})
+	}
+}
+
+func TestRunOnceClaims(t *testing.T) {
+	category := "electronics"
+	platforms := strings.Join(product.CategoryPlatforms(category), ",")
+	cases := []struct {
+		name     string
+		due      []Job
+		taken    map[uint]bool
+		claimErr error
+		used     map[string]int
+
+		wantRefreshed []string
+		wantSaved     []uint
+		wantLastError string
+		wantErr       bool
+	}{
+		{
+			name:          "every job claimed",
+			due:           []Job{{ID: 1, Kind: KindQuery, Target: "airpods", Category: category}, {ID: 2, Kind: KindQuery, Target: "ipad", Category: category}},
+			wantRefreshed: []string{"airpods: " + platforms, "ipad: " + platforms},
+			wantSaved:     []uint{1, 2},
+		},
+		{
+			name:          "claimed by another instance",
+			due:           []Job{{ID: 1, Kind: KindQuery, Target: "airpods", Category: category}, {ID: 2, Kind: KindQuery, Target: "ipad", Category: category}},
+			taken:         map[uint]bool{1: true},
+			wantRefreshed: []string{"ipad: " + platforms},
+			wantSaved:     []uint{2},
+		},
+		{
+			name:     "claim fails",
+			due:      []Job{{ID: 1, Kind: KindQuery, Target: "airpods", Category: category}},
+			claimErr: errors.New("connection reset"),
+			wantErr:  true,
+		},
+		{
+			name:          "budget exhausted",
+			due:           []Job{{ID: 1, Kind: KindQuery, Target: "airpods", Category: category}},
+			used:          exhausted(category, 2),
+			wantSaved:     []uint{1},
+			wantLastError: errNoBudget.Error(),
+		},
+		{
+			name:          "unknown kind",
+			due:           []Job{{ID: 1, Kind: "page", Target: "home"}},
+			wantSaved:     []uint{1},
+			wantLastError: `unknown job kind "page"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := &fakeRepo{due: c.due, taken: c.taken, claimErr: c.claimErr, used: c.used}
+			products := &fakeProducts{}
+			s := newTestScheduler(t, repo, products, map[string]int{DefaultSchedule: 2})
+			start := time.Now()
+
+			err := s.RunOnce(context.Background())
+			if (err != nil) != c.wantErr {
+				t.Fatalf("RunOnce: %v, want error %v", err, c.wantErr)
+			}
+			if !slices.Equal(products.refreshed, c.wantRefreshed) {
+				t.Errorf("refreshed %q, want %q", products.refreshed, c.wantRefreshed)
+			}
+			var saved []uint
+			for _, job := range repo.saved {
+				saved = append(saved, job.ID)
+				if job.Runs != 1 || job.LastRunAt == nil || !job.NextRunAt.After(start) {
+					t.Errorf("job %d saved as run %d at %v, next %v", job.ID, job.Runs, job.LastRunAt, job.NextRunAt)
+				}
+				if job.LastError != c.wantLastError {
+					t.Errorf("job %d error %q, want %q", job.ID, job.LastError, c.wantLastError)
+				}
+			}
+			if !slices.Equal(saved, c.wantSaved) {
+				t.Errorf("saved jobs %v, want %v", saved, c.wantSaved)
+			}
+		})
+	}
+}
+
+// exhausted is a usage that has spent limit scrapes on each of category's platforms.
+func exhausted(category string, limit int) map[string]int {
+	used := make(map[string]int)
+	for _, p := range product.CategoryPlatforms(category) {
+		used[p] = limit
+	}
+	return used
+}
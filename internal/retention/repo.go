@@ -0,0 +1,38 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"never-price-match-server/internal/product"
+)
+
+// Repo defines the interface for finding and deleting expired scraped data.
+// Listing queries page through IDs above afterID, in ID order.
+type Repo interface {
+	// UnseenListings returns up to limit IDs of listings not scraped since cutoff.
+	// Listings that a purchase, watch or watch alert refers to are never returned.
+	UnseenListings(ctx context.Context, cutoff time.Time, afterID uint, limit int) ([]uint, error)
+	// CountObservations counts the price observations of the given listings.
+	CountObservations(ctx context.Context, listingIDs []uint) (int64, error)
+	// DeleteListings deletes listings along with their price observations and scrape
+	// job results, returning how many observations went with them.
+	DeleteListings(ctx context.Context, ids []uint) (int64, error)
+	// ListingsObservedBefore returns up to limit IDs of listings scraped since seenSince
+	// that have price observations older than before.
+	ListingsObservedBefore(ctx context.Context, before, seenSince time.Time, afterID uint, limit int) ([]uint, error)
+	// ObservationsBefore returns the given listings' price observations older than before.
+	ObservationsBefore(ctx context.Context, listingIDs []uint, before time.Time) ([]product.PriceObservation, error)
+	// DeleteObservations deletes price observations by ID.
+	DeleteObservations(ctx context.Context, ids []uint) (int64, error)
+	// CountSearchScrapes counts the records of search scrapes made before the given time.
+	CountSearchScrapes(ctx context.Context, before time.Time) (int64, error)
+	// DeleteSearchScrapes deletes the records of search scrapes made before the given
+	// time, returning how many were deleted.
+	DeleteSearchScrapes(ctx context.Context, before time.Time) (int64, error)
+	// CountScrapeJobs counts the scrape jobs created before the given time.
+	CountScrapeJobs(ctx context.Context, before time.Time) (int64, error)
+	// DeleteScrapeJobs deletes the scrape jobs created before the given time along with
+	// their platforms and results, returning how many jobs were deleted.
+	DeleteScrapeJobs(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,306 @@
+package retention
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/infra/txn"
+	"never-price-match-server/internal/product"
+)
+
+// batchSize is how many listings are handled per query and transaction.
+const batchSize = 500
+
+// Config is the retention policy and how often it is applied.
+type Config struct {
+	// KeepRaw is how long every price observation is kept. Older history is
+	// downsampled to daily points. Zero keeps all observations.
+	KeepRaw time.Duration
+	// PurgeUnseen is how long a listing may go without being scraped before it is
+	// deleted with its history, unless a purchase or watch refers to it. Records of
+	// search scrapes this old are deleted too. Zero keeps all listings.
+	PurgeUnseen time.Duration
+	// PurgeJobs is how long startSearch jobs and their results are kept. Zero keeps all jobs.
+	PurgeJobs time.Duration
+	// Interval is how often the scheduled job runs.
+	Interval time.Duration
+}
+
+// DefaultConfig is the policy applied when none is configured. NewService also uses
+// its Interval for a zero Interval.
+var DefaultConfig = Config{
+	KeepRaw:     30 * 24 * time.Hour,
+	PurgeUnseen: 180 * 24 * time.Hour,
+	PurgeJobs:   7 * 24 * time.Hour,
+	Interval:    24 * time.Hour,
+}
+
+// Report is what one run deleted, or with DryRun set, would have deleted.
+type Report struct {
+	DryRun                  bool
+	ListingsPurged          int
+	ObservationsPurged      int64
+	SearchScrapesPurged     int64
+	ListingsDownsampled     int
+	ObservationsDownsampled int64
+	JobsPurged              int64
+}
+
+// Forgetter drops deleted listings from in-process indexes and caches.
+type Forgetter interface {
+	ForgetListings(ids []uint)
+}
+
+// Service defines the retention job interface.
+type Service interface {
+	// Run applies the retention policy once. With dryRun set nothing is deleted and the
+	// report says what would have been.
+	Run(ctx context.Context, dryRun bool) (*Report, error)
+	// Start runs the policy every Interval until ctx is cancelled.
+	Start(ctx context.Context)
+	// Close waits for a running job to finish.
+	Close()
+}
+
+type service struct {
+	repo    Repo
+	tx      txn.Runner
+	forget  Forgetter
+	cfg     Config
+	log     *logger.Logger
+	running sync.WaitGroup
+}
+
+// NewService creates a new retention service instance. Purged listings are passed to
+// forget, which may be nil when nothing in the process holds listings.
+func NewService(repo Repo, tx txn.Runner, forget Forgetter, cfg Config, log *logger.Logger) Service {
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultConfig.Interval
+	}
+	return &service{repo: repo, tx: tx, forget: forget, cfg: cfg, log: log}
+}
+
+func (s *service) Run(ctx context.Context, dryRun bool) (*Report, error) {
+	now := time.Now()
+	report := &Report{DryRun: dryRun}
+	// Listings about to be purged are left out of downsampling, so a dry run reports
+	// what a real run does.
+	var seenSince time.Time
+	if s.cfg.PurgeUnseen > 0 {
+		seenSince = now.Add(-s.cfg.PurgeUnseen)
+		if err := s.purge(ctx, seenSince, dryRun, report); err != nil {
+			return report, err
+		}
+		if err := s.purgeSearchScrapes(ctx, seenSince, dryRun, report); err != nil {
+			return report, err
+		}
+	}
+	if s.cfg.KeepRaw > 0 {
+		// Whole days only, so a day is never part raw and part downsampled.
+		before := product.StartOfDay(now.Add(-s.cfg.KeepRaw))
+		if err := s.downsample(ctx, before, seenSince, dryRun, report); err != nil {
+			return report, err
+		}
+	}
+	if s.cfg.PurgeJobs > 0 {
+		if err := s.purgeJobs(ctx, now.Add(-s.cfg.PurgeJobs), dryRun, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+func (s *service) Start(ctx context.Context) {
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+		s.runJob(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runJob(ctx)
+			}
+		}
+	}()
+}
+
+func (s *service) runJob(ctx context.Context) {
+	report, err := s.Run(ctx, false)
+	if err != nil {
+		s.log.Warn("Retention job failed", logger.Err(err))
+		return
+	}
+	LogReport(s.log, report)
+}
+
+func (s *service) Close() {
+	s.running.Wait()
+}
+
+// LogReport logs what a retention run deleted or, for a dry run, would delete.
+func LogReport(log *logger.Logger, report *Report) {
+	log.Info("retention run finished",
+		logger.Field("dry_run", report.DryRun),
+		logger.Int("listings_purged", report.ListingsPurged),
+		logger.Field("observations_purged", report.ObservationsPurged),
+		logger.Field("search_scrapes_purged", report.SearchScrapesPurged),
+		logger.Int("listings_downsampled", report.ListingsDownsampled),
+		logger.Field("observations_downsampled", report.ObservationsDownsampled),
+		logger.Field("jobs_purged", report.JobsPurged),
+	)
+}
+
+// purge deletes the listings not scraped since cutoff.
+func (s *service) purge(ctx context.Context, cutoff time.Time, dryRun bool, report *Report) error {
+	var afterID uint
+	for {
+		ids, err := s.repo.UnseenListings(ctx, cutoff, afterID, batchSize)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		afterID = ids[len(ids)-1]
+
+		var observations int64
+		if dryRun {
+			observations, err = s.repo.CountObservations(ctx, ids)
+		} else {
+			err = s.tx.Run(ctx, func(ctx context.Context) error {
+				observations, err = s.repo.DeleteListings(ctx, ids)
+				return err
+			})
+			if err == nil && s.forget != nil {
+				s.forget.ForgetListings(ids)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		report.ListingsPurged += len(ids)
+		report.ObservationsPurged += observations
+	}
+}
+
+// purgeSearchScrapes deletes the records of search scrapes made before the given time.
+// A term whose record is gone counts as never scraped, which is due a scrape anyway.
+func (s *service) purgeSearchScrapes(ctx context.Context, before time.Time, dryRun bool, report *Report) error {
+	var scrapes int64
+	var err error
+	if dryRun {
+		scrapes, err = s.repo.CountSearchScrapes(ctx, before)
+	} else {
+		scrapes, err = s.repo.DeleteSearchScrapes(ctx, before)
+	}
+	if err != nil {
+		return err
+	}
+	report.SearchScrapesPurged = scrapes
+	return nil
+}
+
+// purgeJobs deletes the scrape jobs created before the given time.
+func (s *service) purgeJobs(ctx context.Context, before time.Time, dryRun bool, report *Report) error {
+	var jobs int64
+	var err error
+	if dryRun {
+		jobs, err = s.repo.CountScrapeJobs(ctx, before)
+	} else {
+		err = s.tx.Run(ctx, func(ctx context.Context) error {
+			jobs, err = s.repo.DeleteScrapeJobs(ctx, before)
+			return err
+		})
+	}
+	if err != nil {
+		return err
+	}
+	report.JobsPurged = jobs
+	return nil
+}
+
+// downsample reduces the history of listings scraped since seenSince to daily points
+// before the given time.
+func (s *service) downsample(ctx context.Context, before, seenSince time.Time, dryRun bool, report *Report) error {
+	var afterID uint
+	for {
+		ids, err := s.repo.ListingsObservedBefore(ctx, before, seenSince, afterID, batchSize)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		afterID = ids[len(ids)-1]
+
+		observations, err := s.repo.ObservationsBefore(ctx, ids, before)
+		if err != nil {
+			return err
+		}
+		drop, listings := redundantObservations(observations)
+		if len(drop) == 0 {
+			continue
+		}
+		deleted := int64(len(drop))
+		if !dryRun {
+			if deleted, err = s.deleteObservations(ctx, drop); err != nil {
+				return err
+			}
+		}
+		report.ListingsDownsampled += listings
+		report.ObservationsDownsampled += deleted
+	}
+}
+
+// deleteObservations deletes observations in batches, keeping each statement's
+// parameter count within every driver's limit.
+func (s *service) deleteObservations(ctx context.Context, ids []uint) (int64, error) {
+	var deleted int64
+	for len(ids) > 0 {
+		n := min(len(ids), batchSize)
+		d, err := s.repo.DeleteObservations(ctx, ids[:n])
+		deleted += d
+		if err != nil {
+			return deleted, err
+		}
+		ids = ids[n:]
+	}
+	return deleted, nil
+}
+
+// redundantObservations returns the observations a daily history doesn't need, and
+// how many listings they belong to. Each listing keeps, per calendar day, the rows
+// holding the day's lowest, highest and last price, so the daily min/max/close of
+// its price history is unchanged. observations must be ordered by listing, then time.
+func redundantObservations(observations []product.PriceObservation) ([]uint, int) {
+	var drop []uint
+	listings := make(map[uint]bool)
+	for start := 0; start < len(observations); {
+		end := start + 1
+		day := product.StartOfDay(observations[start].ObservedAt)
+		for end < len(observations) &&
+			observations[end].ProductID == observations[start].ProductID &&
+			product.StartOfDay(observations[end].ObservedAt).Equal(day) {
+			end++
+		}
+
+		group := observations[start:end]
+		minIdx, maxIdx, closeIdx := 0, 0, len(group)-1
+		for i, o := range group {
+			if o.Price < group[minIdx].Price {
+				minIdx = i
+			}
+			if o.Price > group[maxIdx].Price {
+				maxIdx = i
+			}
+		}
+		for i, o := range group {
+			if i != minIdx && i != maxIdx && i != closeIdx {
+				drop = append(drop, o.ID)
+				listings[o.ProductID] = true
+			}
+		}
+		start = end
+	}
+	return drop, len(listings)
+}
@@ -0,0 +1,90 @@
+package scrapejob
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Status is the state of a scrape job.
+type Status string
+
+const (
+	StatusQueued    Status = "QUEUED"
+	StatusRunning   Status = "RUNNING"
+	StatusSucceeded Status = "SUCCEEDED"
+	StatusFailed    Status = "FAILED"
+)
+
+// PlatformStatus is the state of one platform within a scrape job.
+type PlatformStatus string
+
+const (
+	PlatformPending PlatformStatus = "PENDING"
+	PlatformRunning PlatformStatus = "RUNNING"
+	PlatformDone    PlatformStatus = "DONE"
+	PlatformFailed  PlatformStatus = "FAILED"
+)
+
+// Job is a queued live search. A worker holds it under a lease while it runs;
+// a lease that runs out, because the worker crashed, lets another worker take it over.
+type Job struct {
+	ID       string  `gorm:"type:varchar(36);primaryKey"`
+	Term     string  `gorm:"type:varchar(255);not null"`
+	Category string  `gorm:"type:varchar(64);not null"`
+	UserID   *string `gorm:"type:varchar(36)"` // nil for anonymous searches
+	Status   Status  `gorm:"type:varchar(16);not null;index:idx_scrape_jobs_status_lease,priority:1"`
+	// Attempts counts how often a worker has claimed the job.
+	Attempts       int        `gorm:"not null"`
+	LeaseOwner     string     `gorm:"type:varchar(128)"`
+	LeaseExpiresAt *time.Time `gorm:"index:idx_scrape_jobs_status_lease,priority:2"`
+	Error          string     `gorm:"type:varchar(1024)"`
+	CreatedAt      time.Time  `gorm:"index"`
+	UpdatedAt      time.Time
+	FinishedAt     *time.Time
+
+	Platforms []JobPlatform `gorm:"foreignKey:JobID"`
+}
+
+func (Job) TableName() string { return "scrape_jobs" }
+
+func (j *Job) BeforeCreate(tx *gorm.DB) (err error) {
+	if j.ID == "" {
+		j.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Finished reports whether the job has reached a final status.
+func (j *Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// JobPlatform is the progress of one platform of a job.
+type JobPlatform struct {
+	JobID       string         `gorm:"type:varchar(36);primaryKey"`
+	Platform    string         `gorm:"type:varchar(64);primaryKey"`
+	Position    int            `gorm:"not null"` // order the platforms are scraped in
+	Status      PlatformStatus `gorm:"type:varchar(16);not null"`
+	ResultCount int            `gorm:"not null"`
+	Error       string         `gorm:"type:varchar(1024)"`
+	FinishedAt  *time.Time
+}
+
+func (JobPlatform) TableName() string { return "scrape_job_platforms" }
+
+// Done reports whether the platform needs no more work.
+func (p *JobPlatform) Done() bool {
+	return p.Status == PlatformDone || p.Status == PlatformFailed
+}
+
+// JobResult links a job to a listing it found.
+type JobResult struct {
+	JobID     string `gorm:"type:varchar(36);primaryKey"`
+	ProductID uint   `gorm:"primaryKey"`
+	Platform  string `gorm:"type:varchar(64);not null"`
+	Position  int    `gorm:"not null"` // rank on the platform's results page
+}
+
+func (JobResult) TableName() string { return "scrape_job_results" }
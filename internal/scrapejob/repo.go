@@ -0,0 +1,32 @@
+package scrapejob
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrJobNotFound is returned for a job ID that doesn't exist.
+var ErrJobNotFound = errors.New("scrape job not found")
+
+// Repo defines the interface for the scrape job queue.
+type Repo interface {
+	// Create stores a new job together with its platforms.
+	Create(ctx context.Context, job *Job) error
+	// Get returns a job with its platforms, or ErrJobNotFound.
+	Get(ctx context.Context, id string) (*Job, error)
+	// Claim leases the oldest job that is queued or whose lease has run out to owner
+	// until leaseUntil, counting the attempt. It returns nil when there is none.
+	Claim(ctx context.Context, owner string, now, leaseUntil time.Time) (*Job, error)
+	// RenewLease extends owner's lease on a job, reporting false if owner lost it.
+	RenewLease(ctx context.Context, id, owner string, leaseUntil time.Time) (bool, error)
+	// UpdatePlatform stores a platform's progress.
+	UpdatePlatform(ctx context.Context, p *JobPlatform) error
+	// ReplaceResults sets the listings a job found on one platform.
+	ReplaceResults(ctx context.Context, jobID, platform string, results []JobResult) error
+	// Results returns a job's listings, in rank order within each platform.
+	Results(ctx context.Context, jobID string) ([]JobResult, error)
+	// Finish stores a job's final status and releases owner's lease, reporting false
+	// if owner no longer held it.
+	Finish(ctx context.Context, job *Job, owner string) (bool, error)
+}
@@ -0,0 +1,336 @@
+package scrapejob
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/infra/strutil"
+	"never-price-match-server/internal/infra/txn"
+	"never-price-match-server/internal/product"
+
+	"github.com/google/uuid"
+)
+
+// maxErrorLength is the size of the error columns.
+const maxErrorLength = 1024
+
+// Config tunes the job workers.
+type Config struct {
+	// Workers is how many jobs run at once in this process.
+	Workers int
+	// Lease is how long a claimed job stays with its worker without a renewal.
+	// A crashed worker's jobs are picked up again once their lease runs out.
+	Lease time.Duration
+	// PollInterval is how often idle workers look for jobs queued by other processes.
+	PollInterval time.Duration
+	// MaxAttempts is how many times a job is claimed before it is given up on.
+	MaxAttempts int
+}
+
+// DefaultConfig is used for any Config field left at its zero value.
+var DefaultConfig = Config{
+	Workers:      2,
+	Lease:        2 * time.Minute,
+	PollInterval: 2 * time.Second,
+	MaxAttempts:  3,
+}
+
+// Progress is a job with the listings it has found so far.
+type Progress struct {
+	*Job
+	Results []product.ScrapeResult
+	// Summary covers the results and platforms finished so far.
+	Summary product.SearchSummary
+}
+
+// Service defines the business logic interface for asynchronous searches.
+type Service interface {
+	// Enqueue queues a live search of the category's platforms for term.
+	Enqueue(ctx context.Context, term, category, userID string) (*Job, error)
+	// Get returns a job's progress and partial results, or ErrJobNotFound.
+	Get(ctx context.Context, id string) (*Progress, error)
+	// Start runs the workers until ctx is cancelled.
+	Start(ctx context.Context)
+	// Close waits for the workers to stop.
+	Close()
+}
+
+type service struct {
+	repo     Repo
+	tx       txn.Runner
+	products product.Service
+	cfg      Config
+	log      *logger.Logger
+
+	// owner identifies this process in leases.
+	owner string
+	// wake nudges an idle worker when a job is queued by this process.
+	wake    chan struct{}
+	workers sync.WaitGroup
+}
+
+// NewService creates a new scrape job service instance. Jobs are scraped through products.
+func NewService(repo Repo, tx txn.Runner, products product.Service, cfg Config, log *logger.Logger) Service {
+	host, _ := os.Hostname()
+	return &service{
+		repo:     repo,
+		tx:       tx,
+		products: products,
+		cfg:      withDefaults(cfg),
+		log:      log,
+		owner:    fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8]),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+func withDefaults(cfg Config) Config {
+	if cfg.Workers == 0 {
+		cfg.Workers = DefaultConfig.Workers
+	}
+	if cfg.Lease == 0 {
+		cfg.Lease = DefaultConfig.Lease
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = DefaultConfig.PollInterval
+	}
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = DefaultConfig.MaxAttempts
+	}
+	return cfg
+}
+
+func (s *service) Enqueue(ctx context.Context, term, category, userID string) (*Job, error) {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return nil, fmt.Errorf("search term cannot be empty")
+	}
+	job := &Job{Term: term, Category: category, Status: StatusQueued}
+	if userID != "" {
+		job.UserID = &userID
+	}
+	for i, platform := range product.CategoryPlatforms(category) {
+		job.Platforms = append(job.Platforms, JobPlatform{Platform: platform, Position: i, Status: PlatformPending})
+	}
+	if err := s.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+func (s *service) Get(ctx context.Context, id string) (*Progress, error) {
+	job, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.Results(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Listings come back grouped in the order the platforms were scraped.
+	ids := make([]uint, 0, len(rows))
+	for _, platform := range job.Platforms {
+		for _, r := range rows {
+			if r.Platform == platform.Platform {
+				ids = append(ids, r.ProductID)
+			}
+		}
+	}
+	results, err := s.products.Listings(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	var searched, failed []string
+	for _, platform := range job.Platforms {
+		if !platform.Done() {
+			continue
+		}
+		searched = append(searched, platform.Platform)
+		if platform.Status == PlatformFailed {
+			failed = append(failed, platform.Platform)
+		}
+	}
+	return &Progress{Job: job, Results: results, Summary: product.Summarize(results, searched, failed)}, nil
+}
+
+func (s *service) Start(ctx context.Context) {
+	for i := 0; i < s.cfg.Workers; i++ {
+		s.workers.Add(1)
+		go func() {
+			defer s.workers.Done()
+			s.work(ctx)
+		}()
+	}
+}
+
+func (s *service) Close() {
+	s.workers.Wait()
+}
+
+// work claims and runs jobs until ctx is cancelled.
+func (s *service) work(ctx context.Context) {
+	poll := time.NewTicker(s.cfg.PollInterval)
+	defer poll.Stop()
+	for ctx.Err() == nil {
+		now := time.Now()
+		job, err := s.repo.Claim(ctx, s.owner, now, now.Add(s.cfg.Lease))
+		if err != nil && ctx.Err() == nil {
+			s.log.Warn("Failed to claim scrape job", logger.Err(err))
+		}
+		if job != nil {
+			s.run(ctx, job)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-poll.C:
+		}
+	}
+}
+
+// run scrapes a claimed job's remaining platforms, renewing the lease as it goes.
+// If the lease is lost or the worker stops, the job is left for the next claim,
+// which resumes with the platforms that haven't finished.
+func (s *service) run(ctx context.Context, job *Job) {
+	if job.Attempts > s.cfg.MaxAttempts {
+		s.finish(ctx, job, StatusFailed, fmt.Sprintf("gave up after %d attempts", job.Attempts-1))
+		return
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.keepLease(jobCtx, cancel, job.ID)
+
+	for i := range job.Platforms {
+		p := &job.Platforms[i]
+		if p.Done() {
+			continue
+		}
+		if jobCtx.Err() != nil {
+			s.release(job.ID)
+			return
+		}
+
+		p.Status = PlatformRunning
+		if err := s.repo.UpdatePlatform(jobCtx, p); err != nil {
+			s.log.Warn("Failed to update scrape job platform", logger.Str("job", job.ID), logger.Err(err))
+		}
+		result, err := s.products.ScrapePlatform(jobCtx, job.Term, job.Category, p.Platform)
+		if jobCtx.Err() != nil {
+			// Stopped mid-scrape: the platform is retried by whoever claims the job next.
+			s.release(job.ID)
+			return
+		}
+		if err := s.completePlatform(jobCtx, job.ID, p, result, err); err != nil {
+			s.log.Warn("Failed to save scrape job platform", logger.Str("job", job.ID), logger.Str("platform", p.Platform), logger.Err(err))
+			// The results weren't stored, so the platform can't be reported as done.
+			p.Status, p.ResultCount = PlatformFailed, 0
+			p.Error = strutil.Truncate("saving results: "+err.Error(), maxErrorLength)
+			if err := s.repo.UpdatePlatform(jobCtx, p); err != nil {
+				s.log.Warn("Failed to update scrape job platform", logger.Str("job", job.ID), logger.Err(err))
+			}
+		}
+	}
+
+	status, msg := StatusFailed, "every platform failed"
+	for _, p := range job.Platforms {
+		if p.Status == PlatformDone {
+			status, msg = StatusSucceeded, ""
+			break
+		}
+	}
+	// The outcome is stored even if the worker is stopping, or the work is redone.
+	s.finish(context.WithoutCancel(jobCtx), job, status, msg)
+}
+
+// completePlatform records a platform's outcome and, on success, its listings,
+// as one unit of work.
+func (s *service) completePlatform(ctx context.Context, jobID string, p *JobPlatform, result product.ScrapeResult, scrapeErr error) error {
+	now := time.Now()
+	p.FinishedAt = &now
+	if scrapeErr != nil {
+		p.Status = PlatformFailed
+		p.Error = strutil.Truncate(scrapeErr.Error(), maxErrorLength)
+		return s.repo.UpdatePlatform(ctx, p)
+	}
+
+	var rows []JobResult
+	seen := make(map[uint]bool)
+	for i, sp := range result.Products {
+		if sp.ListingID == 0 || seen[sp.ListingID] {
+			continue
+		}
+		seen[sp.ListingID] = true
+		rows = append(rows, JobResult{JobID: jobID, ProductID: sp.ListingID, Platform: p.Platform, Position: i})
+	}
+	p.Status = PlatformDone
+	p.ResultCount = len(rows)
+	p.Error = ""
+	return s.tx.Run(ctx, func(ctx context.Context) error {
+		if err := s.repo.ReplaceResults(ctx, jobID, p.Platform, rows); err != nil {
+			return err
+		}
+		return s.repo.UpdatePlatform(ctx, p)
+	})
+}
+
+func (s *service) finish(ctx context.Context, job *Job, status Status, msg string) {
+	now := time.Now()
+	job.Status = status
+	job.Error = strutil.Truncate(msg, maxErrorLength)
+	job.FinishedAt = &now
+	ok, err := s.repo.Finish(ctx, job, s.owner)
+	switch {
+	case err != nil:
+		s.log.Warn("Failed to finish scrape job", logger.Str("job", job.ID), logger.Err(err))
+	case !ok:
+		s.log.Warn("Scrape job lease lost before it finished", logger.Str("job", job.ID))
+	default:
+		s.log.Info("Scrape job finished", logger.Str("job", job.ID), logger.Str("status", string(status)))
+	}
+}
+
+// keepLease renews the lease on a running job until ctx ends, and cancels the job
+// through stop if the lease is lost.
+func (s *service) keepLease(ctx context.Context, stop context.CancelFunc, jobID string) {
+	ticker := time.NewTicker(s.cfg.Lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := s.repo.RenewLease(ctx, jobID, s.owner, time.Now().Add(s.cfg.Lease))
+			if err != nil {
+				s.log.Warn("Failed to renew scrape job lease", logger.Str("job", jobID), logger.Err(err))
+				continue
+			}
+			if !ok {
+				s.log.Warn("Scrape job lease lost", logger.Str("job", jobID))
+				stop()
+				return
+			}
+		}
+	}
+}
+
+// release ends this worker's lease on a job it is abandoning, so another worker
+// can take it over straight away instead of waiting for the lease to run out.
+func (s *service) release(jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := s.repo.RenewLease(ctx, jobID, s.owner, time.Now()); err != nil {
+		s.log.Warn("Failed to release scrape job lease", logger.Str("job", jobID), logger.Err(err))
+	}
+}
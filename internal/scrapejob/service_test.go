@@ -0,0 +1,278 @@
+package scrapejob
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/product"
+)
+
+// The fakes embed their interface, so calling anything they don't override panics.
+type fakeRepo struct {
+	Repo
+	results  map[string][]JobResult // platform -> listings found
+	saveErr  error                  // returned by ReplaceResults
+	finished bool
+	renewals int
+}
+
+func (r *fakeRepo) UpdatePlatform(context.Context, *JobPlatform) error { return nil }
+
+func (r *fakeRepo) ReplaceResults(_ context.Context, _, platform string, results []JobResult) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	r.results[platform] = results
+	return nil
+}
+
+func (r *fakeRepo) RenewLease(context.Context, string, string, time.Time) (bool, error) {
+	r.renewals++
+	return true, nil
+}
+
+func (r *fakeRepo) Finish(context.Context, *Job, string) (bool, error) {
+	r.finished = true
+	return true, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Run(ctx context.Context, fn func(ctx context.Context) error) error { return fn(ctx) }
+
+type fakeProducts struct {
+	product.Service
+	scrapes map[string]product.ScrapeResult
+	errs    map[string]error
+	stop    func() // called by the scrape of stopAt, to stop the worker mid-scrape
+	stopAt  string
+	scraped []string
+}
+
+func (f *fakeProducts) ScrapePlatform(_ context.Context, _, _, platform string) (product.ScrapeResult, error) {
+	f.scraped = append(f.scraped, platform)
+	if platform == f.stopAt {
+		f.stop()
+	}
+	return f.scrapes[platform], f.errs[platform]
+}
+
+func newJob(attempts int, platforms ...JobPlatform) *Job {
+	for i := range platforms {
+		platforms[i].JobID, platforms[i].Position = "job-1", i
+	}
+	return &Job{ID: "job-1", Term: "airpods", Status: StatusRunning, Attempts: attempts, Platforms: platforms}
+}
+
+func TestRun(t *testing.T) {
+	found := func(platform string, ids ...uint) product.ScrapeResult {
+		res := product.ScrapeResult{Platform: platform}
+		for _, id := range ids {
+			res.Products = append(res.Products, product.ScrapedProduct{ListingID: id})
+		}
+		return res
+	}
+	pending := func(platform string) JobPlatform { return JobPlatform{Platform: platform, Status: PlatformPending} }
+	cases := []struct {
+		name     string
+		job      *Job
+		products *fakeProducts
+		repo     *fakeRepo
+		stop     bool // the worker is stopped during the scrape of products.stopAt
+
+		wantScraped   []string
+		wantStatus    Status
+		wantError     string
+		wantPlatforms []PlatformStatus
+		wantCounts    []int
+		wantFinished  bool
+	}{
+		{
+			name: "every platform succeeds",
+			job:  newJob(1, pending("amazon"), pending("bestbuy")),
+			products: &fakeProducts{scrapes: map[string]product.ScrapeResult{
+				// Unsaved and repeated listings aren't results.
+				"amazon":  found("amazon", 1, 0, 2, 1),
+				"bestbuy": found("bestbuy", 3),
+			}},
+			wantScraped:   []string{"amazon", "bestbuy"},
+			wantStatus:    StatusSucceeded,
+			wantPlatforms: []PlatformStatus{PlatformDone, PlatformDone},
+			wantCounts:    []int{2, 1},
+			wantFinished:  true,
+		},
+		{
+			name: "one platform fails",
+			job:  newJob(1, pending("amazon"), pending("bestbuy")),
+			products: &fakeProducts{
+				scrapes: map[string]product.ScrapeResult{"bestbuy": found("bestbuy", 3)},
+				errs:    map[string]error{"amazon": errors.New("captcha")},
+			},
+			wantScraped:   []string{"amazon", "bestbuy"},
+			wantStatus:    StatusSucceeded,
+			wantPlatforms: []PlatformStatus{PlatformFailed, PlatformDone},
+			wantCounts:    []int{0, 1},
+			wantFinished:  true,
+		},
+		{
+			name: "every platform fails",
+			job:  newJob(1, pending("amazon"), pending("bestbuy")),
+			products: &fakeProducts{errs: map[string]error{
+				"amazon":  errors.New("captcha"),
+				"bestbuy": errors.New("timeout"),
+			}},
+			wantScraped:   []string{"amazon", "bestbuy"},
+			wantStatus:    StatusFailed,
+			wantError:     "every platform failed",
+			wantPlatforms: []PlatformStatus{PlatformFailed, PlatformFailed},
+			wantCounts:    []int{0, 0},
+			wantFinished:  true,
+		},
+		{
+			name:          "results can't be saved",
+			job:           newJob(1, pending("amazon")),
+			products:      &fakeProducts{scrapes: map[string]product.ScrapeResult{"amazon": found("amazon", 1)}},
+			repo:          &fakeRepo{saveErr: errors.New("deadlock")},
+			wantScraped:   []string{"amazon"},
+			wantStatus:    StatusFailed,
+			wantError:     "every platform failed",
+			wantPlatforms: []PlatformStatus{PlatformFailed},
+			wantCounts:    []int{0},
+			wantFinished:  true,
+		},
+		{
+			name: "resumed after a crash",
+			job: newJob(2,
+				JobPlatform{Platform: "amazon", Status: PlatformDone, ResultCount: 4},
+				JobPlatform{Platform: "bestbuy", Status: PlatformRunning}),
+			products:      &fakeProducts{scrapes: map[string]product.ScrapeResult{"bestbuy": found("bestbuy", 3)}},
+			wantScraped:   []string{"bestbuy"},
+			wantStatus:    StatusSucceeded,
+			wantPlatforms: []PlatformStatus{PlatformDone, PlatformDone},
+			wantCounts:    []int{4, 1},
+			wantFinished:  true,
+		},
+		{
+			name:          "last attempt",
+			job:           newJob(DefaultConfig.MaxAttempts, pending("amazon")),
+			products:      &fakeProducts{scrapes: map[string]product.ScrapeResult{"amazon": found("amazon", 1)}},
+			wantScraped:   []string{"amazon"},
+			wantStatus:    StatusSucceeded,
+			wantPlatforms: []PlatformStatus{PlatformDone},
+			wantCounts:    []int{1},
+			wantFinished:  true,
+		},
+		{
+			name:          "out of attempts",
+			job:           newJob(DefaultConfig.MaxAttempts+1, pending("amazon")),
+			products:      &fakeProducts{},
+			wantStatus:    StatusFailed,
+			wantError:     "gave up after 3 attempts",
+			wantPlatforms: []PlatformStatus{PlatformPending},
+			wantCounts:    []int{0},
+			wantFinished:  true,
+		},
+		{
+			name:          "stopped mid-scrape",
+			job:           newJob(1, pending("amazon"), pending("bestbuy")),
+			products:      &fakeProducts{stopAt: "amazon", scrapes: map[string]product.ScrapeResult{"amazon": found("amazon", 1)}},
+			stop:          true,
+			wantScraped:   []string{"amazon"},
+			wantStatus:    StatusRunning,
+			wantPlatforms: []PlatformStatus{PlatformRunning, PlatformPending},
+			wantCounts:    []int{0, 0},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := c.repo
+			if repo == nil {
+				repo = &fakeRepo{}
+			}
+			repo.results = make(map[string][]JobResult)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			c.products.stop = cancel
+			s := NewService(repo, fakeTx{}, c.products, Config{}, logger.Nop()).(*service)
+
+			s.run(ctx, c.job)
+
+			if !slices.Equal(c.products.scraped, c.wantScraped) {
+				t.Errorf("scraped %v, want %v", c.products.scraped, c.wantScraped)
+			}
+			if c.job.Status != c.wantStatus || c.job.Error != c.wantError {
+				t.Errorf("job %s %q, want %s %q", c.job.Status, c.job.Error, c.wantStatus, c.wantError)
+			}
+			var statuses []PlatformStatus
+			var counts []int
+			for _, p := range c.job.Platforms {
+				statuses = append(statuses, p.Status)
+				counts = append(counts, p.ResultCount)
+				if p.Status == PlatformFailed && p.Error == "" {
+					t.Errorf("%s failed without an error", p.Platform)
+				}
+			}
+			if !slices.Equal(statuses, c.wantPlatforms) || !slices.Equal(counts, c.wantCounts) {
+				t.Errorf("platforms %v with %v results, want %v with %v", statuses, counts, c.wantPlatforms, c.wantCounts)
+			}
+			if repo.finished != c.wantFinished {
+				t.Errorf("finished %v, want %v", repo.finished, c.wantFinished)
+			}
+			if c.stop && repo.renewals == 0 {
+				t.Error("stopped without releasing the lease")
+			}
+			for platform, rows := range repo.results {
+				for _, r := range rows {
+					if r.Platform != platform || r.JobID != c.job.ID {
+						t.Errorf("result %+v stored for %s", r, platform)
+					}
+				}
+			}
+			if c.wantFinished && c.job.FinishedAt == nil {
+				t.Error("finished job has no FinishedAt")
+			}
+		})
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	repo := &createRepo{}
+	s := NewService(repo, fakeTx{}, nil, Config{}, logger.Nop())
+
+	if _, err := s.Enqueue(context.Background(), "   ", "", ""); err == nil {
+		t.Error("Enqueue with a blank term succeeded")
+	}
+	job, err := s.Enqueue(context.Background(), " airpods ", "electronics", "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Term != "airpods" || job.Status != StatusQueued || job.UserID == nil || *job.UserID != "u1" {
+		t.Errorf("queued %+v", job)
+	}
+	platforms := product.CategoryPlatforms("electronics")
+	if len(job.Platforms) != len(platforms) {
+		t.Fatalf("%d platforms, want %d", len(job.Platforms), len(platforms))
+	}
+	for i, p := range job.Platforms {
+		if p.Platform != platforms[i] || p.Position != i || p.Status != PlatformPending {
+			t.Errorf("platform %d = %+v", i, p)
+		}
+	}
+	if repo.created != 1 {
+		t.Errorf("stored %d jobs, want 1", repo.created)
+	}
+}
+
+type createRepo struct {
+	Repo
+	created int
+}
+
+func (r *createRepo) Create(context.Context, *Job) error {
+	r.created++
+	return nil
+}
@@ -0,0 +1,63 @@
+package searchlog
+
+import (
+	"time"
+
+	"never-price-match-server/internal/infra/date"
+)
+
+// SearchQuery is one recorded searchProduct call.
+// Raw rows are kept for RawRetention and rolled up into DailySearchStat.
+type SearchQuery struct {
+	ID uint `gorm:"primarykey"`
+	// Term is the search as typed; NormalizedTerm is lower-cased single-spaced words,
+	// which is what searches are grouped by.
+	Term             string    `gorm:"type:varchar(255);not null"`
+	NormalizedTerm   string    `gorm:"type:varchar(255);not null;index"`
+	Category         string    `gorm:"type:varchar(64)"`
+	InferredCategory string    `gorm:"type:varchar(64)"`
+	ResultCount      int       `gorm:"not null"`
+	CacheHit         bool      `gorm:"not null"`
+	LatencyMs        int64     `gorm:"not null"`
+	UserID           *string   `gorm:"type:varchar(36);index"` // nil for anonymous searches
+	CreatedAt        time.Time `gorm:"index"`
+}
+
+// DailySearchStat is the per-day rollup of SearchQuery rows for one normalised term
+// and category pair. It outlives the raw log and backs the trending queries.
+type DailySearchStat struct {
+	Day              date.Date `gorm:"primaryKey;type:date"`
+	Term             string    `gorm:"primaryKey;type:varchar(255)"`
+	Category         string    `gorm:"primaryKey;type:varchar(64)"`
+	InferredCategory string    `gorm:"primaryKey;type:varchar(64)"`
+	Searches         int       `gorm:"not null"`
+	ZeroResults      int       `gorm:"not null"`
+	CacheHits        int       `gorm:"not null"`
+	TotalLatencyMs   int64     `gorm:"not null"`
+}
+
+// TermStats summarises the searches for one normalised term over a window.
+type TermStats struct {
+	Term             string
+	Searches         int
+	ZeroResults      int
+	CacheHits        int
+	TotalLatencyMs   int64
+	InferredCategory string // the most common inferred category, "" if none
+}
+
+// CacheHitRate is the share of searches answered from the cache.
+func (t TermStats) CacheHitRate() float64 {
+	if t.Searches == 0 {
+		return 0
+	}
+	return float64(t.CacheHits) / float64(t.Searches)
+}
+
+// AvgLatencyMs is the mean search latency in milliseconds.
+func (t TermStats) AvgLatencyMs() float64 {
+	if t.Searches == 0 {
+		return 0
+	}
+	return float64(t.TotalLatencyMs) / float64(t.Searches)
+}
@@ -0,0 +1,21 @@
+package searchlog
+
+import (
+	"context"
+	"time"
+)
+
+// Repo defines the interface for search log persistence.
+type Repo interface {
+	// Create stores a single search.
+	Create(ctx context.Context, q *SearchQuery) error
+	// DeleteBefore removes raw searches older than t and returns how many were removed.
+	DeleteBefore(ctx context.Context, t time.Time) (int64, error)
+	// ReplaceDailyStats recomputes the rollup of day (UTC midnight to midnight) from the raw log.
+	ReplaceDailyStats(ctx context.Context, day time.Time) error
+	// DeleteDailyStatsBefore removes rollups of days before day.
+	DeleteDailyStatsBefore(ctx context.Context, day time.Time) (int64, error)
+	// TermStatsSince sums the rollups from day onwards per term, grouped by inferred category.
+	// Each returned row is one term and inferred category pair.
+	TermStatsSince(ctx context.Context, day time.Time) ([]TermStats, error)
+}
@@ -0,0 +1,273 @@
+package searchlog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/infra/strutil"
+	"never-price-match-server/internal/product"
+)
+
+// Window is the period a trending report covers, counted in whole days up to today.
+type Window string
+
+const (
+	WindowDay   Window = "DAY"
+	WindowWeek  Window = "WEEK"
+	WindowMonth Window = "MONTH"
+)
+
+// days returns how many calendar days the window spans, including today.
+func (w Window) days() (int, error) {
+	switch w {
+	case WindowDay:
+		return 1, nil
+	case WindowWeek:
+		return 7, nil
+	case WindowMonth:
+		return 30, nil
+	default:
+		return 0, fmt.Errorf("unknown window %q", w)
+	}
+}
+
+const (
+	// RawRetention is how long individual searches are kept.
+	RawRetention = 30 * 24 * time.Hour
+	// DailyRetention is how long the daily rollups are kept.
+	DailyRetention = 365 * 24 * time.Hour
+	// aggregateInterval is how often today's and yesterday's rollups are recomputed.
+	aggregateInterval = 15 * time.Minute
+	// retentionInterval is how often expired rows are purged.
+	retentionInterval = 24 * time.Hour
+	// maxTermLength is the length in runes of the term columns.
+	maxTermLength = 255
+)
+
+// Entry describes one searchProduct call to record.
+type Entry struct {
+	Term             string
+	Category         string
+	InferredCategory string
+	ResultCount      int
+	CacheHit         bool
+	Latency          time.Duration
+	UserID           string // "" for anonymous searches
+}
+
+// Service defines the business logic interface for the search log.
+type Service interface {
+	// Record stores a search in the background; failures are only logged.
+	// The write is not cancelled with ctx, so a search is still logged if its request ends first.
+	Record(ctx context.Context, e Entry)
+	// Trending returns the most searched terms in the window.
+	Trending(ctx context.Context, window Window, limit int) ([]TermStats, error)
+	// ZeroResults returns the terms that most often found nothing in the window.
+	ZeroResults(ctx context.Context, window Window, limit int) ([]TermStats, error)
+	// Aggregate recomputes the rollups of today and yesterday.
+	Aggregate(ctx context.Context) error
+	// Prune deletes raw searches and rollups past their retention.
+	Prune(ctx context.Context) error
+	// Start runs the aggregation and retention jobs until ctx is cancelled.
+	Start(ctx context.Context)
+	// Close waits for searches still being recorded and for the jobs to stop.
+	Close()
+}
+
+type service struct {
+	repo Repo
+	log  *logger.Logger
+
+	// pending tracks Record writes and the job loop, so Close can wait for them.
+	pending sync.WaitGroup
+}
+
+// NewService creates a new search log service instance.
+func NewService(repo Repo, log *logger.Logger) Service {
+	return &service{repo: repo, log: log}
+}
+
+func (s *service) Record(ctx context.Context, e Entry) {
+	q := &SearchQuery{
+		Term:             strutil.Truncate(strings.TrimSpace(e.Term), maxTermLength),
+		NormalizedTerm:   strutil.Truncate(normalizeTerm(e.Term), maxTermLength),
+		Category:         e.Category,
+		InferredCategory: e.InferredCategory,
+		ResultCount:      e.ResultCount,
+		CacheHit:         e.CacheHit,
+		LatencyMs:        e.Latency.Milliseconds(),
+		CreatedAt:        time.Now().UTC(),
+	}
+	if e.UserID != "" {
+		uid := e.UserID
+		q.UserID = &uid
+	}
+	ctx = context.WithoutCancel(ctx)
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		if err := s.repo.Create(ctx, q); err != nil {
+			s.log.Warn("Failed to record search", logger.Str("term", q.Term), logger.Err(err))
+		}
+	}()
+}
+
+func (s *service) Trending(ctx context.Context, window Window, limit int) ([]TermStats, error) {
+	stats, err := s.termStats(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Searches != stats[j].Searches {
+			return stats[i].Searches > stats[j].Searches
+		}
+		return stats[i].Term < stats[j].Term
+	})
+	return head(stats, limit), nil
+}
+
+func (s *service) ZeroResults(ctx context.Context, window Window, limit int) ([]TermStats, error) {
+	stats, err := s.termStats(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	zero := stats[:0]
+	for _, t := range stats {
+		if t.ZeroResults > 0 {
+			zero = append(zero, t)
+		}
+	}
+	sort.SliceStable(zero, func(i, j int) bool {
+		if zero[i].ZeroResults != zero[j].ZeroResults {
+			return zero[i].ZeroResults > zero[j].ZeroResults
+		}
+		return zero[i].Term < zero[j].Term
+	})
+	return head(zero, limit), nil
+}
+
+// termStats merges the per-category rollups of the window into one row per term,
+// keeping the inferred category seen most often.
+func (s *service) termStats(ctx context.Context, window Window) ([]TermStats, error) {
+	days, err := window.days()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.TermStatsSince(ctx, startOfDay(time.Now()).AddDate(0, 0, 1-days))
+	if err != nil {
+		return nil, err
+	}
+
+	byTerm := make(map[string]*TermStats)
+	categorySearches := make(map[string]int) // "term\x00category" -> searches
+	var terms []string
+	for _, r := range rows {
+		t, ok := byTerm[r.Term]
+		if !ok {
+			t = &TermStats{Term: r.Term}
+			byTerm[r.Term] = t
+			terms = append(terms, r.Term)
+		}
+		t.Searches += r.Searches
+		t.ZeroResults += r.ZeroResults
+		t.CacheHits += r.CacheHits
+		t.TotalLatencyMs += r.TotalLatencyMs
+
+		if r.InferredCategory == "" {
+			continue
+		}
+		key := r.Term + "\x00" + r.InferredCategory
+		categorySearches[key] += r.Searches
+		if t.InferredCategory == "" || categorySearches[key] > categorySearches[r.Term+"\x00"+t.InferredCategory] {
+			t.InferredCategory = r.InferredCategory
+		}
+	}
+
+	stats := make([]TermStats, 0, len(terms))
+	for _, term := range terms {
+		stats = append(stats, *byTerm[term])
+	}
+	return stats, nil
+}
+
+func (s *service) Aggregate(ctx context.Context) error {
+	today := startOfDay(time.Now())
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		if err := s.repo.ReplaceDailyStats(ctx, day); err != nil {
+			return fmt.Errorf("aggregate %s: %w", day.Format("2006-01-02"), err)
+		}
+	}
+	return nil
+}
+
+func (s *service) Prune(ctx context.Context) error {
+	now := time.Now().UTC()
+	raw, err := s.repo.DeleteBefore(ctx, now.Add(-RawRetention))
+	if err != nil {
+		return err
+	}
+	daily, err := s.repo.DeleteDailyStatsBefore(ctx, startOfDay(now.Add(-DailyRetention)))
+	if err != nil {
+		return err
+	}
+	s.log.Info("Search log pruned", logger.Field("raw_deleted", raw), logger.Field("daily_deleted", daily))
+	return nil
+}
+
+func (s *service) Start(ctx context.Context) {
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		aggregate := time.NewTicker(aggregateInterval)
+		prune := time.NewTicker(retentionInterval)
+		defer aggregate.Stop()
+		defer prune.Stop()
+
+		s.runJob(ctx, "aggregate", s.Aggregate)
+		s.runJob(ctx, "prune", s.Prune)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-aggregate.C:
+				s.runJob(ctx, "aggregate", s.Aggregate)
+			case <-prune.C:
+				s.runJob(ctx, "prune", s.Prune)
+			}
+		}
+	}()
+}
+
+func (s *service) Close() {
+	s.pending.Wait()
+}
+
+func (s *service) runJob(ctx context.Context, name string, job func(context.Context) error) {
+	if err := job(ctx); err != nil {
+		s.log.Warn("Search log job failed", logger.Str("job", name), logger.Err(err))
+	}
+}
+
+// normalizeTerm is the form searches are grouped by: lower-case single-spaced words.
+func normalizeTerm(term string) string {
+	return strings.Join(product.SearchTokens(term), " ")
+}
+
+// startOfDay returns UTC midnight of t's UTC day. Rollup days are UTC dates, so
+// they mean the same whatever the server's or database's time zone.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func head(stats []TermStats, limit int) []TermStats {
+	if limit > 0 && len(stats) > limit {
+		return stats[:limit]
+	}
+	return stats
+}
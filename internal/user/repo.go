@@ -1,9 +1,11 @@
 package user
 
+import "context"
+
 type Repo interface {
-	GetAll() ([]*User, error)
-	GetByID(id string) (*User, error)
-	Create(u *User) error
-	CheckEmailExists(email string) (bool, error)
-	GetByEmail(email string) (*User, error)
+	GetAll(ctx context.Context) ([]*User, error)
+	GetByID(ctx context.Context, id string) (*User, error)
+	Create(ctx context.Context, u *User) error
+	CheckEmailExists(ctx context.Context, email string) (bool, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
 }
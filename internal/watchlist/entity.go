@@ -0,0 +1,92 @@
+package watchlist
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AlertStatus is whether the user has seen an alert.
+type AlertStatus string
+
+const (
+	AlertUnread    AlertStatus = "UNREAD"
+	AlertRead      AlertStatus = "READ"
+	AlertDismissed AlertStatus = "DISMISSED"
+)
+
+// Watch asks to be alerted when a product drops to TargetPrice. It watches either a
+// search term, matched against the names of scraped listings, or one stored listing.
+type Watch struct {
+	ID     string `gorm:"type:varchar(36);primaryKey"`
+	UserID string `gorm:"type:varchar(36);not null;index"`
+	// Term is the search term as entered; empty for a listing watch.
+	Term string `gorm:"type:varchar(255)"`
+	// ListingID is the watched listing; nil for a term watch.
+	ListingID   *uint   `gorm:"index"`
+	TargetPrice float64 `gorm:"not null"`
+	// Platforms restricts the watch to these platforms; empty means any.
+	Platforms []string `gorm:"type:varchar(1024);serializer:json"`
+	// PriceMatchAt is the retailer the user shops at. When set, only offers that
+	// retailer would price-match count, at the price it would match them to.
+	PriceMatchAt string `gorm:"type:varchar(64)"`
+	// ExpiresAt ends the watch; nil watches until it is deleted.
+	ExpiresAt *time.Time `gorm:"index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Watch) TableName() string { return "watches" }
+
+func (w *Watch) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Active reports whether the watch is still evaluated at now.
+func (w *Watch) Active(now time.Time) bool {
+	return w.ExpiresAt == nil || now.Before(*w.ExpiresAt)
+}
+
+// Alert is a listing that reached a watch's target price. A watch has one alert per
+// listing, which is raised again, unread, when the price drops further.
+type Alert struct {
+	ID          string  `gorm:"type:varchar(36);primaryKey"`
+	WatchID     string  `gorm:"type:varchar(36);not null;uniqueIndex:idx_watch_alerts_watch_listing,priority:1"`
+	UserID      string  `gorm:"type:varchar(36);not null;index:idx_watch_alerts_user_status,priority:1"`
+	ListingID   uint    `gorm:"not null;uniqueIndex:idx_watch_alerts_watch_listing,priority:2"`
+	Platform    string  `gorm:"type:varchar(64);not null"`
+	ProductName string  `gorm:"type:varchar(255);not null"`
+	Link        string  `gorm:"type:varchar(700);not null"`
+	Price       float64 `gorm:"not null"`
+	// MatchPrice is what the watch's PriceMatchAt retailer would charge; nil without one.
+	MatchPrice  *float64
+	TargetPrice float64     `gorm:"not null"`
+	Status      AlertStatus `gorm:"type:varchar(16);not null;index:idx_watch_alerts_user_status,priority:2"`
+	// RaisedAt is when the alert was last raised.
+	RaisedAt  time.Time `gorm:"not null"`
+	ReadAt    *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Alert) TableName() string { return "watch_alerts" }
+
+func (a *Alert) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// EffectivePrice is the price the alert was raised for: the match price when the
+// watch goes through a price match, otherwise the listing's price.
+func (a *Alert) EffectivePrice() float64 {
+	if a.MatchPrice != nil {
+		return *a.MatchPrice
+	}
+	return a.Price
+}
@@ -0,0 +1,40 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrWatchNotFound is returned for a watch that doesn't exist or isn't the user's.
+	ErrWatchNotFound = errors.New("watch not found")
+	// ErrAlertNotFound is returned for an alert that doesn't exist or isn't the user's.
+	ErrAlertNotFound = errors.New("watch alert not found")
+)
+
+// Repo defines the interface for watchlist persistence.
+type Repo interface {
+	// Create stores a new watch.
+	Create(ctx context.Context, w *Watch) error
+	// ListByUser returns a user's watches, newest first.
+	ListByUser(ctx context.Context, userID string) ([]Watch, error)
+	// Delete deletes one of a user's watches with its alerts, or returns ErrWatchNotFound.
+	Delete(ctx context.Context, userID, id string) error
+	// Active returns the watches active at now that could match the given listings:
+	// every term watch, and the listing watches of those listings.
+	Active(ctx context.Context, now time.Time, listingIDs []uint) ([]Watch, error)
+	// WatchedListings returns the listings of active listing watches.
+	WatchedListings(ctx context.Context, now time.Time) ([]uint, error)
+
+	// Alerts returns a user's alerts, newest first, optionally only with the given status.
+	Alerts(ctx context.Context, userID string, status AlertStatus) ([]Alert, error)
+	// AlertsFor returns the alerts of the given watches for the given listings.
+	AlertsFor(ctx context.Context, watchIDs []string, listingIDs []uint) ([]Alert, error)
+	// GetAlert returns one of a user's alerts, or ErrAlertNotFound.
+	GetAlert(ctx context.Context, userID, id string) (*Alert, error)
+	// SaveAlert creates or updates an alert.
+	SaveAlert(ctx context.Context, a *Alert) error
+	// MarkRead marks a user's unread alerts among ids as read at t, returning how many there were.
+	MarkRead(ctx context.Context, userID string, ids []string, t time.Time) (int64, error)
+}
@@ -0,0 +1,368 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/notify"
+	"never-price-match-server/internal/pricematch"
+	"never-price-match-server/internal/product"
+)
+
+var (
+	// ErrInvalidTarget is returned for a watch whose target price isn't positive.
+	ErrInvalidTarget = errors.New("target price must be positive")
+	// ErrInvalidSubject is returned unless a watch has exactly one of a term and a listing.
+	ErrInvalidSubject = errors.New("a watch needs either a search term or a listing")
+	// ErrExpired is returned for a watch that would expire before it is created.
+	ErrExpired = errors.New("watch expiry is in the past")
+)
+
+// NewWatch describes a watch to create.
+type NewWatch struct {
+	// Term or ListingID is what to watch; exactly one must be set.
+	Term         string
+	ListingID    *uint
+	TargetPrice  float64
+	Platforms    []string
+	PriceMatchAt string
+	ExpiresAt    *time.Time
+}
+
+// Service defines the business logic interface for watchlists. It is also the scrape
+// observer that raises alerts, and the source of watched listings to keep fresh.
+type Service interface {
+	// Create stores a watch for a user.
+	Create(ctx context.Context, userID string, in NewWatch) (*Watch, error)
+	// List returns a user's watches, expired ones included, newest first.
+	List(ctx context.Context, userID string) ([]Watch, error)
+	// Delete removes one of a user's watches with its alerts.
+	Delete(ctx context.Context, userID, id string) error
+	// Alerts returns a user's alerts, newest first; an empty status returns all of them.
+	Alerts(ctx context.Context, userID string, status AlertStatus) ([]Alert, error)
+	// MarkRead marks a user's unread alerts among ids as read and returns how many there were.
+	MarkRead(ctx context.Context, userID string, ids []string) (int, error)
+	// Dismiss marks one of a user's alerts as dismissed.
+	Dismiss(ctx context.Context, userID, id string) (*Alert, error)
+	// ListingsScraped evaluates the active watches against freshly scraped listings in
+	// the background.
+	ListingsScraped(ctx context.Context, results []product.ScrapeResult)
+	// WatchedListings returns the listings of active listing watches.
+	WatchedListings(ctx context.Context) ([]uint, error)
+	// Close waits for evaluations still running.
+	Close()
+}
+
+type service struct {
+	repo     Repo
+	listings product.Repo
+	notifier notify.Service
+	log      *logger.Logger
+
+	// pending tracks background evaluations, so Close can wait for them.
+	pending sync.WaitGroup
+}
+
+// NewService creates a new watchlist service instance. Watched listings are looked up
+// in listings, and users are told of raised alerts through notifier.
+func NewService(repo Repo, listings product.Repo, notifier notify.Service, log *logger.Logger) Service {
+	return &service{repo: repo, listings: listings, notifier: notifier, log: log}
+}
+
+func (s *service) Create(ctx context.Context, userID string, in NewWatch) (*Watch, error) {
+	term := strings.TrimSpace(in.Term)
+	if (term == "") == (in.ListingID == nil) {
+		return nil, ErrInvalidSubject
+	}
+	if in.TargetPrice <= 0 {
+		return nil, ErrInvalidTarget
+	}
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(time.Now()) {
+		return nil, ErrExpired
+	}
+	w := &Watch{
+		UserID:      userID,
+		Term:        term,
+		ListingID:   in.ListingID,
+		TargetPrice: in.TargetPrice,
+		ExpiresAt:   in.ExpiresAt,
+	}
+	if in.PriceMatchAt != "" {
+		policy, ok := pricematch.Lookup(in.PriceMatchAt)
+		if !ok {
+			return nil, fmt.Errorf("%w %q", pricematch.ErrUnknownRetailer, in.PriceMatchAt)
+		}
+		w.PriceMatchAt = policy.Retailer
+	}
+	for _, p := range in.Platforms {
+		if p = strings.TrimSpace(p); p != "" {
+			w.Platforms = append(w.Platforms, p)
+		}
+	}
+	if in.ListingID != nil {
+		if _, err := s.listings.GetProductByID(ctx, *in.ListingID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.Create(ctx, w); err != nil {
+		return nil, err
+	}
+	s.log.Info("watch created", logger.Str("id", w.ID))
+	return w, nil
+}
+
+func (s *service) List(ctx context.Context, userID string) ([]Watch, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *service) Delete(ctx context.Context, userID, id string) error {
+	return s.repo.Delete(ctx, userID, id)
+}
+
+func (s *service) Alerts(ctx context.Context, userID string, status AlertStatus) ([]Alert, error) {
+	return s.repo.Alerts(ctx, userID, status)
+}
+
+func (s *service) MarkRead(ctx context.Context, userID string, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	n, err := s.repo.MarkRead(ctx, userID, ids, time.Now())
+	return int(n), err
+}
+
+func (s *service) Dismiss(ctx context.Context, userID, id string) (*Alert, error) {
+	a, err := s.repo.GetAlert(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.Status == AlertDismissed {
+		return a, nil
+	}
+	a.Status = AlertDismissed
+	if a.ReadAt == nil {
+		now := time.Now()
+		a.ReadAt = &now
+	}
+	if err := s.repo.SaveAlert(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *service) WatchedListings(ctx context.Context) ([]uint, error) {
+	return s.repo.WatchedListings(ctx, time.Now())
+}
+
+// scrapedListing is a saved listing as one scrape found it.
+type scrapedListing struct {
+	platform string
+	product  product.ScrapedProduct
+}
+
+func (s *service) ListingsScraped(ctx context.Context, results []product.ScrapeResult) {
+	// Copy the listings now: the results go on to the caller, which may change them.
+	var listings []scrapedListing
+	for _, res := range results {
+		for _, p := range res.Products {
+			if p.ListingID != 0 {
+				listings = append(listings, scrapedListing{platform: res.Platform, product: p})
+			}
+		}
+	}
+	if len(listings) == 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		if err := s.evaluate(ctx, listings); err != nil {
+			s.log.Warn("Failed to evaluate watches", logger.Err(err))
+		}
+	}()
+}
+
+// evaluate raises an alert for every active watch a listing meets the target of,
+// unless the watch already has an alert for the listing at the same or a lower price.
+func (s *service) evaluate(ctx context.Context, listings []scrapedListing) error {
+	now := time.Now()
+	listingIDs := make([]uint, 0, len(listings))
+	for _, l := range listings {
+		listingIDs = append(listingIDs, l.product.ListingID)
+	}
+	watches, err := s.repo.Active(ctx, now, listingIDs)
+	if err != nil || len(watches) == 0 {
+		return err
+	}
+
+	var hits []*Alert
+	watchIDs := make([]string, 0, len(watches))
+	byID := make(map[string]Watch, len(watches))
+	for _, w := range watches {
+		watchIDs = append(watchIDs, w.ID)
+		byID[w.ID] = w
+		m := newMatcher(w)
+		for _, l := range listings {
+			if a := m.match(l); a != nil {
+				hits = append(hits, a)
+			}
+		}
+	}
+	if len(hits) == 0 {
+		return nil
+	}
+
+	existing, err := s.repo.AlertsFor(ctx, watchIDs, listingIDs)
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string]*Alert, len(existing))
+	for i, a := range existing {
+		byKey[alertKey(a.WatchID, a.ListingID)] = &existing[i]
+	}
+
+	raised := 0
+	for _, hit := range hits {
+		a := byKey[alertKey(hit.WatchID, hit.ListingID)]
+		switch {
+		case a == nil:
+			a = hit
+		case hit.EffectivePrice() < a.EffectivePrice():
+			a.Platform, a.ProductName, a.Link = hit.Platform, hit.ProductName, hit.Link
+			a.Price, a.MatchPrice, a.TargetPrice = hit.Price, hit.MatchPrice, hit.TargetPrice
+			a.ReadAt = nil
+		default:
+			continue
+		}
+		a.Status = AlertUnread
+		a.RaisedAt = now
+		if err := s.repo.SaveAlert(ctx, a); err != nil {
+			return err
+		}
+		byKey[alertKey(a.WatchID, a.ListingID)] = a
+		raised++
+		s.notify(ctx, byID[a.WatchID], a)
+	}
+	if raised > 0 {
+		s.log.Info("Watch alerts raised", logger.Int("alerts", raised))
+	}
+	return nil
+}
+
+// notify tells the watch's owner about a raised alert. A failure is only logged: the
+// alert itself is saved and shows in the user's watch alerts either way.
+func (s *service) notify(ctx context.Context, w Watch, a *Alert) {
+	err := s.notifier.Notify(ctx, a.UserID, notify.KindWatchAlert, a.Link, notify.WatchAlert{
+		ProductName:  a.ProductName,
+		Platform:     a.Platform,
+		Price:        a.Price,
+		MatchPrice:   a.MatchPrice,
+		PriceMatchAt: w.PriceMatchAt,
+		TargetPrice:  a.TargetPrice,
+	})
+	if err != nil {
+		s.log.Warn("Failed to notify watch alert", logger.Str("alert", a.ID), logger.Err(err))
+	}
+}
+
+func (s *service) Close() {
+	s.pending.Wait()
+}
+
+func alertKey(watchID string, listingID uint) string {
+	return fmt.Sprintf("%s\x00%d", watchID, listingID)
+}
+
+// matcher decides which listings meet one watch.
+type matcher struct {
+	watch     Watch
+	tokens    []string
+	platforms map[string]bool
+	policy    *pricematch.Policy
+}
+
+func newMatcher(w Watch) *matcher {
+	m := &matcher{watch: w, tokens: product.SearchTokens(w.Term)}
+	if len(w.Platforms) > 0 {
+		m.platforms = make(map[string]bool, len(w.Platforms))
+		for _, p := range w.Platforms {
+			m.platforms[strings.ToLower(p)] = true
+		}
+	}
+	if w.PriceMatchAt != "" {
+		if policy, ok := pricematch.Lookup(w.PriceMatchAt); ok {
+			m.policy = &policy
+		}
+	}
+	return m
+}
+
+// match returns the alert a listing raises for the watch, or nil if it doesn't meet
+// it. Out-of-stock listings never do.
+func (m *matcher) match(l scrapedListing) *Alert {
+	p := l.product
+	if m.watch.ListingID != nil {
+		if *m.watch.ListingID != p.ListingID {
+			return nil
+		}
+	} else if !containsTokens(product.SearchTokens(p.Name), m.tokens) {
+		return nil
+	}
+	if m.platforms != nil && !m.platforms[strings.ToLower(l.platform)] {
+		return nil
+	}
+	if p.StockStatus == product.StockOutOfStock {
+		return nil
+	}
+
+	a := &Alert{
+		WatchID:     m.watch.ID,
+		UserID:      m.watch.UserID,
+		ListingID:   p.ListingID,
+		Platform:    l.platform,
+		ProductName: p.Name,
+		Link:        p.Link,
+		Price:       p.Price,
+		TargetPrice: m.watch.TargetPrice,
+	}
+	if m.policy != nil {
+		// A listing watch wants the model of the listing itself.
+		query := m.watch.Term
+		if query == "" {
+			query = p.Name
+		}
+		offer := pricematch.EvaluateOffer(*m.policy, query, l.platform, p)
+		if !offer.Eligible {
+			return nil
+		}
+		matchPrice := offer.MatchPrice
+		a.MatchPrice = &matchPrice
+	}
+	if a.EffectivePrice() > m.watch.TargetPrice {
+		return nil
+	}
+	return a
+}
+
+// containsTokens reports whether every token of want is in have.
+func containsTokens(have, want []string) bool {
+	if len(want) == 0 {
+		return false
+	}
+	set := make(map[string]bool, len(have))
+	for _, t := range have {
+		set[t] = true
+	}
+	for _, t := range want {
+		if !set[t] {
+			return false
+		}
+	}
+	return true
+}
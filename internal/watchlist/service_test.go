@@ -0,0 +1,191 @@
+package watchlist
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"never-price-match-server/internal/infra/logger"
+	"never-price-match-server/internal/notify"
+	"never-price-match-server/internal/product"
+)
+
+// The fakes embed their interface, so calling anything they don't override panics.
+type fakeRepo struct {
+	Repo
+	watches  []Watch
+	existing []Alert
+	saved    []Alert
+}
+
+func (r *fakeRepo) Active(context.Context, time.Time, []uint) ([]Watch, error) { return r.watches, nil }
+
+func (r *fakeRepo) AlertsFor(context.Context, []string, []uint) ([]Alert, error) {
+	return slices.Clone(r.existing), nil
+}
+
+func (r *fakeRepo) SaveAlert(_ context.Context, a *Alert) error {
+	if a.ID == "" {
+		a.ID = "new"
+	}
+	r.saved = append(r.saved, *a)
+	return nil
+}
+
+type fakeNotifier struct {
+	notify.Service
+	sent int
+}
+
+func (f *fakeNotifier) Notify(context.Context, string, string, string, any) error {
+	f.sent++
+	return nil
+}
+
+func listing(id uint, platform, name string, price float64) scrapedListing {
+	return scrapedListing{platform: platform, product: product.ScrapedProduct{ListingID: id, Name: name, Price: price}}
+}
+
+func TestMatch(t *testing.T) {
+	id := uint(7)
+	outOfStock := listing(7, "Amazon AU", "Sony XM5 Headphones", 299)
+	outOfStock.product.StockStatus = product.StockOutOfStock
+	cases := []struct {
+		name      string
+		watch     Watch
+		listing   scrapedListing
+		wantPrice *float64 // the alert's effective price; nil for no alert
+	}{
+		{"below target", Watch{Term: "sony xm5", TargetPrice: 350}, listing(7, "Amazon AU", "Sony XM5 Headphones", 299), ptr(299.0)},
+		{"at target", Watch{Term: "sony xm5", TargetPrice: 299}, listing(7, "Amazon AU", "Sony XM5", 299), ptr(299.0)},
+		{"a cent above target", Watch{Term: "sony xm5", TargetPrice: 299}, listing(7, "Amazon AU", "Sony XM5", 299.01), nil},
+		{"term not in the name", Watch{Term: "sony xm4", TargetPrice: 350}, listing(7, "Amazon AU", "Sony XM5", 299), nil},
+		{"out of stock", Watch{Term: "sony xm5", TargetPrice: 350}, outOfStock, nil},
+		{"watched listing", Watch{ListingID: &id, TargetPrice: 350}, listing(7, "Amazon AU", "Sony XM5", 299), ptr(299.0)},
+		{"other listing", Watch{ListingID: &id, TargetPrice: 350}, listing(8, "Amazon AU", "Sony XM5", 299), nil},
+		{"watched platform", Watch{Term: "sony xm5", TargetPrice: 350, Platforms: []string{"amazon au"}}, listing(7, "Amazon AU", "Sony XM5", 299), ptr(299.0)},
+		{"other platform", Watch{Term: "sony xm5", TargetPrice: 350, Platforms: []string{"Big W"}}, listing(7, "Amazon AU", "Sony XM5", 299), nil},
+		// Officeworks beats matched prices by 5%: 369 is matched at 350.55.
+		{"match price below target", Watch{Term: "sony wh-1000xm5", TargetPrice: 351, PriceMatchAt: "Officeworks"}, listing(7, "Amazon AU", "Sony WH-1000XM5", 369), ptr(350.55)},
+		{"match price above target", Watch{Term: "sony wh-1000xm5", TargetPrice: 350, PriceMatchAt: "Officeworks"}, listing(7, "Amazon AU", "Sony WH-1000XM5", 369), nil},
+		{"not price-matched", Watch{Term: "sony wh-1000xm5", TargetPrice: 350, PriceMatchAt: "JB Hi-Fi"}, listing(7, "Kmart", "Sony WH-1000XM5", 299), nil},
+	}
+	for _, c := range cases {
+		a := newMatcher(c.watch).match(c.listing)
+		switch {
+		case (a == nil) != (c.wantPrice == nil):
+			t.Errorf("%s: alert %+v, want price %v", c.name, a, c.wantPrice)
+		case a != nil && a.EffectivePrice() != *c.wantPrice:
+			t.Errorf("%s: alert at %v, want %v", c.name, a.EffectivePrice(), *c.wantPrice)
+		}
+	}
+}
+
+func TestEvaluateDeduplicates(t *testing.T) {
+	watch := Watch{ID: "w1", UserID: "u1", Term: "sony xm5", TargetPrice: 350}
+	read := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	alert := func(status AlertStatus, price float64) Alert {
+		return Alert{ID: "a1", WatchID: "w1", UserID: "u1", ListingID: 7, Price: price, TargetPrice: 350, Status: status, ReadAt: &read}
+	}
+	cases := []struct {
+		name     string
+		watches  []Watch
+		existing []Alert
+		listings []scrapedListing
+
+		wantSaved []string // IDs of the saved alerts, in order
+		wantPrice float64  // the price of the last saved alert
+	}{
+		{
+			name:      "first time at target",
+			listings:  []scrapedListing{listing(7, "Amazon AU", "Sony XM5", 329)},
+			wantSaved: []string{"new"},
+			wantPrice: 329,
+		},
+		{
+			name:     "same price again",
+			existing: []Alert{alert(AlertRead, 329)},
+			listings: []scrapedListing{listing(7, "Amazon AU", "Sony XM5", 329)},
+		},
+		{
+			name:     "price back up",
+			existing: []Alert{alert(AlertUnread, 299)},
+			listings: []scrapedListing{listing(7, "Amazon AU", "Sony XM5", 329)},
+		},
+		{
+			name:      "dropped further",
+			existing:  []Alert{alert(AlertRead, 329)},
+			listings:  []scrapedListing{listing(7, "Amazon AU", "Sony XM5", 319)},
+			wantSaved: []string{"a1"},
+			wantPrice: 319,
+		},
+		{
+			name:      "dismissed, dropped further",
+			existing:  []Alert{alert(AlertDismissed, 329)},
+			listings:  []scrapedListing{listing(7, "Amazon AU", "Sony XM5", 319)},
+			wantSaved: []string{"a1"},
+			wantPrice: 319,
+		},
+		{
+			name:     "dismissed, same price",
+			existing: []Alert{alert(AlertDismissed, 329)},
+			listings: []scrapedListing{listing(7, "Amazon AU", "Sony XM5", 329)},
+		},
+		{
+			name:      "listed twice in one scrape",
+			listings:  []scrapedListing{listing(7, "Amazon AU", "Sony XM5", 329), listing(7, "Amazon AU", "Sony XM5", 329)},
+			wantSaved: []string{"new"},
+			wantPrice: 329,
+		},
+		{
+			name:      "cheaper copy later in the scrape",
+			listings:  []scrapedListing{listing(7, "Amazon AU", "Sony XM5", 329), listing(7, "Amazon AU", "Sony XM5", 319)},
+			wantSaved: []string{"new", "new"},
+			wantPrice: 319,
+		},
+		{
+			name:      "another user's watch",
+			watches:   []Watch{watch, {ID: "w2", UserID: "u2", Term: "xm5", TargetPrice: 330}},
+			existing:  []Alert{alert(AlertRead, 329)},
+			listings:  []scrapedListing{listing(7, "Amazon AU", "Sony XM5", 329)},
+			wantSaved: []string{"new"},
+			wantPrice: 329,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			watches := c.watches
+			if watches == nil {
+				watches = []Watch{watch}
+			}
+			repo := &fakeRepo{watches: watches, existing: c.existing}
+			notifier := &fakeNotifier{}
+			s := NewService(repo, nil, notifier, logger.Nop()).(*service)
+
+			if err := s.evaluate(context.Background(), c.listings); err != nil {
+				t.Fatal(err)
+			}
+			var saved []string
+			for _, a := range repo.saved {
+				saved = append(saved, a.ID)
+				if a.Status != AlertUnread || a.ReadAt != nil {
+					t.Errorf("alert %s saved %s, read at %v; want unread", a.ID, a.Status, a.ReadAt)
+				}
+			}
+			if !slices.Equal(saved, c.wantSaved) {
+				t.Fatalf("saved alerts %v, want %v", saved, c.wantSaved)
+			}
+			if notifier.sent != len(c.wantSaved) {
+				t.Errorf("sent %d notifications, want %d", notifier.sent, len(c.wantSaved))
+			}
+			if len(saved) > 0 {
+				if got := repo.saved[len(saved)-1].Price; got != c.wantPrice {
+					t.Errorf("alert raised at %v, want %v", got, c.wantPrice)
+				}
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }